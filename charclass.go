@@ -3,9 +3,9 @@ package httpheader
 import "strings"
 
 // classify scans s to determine how it can be represented on the wire.
-// tokenOK means s is a simple RFC 7230 token.
+// tokenOK means s is a simple RFC 9110 token.
 // quotedOK means s consists entirely of characters that can be represented in
-// an RFC 7230 quoted-string, with the exception of bytes 0x80..0xFF (obs-text),
+// an RFC 9110 quoted-string, with the exception of bytes 0x80..0xFF (obs-text),
 // which are poorly supported (at best, they are interpreted as ISO-8859-1, which
 // is unlikely to be useful).
 // quotedSafe means that, in addition to quotedOK, s doesn't contain delimiters
@@ -37,6 +37,12 @@ func isToken(s string) bool {
 	return s != ""
 }
 
+// isQuotable reports whether b can appear in a field value, inside
+// a quoted-string or a comment (qdtext, ctext, quoted-pair, or obs-text).
+func isQuotable(b byte) bool {
+	return b == '\t' || (b >= 0x20 && b != 0x7F)
+}
+
 func isLower(s string) bool {
 	for i := 0; i < len(s); i++ {
 		if 'A' <= s[i] && s[i] <= 'Z' {
@@ -58,7 +64,7 @@ const (
 var byteClass [256]charClass
 
 func init() {
-	for i := 0; i < 0xFF; i++ {
+	for i := range byteClass {
 		b := byte(i)
 		switch {
 		case b < 0x20 || b > 0x7E:
@@ -0,0 +1,437 @@
+// Command httpheader parses the headers of an HTTP message with package
+// httpheader and prints the result, for inspecting captured traffic.
+//
+// Usage:
+//
+//	httpheader [flags] [file]
+//
+// The input, read from file or from standard input, is a raw HTTP request
+// or response, or just a block of header fields. Any message body is ignored.
+// For each header, httpheader prints its field values, the structure parsed
+// from them, and any problems found by httpheader.Lint. Headers that the
+// package doesn't support are printed as is.
+//
+// The flags are:
+//
+//	-json
+//		Print the result as JSON instead of a table.
+//	-roundtrip
+//		Serialize each parsed header back with the corresponding Set
+//		function and show how it differs from the original.
+//	-response
+//		Treat a bare header block as a response header, not a request header.
+//		Messages with a start line are recognized automatically.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vfaronov/httpheader"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("httpheader", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: httpheader [flags] [file]")
+		flags.PrintDefaults()
+	}
+	asJSON := flags.Bool("json", false, "print the result as JSON")
+	roundTrip := flags.Bool("roundtrip", false, "re-serialize parsed headers and show differences")
+	response := flags.Bool("response", false, "treat a bare header block as a response")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	input := stdin
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defer f.Close()
+		input = f
+	default:
+		flags.Usage()
+		return 2
+	}
+
+	h, isRequest, err := readHeader(input, !*response)
+	if err != nil {
+		fmt.Fprintln(stderr, "httpheader:", err)
+		return 1
+	}
+	fields := inspect(h, isRequest, *roundTrip)
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(fields)
+	} else {
+		err = printTable(stdout, fields)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "httpheader:", err)
+		return 1
+	}
+	return 0
+}
+
+// readHeader reads the header block of an HTTP message from r. If the message
+// has a start line, it determines whether the message is a request;
+// otherwise, defaultRequest is returned.
+func readHeader(r io.Reader, defaultRequest bool) (h http.Header, isRequest bool, err error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, false, err
+	}
+	// Terminate the header block in case the input is truncated after it.
+	data = append(bytes.TrimRight(data, "\r\n"), "\r\n\r\n"...)
+
+	isRequest = defaultRequest
+	end := bytes.IndexByte(data, '\n')
+	switch line := string(bytes.TrimSpace(data[:end])); {
+	case strings.HasPrefix(line, "HTTP/"):
+		isRequest = false
+		data = data[end+1:]
+	case isRequestLine(line):
+		isRequest = true
+		data = data[end+1:]
+	}
+	tp := textproto.NewReader(bufio.NewReader(bytes.NewReader(data)))
+	mh, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return nil, false, err
+	}
+	return http.Header(mh), isRequest, nil
+}
+
+func isRequestLine(line string) bool {
+	parts := strings.Fields(line)
+	return len(parts) == 3 && strings.HasPrefix(parts[2], "HTTP/") &&
+		!strings.Contains(parts[0], ":")
+}
+
+// A field is the result of inspecting one header.
+type field struct {
+	Name        string
+	Values      []string
+	Parsed      interface{}          `json:",omitempty"`
+	Problems    []httpheader.Problem `json:",omitempty"`
+	Regenerated []string             `json:",omitempty"`
+	Changed     bool                 `json:",omitempty"`
+}
+
+func inspect(h http.Header, isRequest, roundTrip bool) []field {
+	problems := make(map[string][]httpheader.Problem)
+	for _, problem := range httpheader.Lint(h, isRequest) {
+		problems[problem.Header] = append(problems[problem.Header], problem)
+	}
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fields := make([]field, 0, len(names))
+	for _, name := range names {
+		f := field{Name: name, Values: h[name], Problems: problems[name]}
+		if c, ok := codecs[name]; ok {
+			f.Parsed = c.parse(h)
+			if roundTrip && c.set != nil {
+				regenerated := http.Header{}
+				c.set(regenerated, f.Parsed)
+				f.Regenerated = regenerated[name]
+				f.Changed = strings.Join(f.Regenerated, ", ") != strings.Join(f.Values, ", ")
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func printTable(w io.Writer, fields []field) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range fields {
+		for _, v := range f.Values {
+			fmt.Fprintf(bw, "%s: %s\n", f.Name, v)
+		}
+		if _, ok := codecs[f.Name]; !ok {
+			fmt.Fprintf(bw, "\t(not supported)\n")
+		} else {
+			parsed, err := json.Marshal(f.Parsed)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(bw, "\t= %s\n", parsed)
+		}
+		for _, problem := range f.Problems {
+			fmt.Fprintf(bw, "\t! %s: %s (%s)\n", problem.Severity, problem.Msg, problem.Ref)
+		}
+		if f.Changed {
+			for _, v := range f.Values {
+				fmt.Fprintf(bw, "\t- %s\n", v)
+			}
+			for _, v := range f.Regenerated {
+				fmt.Fprintf(bw, "\t+ %s\n", v)
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// A codec parses and serializes one header. set is nil for headers
+// that the package can only parse.
+type codec struct {
+	parse func(h http.Header) interface{}
+	set   func(h http.Header, v interface{})
+}
+
+type contentType struct {
+	Type   string
+	Params map[string]string `json:",omitempty"`
+}
+
+type contentDisposition struct {
+	Type     string
+	Filename string            `json:",omitempty"`
+	Params   map[string]string `json:",omitempty"`
+}
+
+var codecs = map[string]codec{
+	"Accept": {
+		func(h http.Header) interface{} { return httpheader.Accept(h) },
+		func(h http.Header, v interface{}) { httpheader.SetAccept(h, v.([]httpheader.AcceptElem)) },
+	},
+	"Accept-Ranges": {
+		func(h http.Header) interface{} { return httpheader.AcceptRanges(h) },
+		func(h http.Header, v interface{}) { httpheader.SetAcceptRanges(h, v.([]string)) },
+	},
+	"Allow": {
+		func(h http.Header) interface{} { return httpheader.Allow(h) },
+		func(h http.Header, v interface{}) { httpheader.SetAllow(h, v.([]string)) },
+	},
+	"Authorization": {
+		func(h http.Header) interface{} { return httpheader.Authorization(h) },
+		func(h http.Header, v interface{}) { httpheader.SetAuthorization(h, v.(httpheader.Auth)) },
+	},
+	"Cache-Control": {
+		func(h http.Header) interface{} { return httpheader.CacheControl(h) },
+		func(h http.Header, v interface{}) {
+			httpheader.SetCacheControl(h, v.(httpheader.CacheDirectives))
+		},
+	},
+	"Connection": {
+		func(h http.Header) interface{} { return httpheader.Connection(h) },
+		func(h http.Header, v interface{}) { httpheader.SetConnection(h, v.([]string)) },
+	},
+	"Content-Disposition": {
+		func(h http.Header) interface{} {
+			var cd contentDisposition
+			cd.Type, cd.Filename, cd.Params = httpheader.ContentDisposition(h)
+			return cd
+		},
+		func(h http.Header, v interface{}) {
+			cd := v.(contentDisposition)
+			httpheader.SetContentDisposition(h, cd.Type, cd.Filename, cd.Params)
+		},
+	},
+	"Content-Location": {
+		func(h http.Header) interface{} {
+			if u := httpheader.ContentLocation(h, nil); u != nil {
+				return u.String()
+			}
+			return nil
+		},
+		func(h http.Header, v interface{}) {
+			if s, ok := v.(string); ok {
+				u, _ := url.Parse(s)
+				httpheader.SetContentLocation(h, u)
+			}
+		},
+	},
+	"Content-Type": {
+		func(h http.Header) interface{} {
+			var ct contentType
+			ct.Type, ct.Params = httpheader.ContentType(h)
+			return ct
+		},
+		func(h http.Header, v interface{}) {
+			ct := v.(contentType)
+			httpheader.SetContentType(h, ct.Type, ct.Params)
+		},
+	},
+	"Date": {
+		func(h http.Header) interface{} {
+			if t, err := http.ParseTime(h.Get("Date")); err == nil {
+				return t
+			}
+			return nil
+		},
+		func(h http.Header, v interface{}) {
+			if t, ok := v.(time.Time); ok {
+				h.Set("Date", t.UTC().Format(http.TimeFormat))
+			}
+		},
+	},
+	// The package has no ETag parser (see SetETag), but an ETag
+	// is parsed like an If-Match with one element.
+	"Etag": {
+		func(h http.Header) interface{} {
+			tags := httpheader.IfMatch(http.Header{"If-Match": h["Etag"]})
+			if len(tags) == 1 && tags[0] != httpheader.AnyTag {
+				return tags[0]
+			}
+			return nil
+		},
+		func(h http.Header, v interface{}) {
+			if tag, ok := v.(httpheader.EntityTag); ok {
+				httpheader.SetETag(h, tag)
+			}
+		},
+	},
+	"Forwarded": {
+		func(h http.Header) interface{} { return httpheader.Forwarded(h) },
+		func(h http.Header, v interface{}) { httpheader.SetForwarded(h, v.([]httpheader.ForwardedElem)) },
+	},
+	"If-Match": {
+		func(h http.Header) interface{} { return httpheader.IfMatch(h) },
+		setTags("If-Match"),
+	},
+	"If-None-Match": {
+		func(h http.Header) interface{} { return httpheader.IfNoneMatch(h) },
+		setTags("If-None-Match"),
+	},
+	"Link": {
+		func(h http.Header) interface{} { return httpheader.Link(h, nil) },
+		func(h http.Header, v interface{}) { httpheader.SetLink(h, v.([]httpheader.LinkElem)) },
+	},
+	"Link-Template": {
+		func(h http.Header) interface{} { return httpheader.LinkTemplate(h) },
+		func(h http.Header, v interface{}) {
+			httpheader.SetLinkTemplate(h, v.([]httpheader.LinkTemplateElem))
+		},
+	},
+	"Max-Forwards": {
+		func(h http.Header) interface{} {
+			if n, ok := httpheader.MaxForwards(h); ok {
+				return n
+			}
+			return nil
+		},
+		func(h http.Header, v interface{}) {
+			if n, ok := v.(int); ok {
+				httpheader.SetMaxForwards(h, n)
+			}
+		},
+	},
+	"Prefer": {
+		func(h http.Header) interface{} { return httpheader.Prefer(h) },
+		func(h http.Header, v interface{}) { httpheader.SetPrefer(h, v.(map[string]httpheader.Pref)) },
+	},
+	"Preference-Applied": {
+		func(h http.Header) interface{} { return httpheader.PreferenceApplied(h) },
+		func(h http.Header, v interface{}) { httpheader.SetPreferenceApplied(h, v.(map[string]string)) },
+	},
+	"Priority": {
+		func(h http.Header) interface{} { return httpheader.Priority(h) },
+		func(h http.Header, v interface{}) { httpheader.SetPriority(h, v.(httpheader.PriorityParams)) },
+	},
+	"Proxy-Authenticate": {
+		func(h http.Header) interface{} { return httpheader.ProxyAuthenticate(h) },
+		func(h http.Header, v interface{}) { httpheader.SetProxyAuthenticate(h, v.([]httpheader.Auth)) },
+	},
+	"Proxy-Authorization": {
+		func(h http.Header) interface{} { return httpheader.ProxyAuthorization(h) },
+		func(h http.Header, v interface{}) { httpheader.SetProxyAuthorization(h, v.(httpheader.Auth)) },
+	},
+	"Retry-After": {
+		func(h http.Header) interface{} {
+			if t := httpheader.RetryAfter(h); !t.IsZero() {
+				return t
+			}
+			return nil
+		},
+		func(h http.Header, v interface{}) {
+			if t, ok := v.(time.Time); ok {
+				httpheader.SetRetryAfter(h, t)
+			}
+		},
+	},
+	"Server": {
+		func(h http.Header) interface{} { return httpheader.Server(h) },
+		func(h http.Header, v interface{}) { httpheader.SetServer(h, v.([]httpheader.Product)) },
+	},
+	"Te": {
+		func(h http.Header) interface{} { return httpheader.ListElems(h, "Te") },
+		func(h http.Header, v interface{}) { httpheader.SetListElems(h, "Te", v.([]httpheader.ListElem)) },
+	},
+	"User-Agent": {
+		func(h http.Header) interface{} { return httpheader.UserAgent(h) },
+		func(h http.Header, v interface{}) { httpheader.SetUserAgent(h, v.([]httpheader.Product)) },
+	},
+	"Vary": {
+		func(h http.Header) interface{} { return httpheader.Vary(h) },
+		func(h http.Header, v interface{}) { httpheader.SetVary(h, v.(map[string]bool)) },
+	},
+	"Via": {
+		func(h http.Header) interface{} { return httpheader.Via(h) },
+		func(h http.Header, v interface{}) { httpheader.SetVia(h, v.([]httpheader.ViaElem)) },
+	},
+	"Warning": {
+		func(h http.Header) interface{} { return httpheader.Warning(h) },
+		func(h http.Header, v interface{}) { httpheader.SetWarning(h, v.([]httpheader.WarningElem)) },
+	},
+	"Www-Authenticate": {
+		func(h http.Header) interface{} { return httpheader.WWWAuthenticate(h) },
+		func(h http.Header, v interface{}) { httpheader.SetWWWAuthenticate(h, v.([]httpheader.Auth)) },
+	},
+	"X-Forwarded-For":   xForwarded,
+	"X-Forwarded-Host":  xForwarded,
+	"X-Forwarded-Port":  xForwarded,
+	"X-Forwarded-Proto": xForwarded,
+}
+
+// setTags returns a set function for the header with the given name, which is
+// a list of entity tags. The package has no such function; see SetETag.
+func setTags(name string) func(h http.Header, v interface{}) {
+	return func(h http.Header, v interface{}) {
+		tags := v.([]httpheader.EntityTag)
+		if len(tags) == 0 {
+			h.Del(name)
+			return
+		}
+		items := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			text, _ := tag.MarshalText()
+			items = append(items, string(text))
+		}
+		h.Set(name, strings.Join(items, ", "))
+	}
+}
+
+// The X-Forwarded-* headers can only be parsed together, so each of them
+// shows the same elements, and is compared with its own part of the result
+// of regenerating them all.
+var xForwarded = codec{
+	func(h http.Header) interface{} { return httpheader.XForwarded(h) },
+	func(h http.Header, v interface{}) { httpheader.SetXForwarded(h, v.([]httpheader.ForwardedElem)) },
+}
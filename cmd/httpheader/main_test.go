@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const response = `HTTP/1.1 200 OK
+Content-Type: text/html;charset=UTF-8
+Cache-Control: no-store, max-age=60
+Vary: Accept-Encoding,  Accept
+X-Request-Id: 42
+
+<!doctype html>
+`
+
+func TestRunTable(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-roundtrip"}, strings.NewReader(response), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	expected := `Cache-Control: no-store, max-age=60
+	= {"NoStore":true,"NoTransform":false,"OnlyIfCached":false,"MustRevalidate":false,"Public":false,"ProxyRevalidate":false,"Immutable":false,"MustUnderstand":false,"NoCache":false,"Private":false,"NoCacheHeaders":null,"PrivateHeaders":null,"MaxAge":60,"SMaxage":null,"MinFresh":null,"StaleWhileRevalidate":null,"StaleIfError":null,"MaxStale":null,"Ext":null}
+	! warning: no-store conflicts with max-age (RFC 9111 Section 5.2.2.5)
+Content-Type: text/html;charset=UTF-8
+	= {"Type":"text/html","Params":{"charset":"UTF-8"}}
+Vary: Accept-Encoding,  Accept
+	= {"Accept":true,"Accept-Encoding":true}
+	- Accept-Encoding,  Accept
+	+ Accept, Accept-Encoding
+X-Request-Id: 42
+	(not supported)
+`
+	if stdout.String() != expected {
+		t.Errorf("expected:\n%s\nactual:\n%s", expected, stdout.String())
+	}
+}
+
+func TestRunXForwarded(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	input := "X-Forwarded-For: 192.0.2.60,  198.51.100.17\nX-Forwarded-Proto: https\n"
+	code := run([]string{"-roundtrip"}, strings.NewReader(input), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	parsed := `[{"By":"","For":"192.0.2.60","Host":"","Proto":"https","Ext":null},` +
+		`{"By":"","For":"198.51.100.17","Host":"","Proto":"","Ext":null}]`
+	expected := "X-Forwarded-For: 192.0.2.60,  198.51.100.17\n" +
+		"\t= " + parsed + "\n" +
+		"\t- 192.0.2.60,  198.51.100.17\n" +
+		"\t+ 192.0.2.60, 198.51.100.17\n" +
+		"X-Forwarded-Proto: https\n" +
+		"\t= " + parsed + "\n"
+	if stdout.String() != expected {
+		t.Errorf("expected:\n%s\nactual:\n%s", expected, stdout.String())
+	}
+}
+
+func TestRunETagDateTE(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	input := "HTTP/1.1 200 OK\n" +
+		"Date: Wed, 21 Oct 2015 07:28:00 GMT\n" +
+		"ETag: W/\"xyzzy\"\n" +
+		"TE: trailers,deflate;q=0.5\n" +
+		"If-None-Match: \"a\",W/\"b\"\n"
+	code := run([]string{"-roundtrip"}, strings.NewReader(input), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	expected := `Date: Wed, 21 Oct 2015 07:28:00 GMT
+	= "2015-10-21T07:28:00Z"
+Etag: W/"xyzzy"
+	= "W/\"xyzzy\""
+If-None-Match: "a",W/"b"
+	= ["\"a\"","W/\"b\""]
+	! warning: request header in a response (RFC 9110 Section 13.1.2)
+	- "a",W/"b"
+	+ "a", W/"b"
+Te: trailers,deflate;q=0.5
+	= [{"Name":"trailers","Value":"","EmptyValue":false,"Params":null},{"Name":"deflate","Value":"","EmptyValue":false,"Params":[{"Name":"q","Value":"0.5","EmptyValue":false}]}]
+	! warning: request header in a response (RFC 9110 Section 10.1.4)
+	- trailers,deflate;q=0.5
+	+ trailers, deflate;q=0.5
+`
+	if stdout.String() != expected {
+		t.Errorf("expected:\n%s\nactual:\n%s", expected, stdout.String())
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	input := "Accept: text/html;q=0.5\nServer: Apache\n"
+	if code := run([]string{"-json"}, strings.NewReader(input), &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	var fields []struct {
+		Name     string
+		Values   []string
+		Parsed   json.RawMessage
+		Problems []struct{ Severity, Msg string }
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &fields); err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 2 || fields[0].Name != "Accept" || fields[1].Name != "Server" {
+		t.Fatalf("unexpected fields: %s", stdout.String())
+	}
+	var parsed bytes.Buffer
+	json.Compact(&parsed, fields[0].Parsed)
+	if parsed.String() != `[{"Type":"text/html","Params":null,"Q":0.5,"Ext":null}]` {
+		t.Errorf("unexpected parsed Accept: %s", parsed.String())
+	}
+	if len(fields[1].Problems) != 1 || fields[1].Problems[0].Severity != "warning" {
+		t.Errorf("expected a warning about Server in a request, got %+v", fields[1].Problems)
+	}
+}
+
+func TestReadHeader(t *testing.T) {
+	tests := []struct {
+		input          string
+		defaultRequest bool
+		isRequest      bool
+		names          int
+	}{
+		{"GET / HTTP/1.1\r\nHost: example.com\r\n\r\n", false, true, 1},
+		{"HTTP/2 204\nVary: Accept\n", true, false, 1},
+		{"Vary: Accept\nAccept: */*", false, false, 2},
+		{"Vary: Accept\n", true, true, 1},
+		{"", true, true, 0},
+	}
+	for _, test := range tests {
+		h, isRequest, err := readHeader(strings.NewReader(test.input), test.defaultRequest)
+		if err != nil {
+			t.Errorf("reading %q: %v", test.input, err)
+			continue
+		}
+		if isRequest != test.isRequest || len(h) != test.names {
+			t.Errorf("reading %q: got isRequest=%v and %d headers", test.input, isRequest, len(h))
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-bogus"}, strings.NewReader(""), &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 for a bad flag, got %d", code)
+	}
+	if code := run([]string{"/nonexistent"}, strings.NewReader(""), &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 for a missing file, got %d", code)
+	}
+	if code := run(nil, strings.NewReader("bad header\n"), &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 for malformed input, got %d", code)
+	}
+}
@@ -156,6 +156,8 @@ func likeInt(rand *rand.Rand, ex int) int {
 		return 10 + rand.Intn(90)
 	case 9:
 		return rand.Intn(10)
+	case 7:
+		return rand.Intn(8)
 	case 0:
 		return 0
 	default:
@@ -208,6 +210,8 @@ func likeString(rand *rand.Rand, ex string) string {
 	case "URL":
 		u := randURL(rand)
 		s = u.String()
+	case "language":
+		s = randString(rand, alnum) + "-" + randString(rand, alnum)
 	case "_obfID":
 		s = "_" + randString(rand, alnum+"._-")
 	default:
@@ -233,7 +237,7 @@ const (
 	alpha   = hialpha + loalpha
 	alnum   = digit + alpha
 
-	// RFC 7230 Section 3.2.6.
+	// RFC 9110 Section 5.6.2.
 	tchar = "!#$%&'*+-.^_`|~" + alnum
 	// Characters that can be represented inside a quoted-string or comment.
 	quotable = "\t !\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~" + alnum +
@@ -9,15 +9,36 @@ Add method), which, although correct, is poorly supported by some recipients.
 FooBar parses all valid Foo-Bar headers, but many invalid headers are also
 tolerated and parsed to some extent. FooBar never errors, instead returning
 whatever it can easily salvage. Do not assume that strings returned by FooBar
-conform to the grammar of the protocol.
+conform to the grammar of the protocol. Use Check to detect malformed headers,
+or StrictFooBar to check and parse the header at once.
+To bound the work done on hostile input, FooBar truncates headers that exceed
+DefaultLimits; to apply other Limits, use their FooBar method.
+ScanList provides a lower-level, allocation-free way to walk list headers.
 
 Likewise, SetFooBar doesn't validate parameter names or other tokens you supply.
 However, it will automatically quote and escape your text where the grammar
-admits an arbitrary quoted string or comment (RFC 7230 Section 3.2.6), such as
-in parameter values.
+admits an arbitrary quoted string or comment (RFC 9110 Section 5.6.4), such as
+in parameter values. SetFooBar writes map entries in order of their keys, so its
+output is deterministic. To preserve the original order of elements and
+parameters instead, use ListElems and SetListElems.
 
 Tokens that are known to be case-insensitive, like directive or parameter names,
 are lowercased by FooBar. Any slices or maps returned by FooBar may be nil
 when there is no corresponding input data.
+
+All functions work on an http.Header, which includes trailers such as
+http.Response's Trailer (after the body has been read). A textproto.MIMEHeader,
+such as the header of a multipart part, can be converted to http.Header
+without copying. To take only the needed headers from any FieldSource,
+including raw bytes adapted with RawFields, use Fields.
+
+The package follows RFC 9110 and RFC 9111, which obsolete RFC 7230 through
+RFC 7235. The few functions whose results changed between them, like Accept
+and Check, keep the old behavior, to give existing code time to migrate,
+and have counterparts like AcceptRFC9110 that follow RFC 9110.
+Functions for the obsolete Warning header are deprecated.
+
+Element types like AcceptElem implement encoding.TextMarshaler, producing their
+wire form, and have a JSON representation suitable for structured logging.
 */
 package httpheader
@@ -0,0 +1,87 @@
+package httpheader
+
+import (
+	"net/http"
+	"strings"
+)
+
+// A ListElem is one element of a header with the common structure
+// name[=value] *( ";" param[=value] ), such as Accept, Cache-Control, Prefer
+// or Content-Type. Unlike the maps returned by functions like Prefer,
+// a ListElem preserves the order of parameters, as well as any duplicates,
+// through parsing with ListElems and serialization with SetListElems.
+type ListElem struct {
+	Name       string
+	Value      string
+	EmptyValue bool // Value is empty but present, as in `foo=""`
+	Params     []ListParam
+}
+
+// A ListParam is one parameter of a ListElem.
+type ListParam struct {
+	Name       string
+	Value      string
+	EmptyValue bool // Value is empty but present, as in `;foo=""`
+}
+
+// ListElems parses the header with the given name from h into a ListElem
+// for each element, in order. The name must be in canonical form.
+// Names are not lowercased, and values are unquoted. Elements without a name,
+// such as "=x", are skipped. See also ScanList.
+func ListElems(h http.Header, name string) []ListElem {
+	return DefaultLimits().ListElems(h, name)
+}
+
+// ListElems is like the ListElems function, but applies l instead of
+// DefaultLimits.
+func (l Limits) ListElems(h http.Header, name string) []ListElem {
+	values := l.values(h[name])
+	if values == nil {
+		return nil
+	}
+	elems := make([]ListElem, 0, estimateElems(values))
+	s := Scanner{vs: values}
+	for s.Next() && !l.elemsDone(len(elems)) {
+		if s.Name() == "" {
+			continue
+		}
+		elem := ListElem{Name: s.Name(), Value: s.Value(),
+			EmptyValue: s.HasValue() && s.Value() == ""}
+		for s.NextParam() && !l.paramsDone(len(elem.Params)) {
+			elem.Params = append(elem.Params, ListParam{s.ParamName(), s.ParamValue(),
+				s.HasParamValue() && s.ParamValue() == ""})
+		}
+		elems = append(elems, elem)
+	}
+	return elems
+}
+
+// SetListElems replaces the header with the given name in h with elems,
+// in order. Values are quoted as necessary. An empty Value is omitted
+// together with its equals sign, as in "no-cache" or ";foo", unless
+// EmptyValue is true, in which case it is written as `=""`.
+func SetListElems(h http.Header, name string, elems []ListElem) {
+	if len(elems) == 0 {
+		h.Del(name)
+		return
+	}
+	b := &strings.Builder{}
+	var wrote bool
+	for _, elem := range elems {
+		wrote = writeDirective(b, wrote, elem.Name, elem.Value)
+		if elem.Value == "" && elem.EmptyValue {
+			write(b, `=""`)
+		}
+		for _, param := range elem.Params {
+			write(b, ";", param.Name)
+			switch {
+			case param.Value != "":
+				write(b, "=")
+				writeTokenOrQuoted(b, param.Value)
+			case param.EmptyValue:
+				write(b, `=""`)
+			}
+		}
+	}
+	h.Set(name, b.String())
+}
@@ -0,0 +1,192 @@
+package httpheader
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func ExampleListElems() {
+	header := http.Header{"Content-Type": {`text/plain; format=flowed; Charset="UTF-8"`}}
+	elems := ListElems(header, "Content-Type")
+	elems[0].Params = append(elems[0].Params, ListParam{Name: "delsp", Value: "yes"})
+	SetListElems(header, "Content-Type", elems)
+	fmt.Println(header.Get("Content-Type"))
+	// Output: text/plain;format=flowed;Charset=UTF-8;delsp=yes
+}
+
+func TestListElems(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		result []ListElem
+	}{
+		{
+			http.Header{},
+			nil,
+		},
+		{
+			http.Header{"Foo": {""}},
+			[]ListElem{},
+		},
+		{
+			http.Header{"Foo": {
+				`wait=10; z=1; a=2; z=3, respond-async`,
+				`handling="lenient, please"`,
+			}},
+			[]ListElem{
+				{
+					Name:  "wait",
+					Value: "10",
+					Params: []ListParam{
+						{Name: "z", Value: "1"},
+						{Name: "a", Value: "2"},
+						{Name: "z", Value: "3"},
+					},
+				},
+				{Name: "respond-async"},
+				{Name: "handling", Value: "lenient, please"},
+			},
+		},
+		{
+			http.Header{"Foo": {"=bar;baz, qux"}},
+			[]ListElem{{Name: "qux"}},
+		},
+		{
+			http.Header{"Foo": {`a="";b=;c, d=, e`}},
+			[]ListElem{
+				{
+					Name:       "a",
+					EmptyValue: true,
+					Params: []ListParam{
+						{Name: "b", EmptyValue: true},
+						{Name: "c"},
+					},
+				},
+				{Name: "d", EmptyValue: true},
+				{Name: "e"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			checkParse(t, test.header, test.result, ListElems(test.header, "Foo"))
+		})
+	}
+}
+
+func TestSetListElems(t *testing.T) {
+	tests := []struct {
+		input  []ListElem
+		result http.Header
+	}{
+		{
+			[]ListElem{},
+			http.Header{},
+		},
+		{
+			[]ListElem{
+				{Name: "max-age", Value: "60"},
+				{Name: "no-cache", Value: "Set-Cookie, Set-Cookie2"},
+				{Name: "private"},
+			},
+			http.Header{"Foo": {`max-age=60, no-cache="Set-Cookie, Set-Cookie2", private`}},
+		},
+		{
+			[]ListElem{
+				{
+					Name: "text/html",
+					Params: []ListParam{
+						{Name: "q", Value: "0.9"},
+						{Name: "level", Value: "1"},
+						{Name: "foo", Value: ""},
+						{Name: "bar", Value: "", EmptyValue: true},
+					},
+				},
+			},
+			http.Header{"Foo": {`text/html;q=0.9;level=1;foo;bar=""`}},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			header := http.Header{"Foo": {"old"}}
+			SetListElems(header, "Foo", test.input)
+			checkGenerate(t, test.input, test.result, header)
+		})
+	}
+}
+
+func TestListElemsFuzz(t *testing.T) {
+	checkFuzz(t, "Foo",
+		func(h http.Header) []ListElem { return ListElems(h, "Foo") },
+		func(h http.Header, elems []ListElem) { SetListElems(h, "Foo", elems) })
+}
+
+func TestListElemsRoundTrip(t *testing.T) {
+	checkRoundTrip(t,
+		func(h http.Header, elems []ListElem) {
+			// Canonicalize the input in place: EmptyValue is meaningful
+			// only with an empty Value.
+			for i := range elems {
+				elems[i].EmptyValue = elems[i].EmptyValue && elems[i].Value == ""
+				for j := range elems[i].Params {
+					param := &elems[i].Params[j]
+					param.EmptyValue = param.EmptyValue && param.Value == ""
+				}
+			}
+			SetListElems(h, "Foo", elems)
+		},
+		func(h http.Header) []ListElem { return ListElems(h, "Foo") },
+		[]ListElem{{
+			Name:   "token",
+			Value:  "quotable | empty",
+			Params: []ListParam{{Name: "token", Value: "quotable"}},
+		}},
+	)
+}
+
+// TestGenerateDeterministic checks that generators produce the same output
+// for the same input, regardless of map iteration order.
+func TestGenerateDeterministic(t *testing.T) {
+	generators := []func(h http.Header, r *rand.Rand){
+		func(h http.Header, r *rand.Rand) {
+			SetAccept(h, likeExample(r, []AcceptElem{{
+				Type:   "token/token",
+				Params: map[string]string{"token": "quotable"},
+				Q:      0.5,
+				Ext:    map[string]string{"token": "quotable | empty"},
+			}}).([]AcceptElem))
+		},
+		func(h http.Header, r *rand.Rand) {
+			SetCacheControl(h, CacheDirectives{
+				Ext: likeExample(r, map[string]string{"token": "quotable"}).(map[string]string),
+			})
+		},
+		func(h http.Header, r *rand.Rand) {
+			SetPrefer(h, likeExample(r, map[string]Pref{"token": {
+				Value:  "quotable | empty",
+				Params: map[string]string{"token": "quotable | empty"},
+			}}).(map[string]Pref))
+		},
+		func(h http.Header, r *rand.Rand) {
+			SetPreferenceApplied(h,
+				likeExample(r, map[string]string{"token": "quotable"}).(map[string]string))
+		},
+		func(h http.Header, r *rand.Rand) {
+			SetVary(h, likeExample(r, map[string]bool{"token": true}).(map[string]bool))
+		},
+	}
+	for i := 0; i < 100; i++ {
+		for _, generate := range generators {
+			h1, h2 := http.Header{}, http.Header{}
+			generate(h1, rand.New(rand.NewSource(int64(i))))
+			for j := 0; j < 10; j++ {
+				generate(h2, rand.New(rand.NewSource(int64(i))))
+				if !reflect.DeepEqual(h1, h2) {
+					t.Fatalf("nondeterministic output:\n%#v\n%#v", h1, h2)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,607 @@
+//go:build go1.18
+// +build go1.18
+
+package httpheader
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Native fuzz targets, one per header or format. The seed corpus for each
+// is in testdata/fuzz/FuzzFooBar, and runs as part of go test. To fuzz, run:
+//
+//	go test -fuzz=FuzzFooBar
+//
+// The input is split on newlines into field lines. For any input, valid
+// or not, the parser and generator must not panic, must finish in bounded
+// time, and must round-trip: parse(set(parse(x))) == parse(x), where
+// the outer parse applies no limits.
+
+// fuzzTimeout bounds the time spent on one input, which is no longer
+// than a few kilobytes in practice, much less than the default MaxBytes.
+const fuzzTimeout = time.Second
+
+// inTime runs f, failing t if it does not return within fuzzTimeout.
+// f runs in its own goroutine, so that a hang is reported, too.
+func inTime(t *testing.T, x string, f func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	timer := time.NewTimer(fuzzTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		t.Fatalf("no result after %v on %q", fuzzTimeout, x)
+	}
+}
+
+func fuzzHeader(
+	f *testing.F,
+	name string,
+	parse func(l Limits, h http.Header) interface{},
+	set func(h http.Header, v interface{}),
+) {
+	fuzzHeaders(f, func(x string) http.Header {
+		return http.Header{name: strings.Split(x, "\n")}
+	}, parse, set)
+}
+
+// fuzzHeaders is like fuzzHeader, but header builds the input header from x.
+func fuzzHeaders(
+	f *testing.F,
+	header func(x string) http.Header,
+	parse func(l Limits, h http.Header) interface{},
+	set func(h http.Header, v interface{}),
+) {
+	f.Fuzz(func(t *testing.T, x string) {
+		var parsed, regenerated interface{}
+		inTime(t, x, func() {
+			parsed = parse(DefaultLimits(), header(x))
+			if set != nil {
+				regenerated = roundTrip(parse, set, parsed)
+			}
+		})
+		if set != nil && !sameParse(parsed, regenerated) {
+			t.Fatalf("round-trip failure on %q\nparsed:      %#v\nregenerated: %#v",
+				x, parsed, regenerated)
+		}
+	})
+}
+
+func roundTrip(
+	parse func(l Limits, h http.Header) interface{},
+	set func(h http.Header, v interface{}),
+	v interface{},
+) interface{} {
+	header := http.Header{}
+	set(header, v)
+	// The generated header may exceed the limits that v was parsed with,
+	// such as when a Link element with several relation types becomes
+	// several elements that repeat the other parameters.
+	return parse(Limits{}, header)
+}
+
+// sameParse is like reflect.DeepEqual, except that it treats nil and empty
+// slices and maps as equal, because Set functions delete empty headers,
+// and URLs as equal if they serialize the same, because a URL can be parsed
+// from more than one form, such as a space and "%20".
+func sameParse(a, b interface{}) bool {
+	return sameValue(reflect.ValueOf(a), reflect.ValueOf(b))
+}
+
+var urlType = reflect.TypeOf(url.URL{})
+
+func sameValue(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+	if a.Type() == urlType {
+		ua, ub := a.Interface().(url.URL), b.Interface().(url.URL)
+		return ua.String() == ub.String()
+	}
+	switch a.Kind() {
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !sameValue(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for _, key := range a.MapKeys() {
+			if !b.MapIndex(key).IsValid() || !sameValue(a.MapIndex(key), b.MapIndex(key)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !sameValue(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return sameValue(a.Elem(), b.Elem())
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+	case reflect.String:
+		return a.String() == b.String()
+	}
+	// Values of unexported fields cannot be passed to reflect.DeepEqual,
+	// but the kinds above cover all such fields in this package.
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+func FuzzAccept(f *testing.F) {
+	fuzzHeader(f, "Accept",
+		func(l Limits, h http.Header) interface{} { return l.Accept(h) },
+		func(h http.Header, v interface{}) { SetAccept(h, v.([]AcceptElem)) })
+}
+
+func FuzzAcceptRFC9110(f *testing.F) {
+	fuzzHeader(f, "Accept",
+		func(l Limits, h http.Header) interface{} { return l.AcceptRFC9110(h) },
+		func(h http.Header, v interface{}) { SetAccept(h, v.([]AcceptElem)) })
+}
+
+func FuzzAcceptRanges(f *testing.F) {
+	fuzzHeader(f, "Accept-Ranges",
+		func(l Limits, h http.Header) interface{} { return l.AcceptRanges(h) },
+		func(h http.Header, v interface{}) { SetAcceptRanges(h, v.([]string)) })
+}
+
+func FuzzAllow(f *testing.F) {
+	fuzzHeader(f, "Allow",
+		func(l Limits, h http.Header) interface{} { return l.Allow(h) },
+		func(h http.Header, v interface{}) { SetAllow(h, v.([]string)) })
+}
+
+func FuzzAuthorization(f *testing.F) {
+	fuzzHeader(f, "Authorization",
+		func(l Limits, h http.Header) interface{} { return l.Authorization(h) },
+		func(h http.Header, v interface{}) { SetAuthorization(h, v.(Auth)) })
+}
+
+func FuzzCacheControl(f *testing.F) {
+	fuzzHeader(f, "Cache-Control",
+		func(l Limits, h http.Header) interface{} { return l.CacheControl(h) },
+		func(h http.Header, v interface{}) { SetCacheControl(h, v.(CacheDirectives)) })
+}
+
+func FuzzConnection(f *testing.F) {
+	fuzzHeader(f, "Connection",
+		func(l Limits, h http.Header) interface{} { return l.Connection(h) },
+		func(h http.Header, v interface{}) { SetConnection(h, v.([]string)) })
+}
+
+type fuzzDisposition struct {
+	dtype, filename string
+	params          map[string]string
+}
+
+func FuzzContentDisposition(f *testing.F) {
+	fuzzHeader(f, "Content-Disposition",
+		func(l Limits, h http.Header) interface{} {
+			var d fuzzDisposition
+			d.dtype, d.filename, d.params = l.ContentDisposition(h)
+			return d
+		},
+		func(h http.Header, v interface{}) {
+			d := v.(fuzzDisposition)
+			SetContentDisposition(h, d.dtype, d.filename, d.params)
+		})
+}
+
+func FuzzContentLocation(f *testing.F) {
+	fuzzHeader(f, "Content-Location",
+		func(l Limits, h http.Header) interface{} { return l.ContentLocation(h, nil) },
+		func(h http.Header, v interface{}) { SetContentLocation(h, v.(*url.URL)) })
+}
+
+type fuzzContentType struct {
+	mtype  string
+	params map[string]string
+}
+
+func FuzzContentType(f *testing.F) {
+	fuzzHeader(f, "Content-Type",
+		func(l Limits, h http.Header) interface{} {
+			var ct fuzzContentType
+			ct.mtype, ct.params = l.ContentType(h)
+			return ct
+		},
+		func(h http.Header, v interface{}) {
+			ct := v.(fuzzContentType)
+			SetContentType(h, ct.mtype, ct.params)
+		})
+}
+
+func FuzzForwarded(f *testing.F) {
+	fuzzHeader(f, "Forwarded",
+		func(l Limits, h http.Header) interface{} {
+			// Forwarded keeps invalid obfuscated identifiers, but SetForwarded
+			// writes them as unknown.
+			elems := l.Forwarded(h)
+			for i := range elems {
+				elems[i].For = validNode(elems[i].For)
+				elems[i].By = validNode(elems[i].By)
+			}
+			return elems
+		},
+		func(h http.Header, v interface{}) { SetForwarded(h, v.([]ForwardedElem)) })
+}
+
+func validNode(node Node) Node {
+	if !isObfuscated(node.ObfuscatedNode) {
+		node.ObfuscatedNode = ""
+	}
+	if !isObfuscated(node.ObfuscatedPort) {
+		node.ObfuscatedPort = ""
+	}
+	return node
+}
+
+func FuzzIfMatch(f *testing.F) {
+	fuzzHeader(f, "If-Match",
+		func(l Limits, h http.Header) interface{} { return l.IfMatch(h) },
+		nil)
+}
+
+func FuzzIfNoneMatch(f *testing.F) {
+	fuzzHeader(f, "If-None-Match",
+		func(l Limits, h http.Header) interface{} { return l.IfNoneMatch(h) },
+		nil)
+}
+
+func FuzzLink(f *testing.F) {
+	fuzzHeader(f, "Link",
+		func(l Limits, h http.Header) interface{} { return l.Link(h, nil) },
+		func(h http.Header, v interface{}) { SetLink(h, v.([]LinkElem)) })
+}
+
+func FuzzLinkTemplate(f *testing.F) {
+	fuzzHeader(f, "Link-Template",
+		func(l Limits, h http.Header) interface{} { return l.LinkTemplate(h) },
+		func(h http.Header, v interface{}) { SetLinkTemplate(h, v.([]LinkTemplateElem)) })
+}
+
+func FuzzListElems(f *testing.F) {
+	fuzzHeader(f, "X-List",
+		func(l Limits, h http.Header) interface{} { return l.ListElems(h, "X-List") },
+		func(h http.Header, v interface{}) { SetListElems(h, "X-List", v.([]ListElem)) })
+}
+
+type fuzzMaxForwards struct {
+	n  int
+	ok bool
+}
+
+func FuzzMaxForwards(f *testing.F) {
+	fuzzHeader(f, "Max-Forwards",
+		func(l Limits, h http.Header) interface{} {
+			var mf fuzzMaxForwards
+			mf.n, mf.ok = l.MaxForwards(h)
+			return mf
+		},
+		func(h http.Header, v interface{}) {
+			if mf := v.(fuzzMaxForwards); mf.ok {
+				SetMaxForwards(h, mf.n)
+			}
+		})
+}
+
+func FuzzPrefer(f *testing.F) {
+	fuzzHeader(f, "Prefer",
+		func(l Limits, h http.Header) interface{} { return l.Prefer(h) },
+		func(h http.Header, v interface{}) { SetPrefer(h, v.(map[string]Pref)) })
+}
+
+func FuzzPreferenceApplied(f *testing.F) {
+	fuzzHeader(f, "Preference-Applied",
+		func(l Limits, h http.Header) interface{} { return l.PreferenceApplied(h) },
+		func(h http.Header, v interface{}) { SetPreferenceApplied(h, v.(map[string]string)) })
+}
+
+func FuzzPriority(f *testing.F) {
+	fuzzHeader(f, "Priority",
+		func(l Limits, h http.Header) interface{} { return l.Priority(h) },
+		func(h http.Header, v interface{}) { SetPriority(h, v.(PriorityParams)) })
+}
+
+func FuzzProxyAuthenticate(f *testing.F) {
+	fuzzHeader(f, "Proxy-Authenticate",
+		func(l Limits, h http.Header) interface{} { return l.ProxyAuthenticate(h) },
+		func(h http.Header, v interface{}) { SetProxyAuthenticate(h, v.([]Auth)) })
+}
+
+func FuzzProxyAuthorization(f *testing.F) {
+	fuzzHeader(f, "Proxy-Authorization",
+		func(l Limits, h http.Header) interface{} { return l.ProxyAuthorization(h) },
+		func(h http.Header, v interface{}) { SetProxyAuthorization(h, v.(Auth)) })
+}
+
+func FuzzRetryAfter(f *testing.F) {
+	// A fixed Date makes delay-seconds deterministic.
+	date := time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)
+	fuzzHeader(f, "Retry-After",
+		func(l Limits, h http.Header) interface{} {
+			h.Set("Date", date.Format(http.TimeFormat))
+			return l.RetryAfter(h)
+		},
+		func(h http.Header, v interface{}) {
+			if t := v.(time.Time); !t.IsZero() {
+				SetRetryAfter(h, t)
+			}
+		})
+}
+
+func FuzzServer(f *testing.F) {
+	fuzzHeader(f, "Server",
+		func(l Limits, h http.Header) interface{} { return l.Server(h) },
+		func(h http.Header, v interface{}) { SetServer(h, v.([]Product)) })
+}
+
+func FuzzUserAgent(f *testing.F) {
+	fuzzHeader(f, "User-Agent",
+		func(l Limits, h http.Header) interface{} { return l.UserAgent(h) },
+		func(h http.Header, v interface{}) { SetUserAgent(h, v.([]Product)) })
+}
+
+func FuzzVary(f *testing.F) {
+	fuzzHeader(f, "Vary",
+		func(l Limits, h http.Header) interface{} { return l.Vary(h) },
+		func(h http.Header, v interface{}) { SetVary(h, v.(map[string]bool)) })
+}
+
+func FuzzVia(f *testing.F) {
+	fuzzHeader(f, "Via",
+		func(l Limits, h http.Header) interface{} { return l.Via(h) },
+		func(h http.Header, v interface{}) { SetVia(h, v.([]ViaElem)) })
+}
+
+func FuzzWarning(f *testing.F) {
+	fuzzHeader(f, "Warning",
+		func(l Limits, h http.Header) interface{} { return l.Warning(h) },
+		func(h http.Header, v interface{}) { SetWarning(h, v.([]WarningElem)) })
+}
+
+// FuzzXForwarded takes the X-Forwarded-For, X-Forwarded-Proto,
+// X-Forwarded-Host and X-Forwarded-Port headers from consecutive lines
+// of the input.
+func FuzzXForwarded(f *testing.F) {
+	fuzzHeaders(f,
+		func(x string) http.Header {
+			h := http.Header{}
+			names := []string{"X-Forwarded-For", "X-Forwarded-Proto",
+				"X-Forwarded-Host", "X-Forwarded-Port"}
+			for i, line := range strings.SplitN(x, "\n", len(names)) {
+				h.Set(names[i], line)
+			}
+			return h
+		},
+		func(l Limits, h http.Header) interface{} { return l.XForwarded(h) },
+		func(h http.Header, v interface{}) { SetXForwarded(h, v.([]ForwardedElem)) })
+}
+
+func FuzzWWWAuthenticate(f *testing.F) {
+	fuzzHeader(f, "Www-Authenticate",
+		func(l Limits, h http.Header) interface{} { return l.WWWAuthenticate(h) },
+		func(h http.Header, v interface{}) { SetWWWAuthenticate(h, v.([]Auth)) })
+}
+
+// FuzzStructuredList checks ParseList and SerializeList directly, because
+// their errors make a stricter round-trip property possible: any List
+// that parses must serialize, and parse back to the same List.
+func FuzzStructuredList(f *testing.F) {
+	f.Fuzz(func(t *testing.T, x string) {
+		list, err := ParseList(strings.Split(x, "\n"))
+		if err != nil {
+			return
+		}
+		s, err := SerializeList(list)
+		if err != nil {
+			t.Fatalf("cannot serialize %#v parsed from %q: %v", list, x, err)
+		}
+		again, err := ParseList([]string{s})
+		if err != nil || !sameParse(list, again) {
+			t.Fatalf("round-trip failure on %q via %q: %#v, %v", x, s, again, err)
+		}
+	})
+}
+
+// FuzzStructuredDictionary is like FuzzStructuredList for Dictionary.
+func FuzzStructuredDictionary(f *testing.F) {
+	f.Fuzz(func(t *testing.T, x string) {
+		dict, err := ParseDictionary(strings.Split(x, "\n"))
+		if err != nil {
+			return
+		}
+		s, err := SerializeDictionary(dict)
+		if err != nil {
+			t.Fatalf("cannot serialize %#v parsed from %q: %v", dict, x, err)
+		}
+		again, err := ParseDictionary([]string{s})
+		if err != nil || !sameParse(dict, again) {
+			t.Fatalf("round-trip failure on %q via %q: %#v, %v", x, s, again, err)
+		}
+	})
+}
+
+// FuzzStructuredItem is like FuzzStructuredList for Item.
+func FuzzStructuredItem(f *testing.F) {
+	f.Fuzz(func(t *testing.T, x string) {
+		item, err := ParseItem(strings.Split(x, "\n"))
+		if err != nil {
+			return
+		}
+		s, err := SerializeItem(item)
+		if err != nil {
+			t.Fatalf("cannot serialize %#v parsed from %q: %v", item, x, err)
+		}
+		again, err := ParseItem([]string{s})
+		if err != nil || !sameParse(item, again) {
+			t.Fatalf("round-trip failure on %q via %q: %#v, %v", x, s, again, err)
+		}
+	})
+}
+
+func FuzzParseLinkset(f *testing.F) {
+	f.Fuzz(func(t *testing.T, x string) {
+		var links, again []LinkElem
+		inTime(t, x, func() {
+			links, _ = ParseLinkset([]byte(x), nil)
+			again, _ = Limits{}.ParseLinkset(MarshalLinkset(links), nil)
+		})
+		if !sameParse(links, again) {
+			t.Fatalf("round-trip failure on %q\nparsed:      %#v\nregenerated: %#v",
+				x, links, again)
+		}
+	})
+}
+
+// FuzzParseLinksetJSON parses the input as an application/linkset+json
+// document. If it is valid, the links must survive MarshalLinksetJSON,
+// which groups them by Anchor and Rel, so they are compared in that order.
+func FuzzParseLinksetJSON(f *testing.F) {
+	f.Fuzz(func(t *testing.T, x string) {
+		var links, again []LinkElem
+		var parseErr, err error
+		inTime(t, x, func() {
+			links, parseErr = ParseLinksetJSON([]byte(x), nil)
+			if parseErr != nil {
+				return
+			}
+			var doc []byte
+			if doc, err = MarshalLinksetJSON(links); err == nil {
+				again, err = Limits{}.ParseLinksetJSON(doc, nil)
+			}
+		})
+		if parseErr != nil {
+			return
+		}
+		if err != nil {
+			t.Fatalf("round-trip failure on %q: %v", x, err)
+		}
+		links, again = groupLinks(links), groupLinks(again)
+		if !sameParse(links, again) {
+			t.Fatalf("round-trip failure on %q\nparsed:      %#v\nregenerated: %#v",
+				x, links, again)
+		}
+	})
+}
+
+// groupLinks stably sorts links by Anchor, then Rel.
+func groupLinks(links []LinkElem) []LinkElem {
+	key := func(link LinkElem) string {
+		if link.Anchor == nil {
+			return "\x00" + link.Rel
+		}
+		return "\x01" + link.Anchor.String() + "\x00" + link.Rel
+	}
+	sort.SliceStable(links, func(i, j int) bool {
+		return key(links[i]) < key(links[j])
+	})
+	return links
+}
+
+func FuzzParseCoRELinkFormat(f *testing.F) {
+	origin := U("coap://example.com")
+	f.Fuzz(func(t *testing.T, x string) {
+		var links, again []LinkElem
+		inTime(t, x, func() {
+			links, _ = ParseCoRELinkFormat([]byte(x), origin)
+			again, _ = Limits{}.ParseCoRELinkFormat(
+				MarshalCoRELinkFormat(links, origin), origin)
+		})
+		if !sameParse(links, again) {
+			t.Fatalf("round-trip failure on %q\nparsed:      %#v\nregenerated: %#v",
+				x, links, again)
+		}
+	})
+}
+
+// FuzzExpandURITemplate expands the input template with variables of every
+// supported kind, whose values include value. The result must consist only
+// of characters allowed in a URI.
+func FuzzExpandURITemplate(f *testing.F) {
+	f.Fuzz(func(t *testing.T, template, value string) {
+		vars := map[string]interface{}{
+			"x":     value,
+			"list":  []string{value, "red", ""},
+			"keys":  map[string]string{"semi": ";", "x": value},
+			"pairs": [][2]string{{"b", value}, {"a", ","}},
+			"empty": "",
+			"undef": nil,
+		}
+		var expanded string
+		var err error
+		inTime(t, template, func() {
+			expanded, err = ExpandURITemplate(template, vars)
+		})
+		if err != nil {
+			return
+		}
+		for i := 0; i < len(expanded); i++ {
+			c := expanded[i]
+			switch {
+			case isAlpha(c), isDigit(c), strings.IndexByte("-._~:/?#[]@!$&'()*+,;=", c) != -1:
+			case c == '%' && i+2 < len(expanded) && isHex(expanded[i+1]) && isHex(expanded[i+2]):
+				i += 2
+			default:
+				t.Fatalf("bad character %q at offset %d of %q, expanded from %q with %q",
+					c, i, expanded, template, value)
+			}
+		}
+	})
+}
+
+// FuzzParsePreload parses the input as a Link header, and each of its links
+// that is a preload must survive Preload's Link method and SetLink.
+func FuzzParsePreload(f *testing.F) {
+	fuzzHeader(f, "Link",
+		func(l Limits, h http.Header) interface{} {
+			var preloads []Preload
+			for _, link := range l.Link(h, nil) {
+				if p, ok := ParsePreload(link); ok {
+					preloads = append(preloads, p)
+				}
+			}
+			return preloads
+		},
+		func(h http.Header, v interface{}) {
+			var links []LinkElem
+			for _, p := range v.([]Preload) {
+				links = append(links, p.Link())
+			}
+			SetLink(h, links)
+		})
+}
@@ -0,0 +1,79 @@
+//go:build ignore
+// +build ignore
+
+// This program generates linkrels.go from the XML form of the IANA
+// Link Relation Types registry in testdata/link-relations.xml, or in the file
+// named by its argument. Run it with "go generate". To update the registry
+// first, run "make linkrels".
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// registry is the part of the IANA registry XML that we need.
+type registry struct {
+	Registries []struct {
+		ID      string `xml:"id,attr"`
+		Records []struct {
+			Value string `xml:"value"`
+		} `xml:"record"`
+	} `xml:"registry"`
+}
+
+func main() {
+	log.SetFlags(0)
+	path := "testdata/link-relations.xml"
+	if len(os.Args) > 1 {
+		path = os.Args[1]
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer src.Close()
+	var reg registry
+	if err := xml.NewDecoder(src).Decode(&reg); err != nil {
+		log.Fatal(err)
+	}
+	var rels []string
+	for _, sub := range reg.Registries {
+		if sub.ID != "link-relations-1" {
+			continue
+		}
+		for _, record := range sub.Records {
+			rels = append(rels, strings.ToLower(strings.TrimSpace(record.Value)))
+		}
+	}
+	if len(rels) == 0 {
+		log.Fatal("unexpected registry format: no relation types")
+	}
+	sort.Strings(rels)
+
+	b := &bytes.Buffer{}
+	fmt.Fprintln(b, "// Code generated by gen_linkrels.go; DO NOT EDIT.")
+	fmt.Fprintln(b)
+	fmt.Fprintln(b, "package httpheader")
+	fmt.Fprintln(b)
+	fmt.Fprintln(b, "// registeredRels is the set of relation types in the IANA")
+	fmt.Fprintln(b, "// Link Relation Types registry, lowercased.")
+	fmt.Fprintln(b, "var registeredRels = map[string]bool{")
+	for _, rel := range rels {
+		fmt.Fprintf(b, "\t%q: true,\n", rel)
+	}
+	fmt.Fprintln(b, "}")
+	out, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("linkrels.go", out, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,163 @@
+package httpheader
+
+import "errors"
+
+// Limits bounds the work that parsers in this package do on one header,
+// so that hostile input, such as a 64 KB Accept, cannot cause outsized
+// CPU and memory use. A zero field means no limit.
+//
+// Every function in this package that parses a header applies DefaultLimits.
+// To apply other limits, call the method of Limits with the same name,
+// such as Limits{MaxElems: 16}.Accept(h).
+//
+// Parsers never fail because of limits. Instead, they truncate:
+// they ignore whole elements or parameters beyond the limit, but never
+// cut one short, which could change its meaning. Check, on the other hand,
+// reports a header exceeding any limit as a SyntaxError. Parsers of link
+// documents, such as ParseLinkset, apply DefaultDocumentLimits instead,
+// and report truncated links with ErrTruncated.
+type Limits struct {
+	// MaxBytes is the maximum total length of all field lines of a header.
+	// In a list header, such as Accept, any element that does not end
+	// within MaxBytes is ignored, along with all elements after it.
+	// Any other header exceeding MaxBytes, such as Content-Type, is ignored
+	// entirely, and a structured header, such as Priority, is treated
+	// as invalid.
+	MaxBytes int
+
+	// MaxElems is the maximum number of elements parsed from a list header,
+	// such as Accept, Link or Forwarded, or of products in User-Agent
+	// and Server. Any further elements are ignored.
+	MaxElems int
+
+	// MaxParams is the maximum number of parameters parsed from one element,
+	// or from a non-list header like Content-Type. Any further parameters
+	// are ignored, except those stored in a field of their own, such as
+	// the q of AcceptElem or the Anchor of LinkElem, which are always
+	// parsed.
+	MaxParams int
+
+	// MaxNesting is the maximum nesting depth of comments, such as in Via
+	// or User-Agent. A comment is cut off before an opening parenthesis
+	// that would exceed MaxNesting, and the rest of the field line
+	// is ignored.
+	MaxNesting int
+}
+
+// DefaultLimits returns the limits applied by all parsers in this package,
+// except ScanList, which never allocates, RemoveHopByHop and ViaLoop,
+// which must see the whole Connection or Via header to be safe, and parsers
+// of link documents (see DefaultDocumentLimits). They are generous enough
+// for any legitimate header.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxBytes:   16 << 10,
+		MaxElems:   1024,
+		MaxParams:  256,
+		MaxNesting: 16,
+	}
+}
+
+// DefaultDocumentLimits returns the limits applied by parsers of link
+// documents: ParseLinkset, ParseLinksetJSON and ParseCoRELinkFormat.
+// They allow a document of up to 1 MiB with up to 16384 links.
+func DefaultDocumentLimits() Limits {
+	return Limits{
+		MaxBytes:   1 << 20,
+		MaxElems:   16 << 10,
+		MaxParams:  256,
+		MaxNesting: 16,
+	}
+}
+
+// ErrTruncated is returned by parsers of link documents, such as ParseLinkset,
+// along with the links that they did parse, when the document exceeds
+// their Limits and some links were discarded.
+var ErrTruncated = errors.New("httpheader: document exceeds limits, links discarded")
+
+// values returns the elements of the list header vs that end
+// within l.MaxBytes total bytes.
+func (l Limits) values(vs []string) []string {
+	if l.MaxBytes <= 0 {
+		return vs
+	}
+	n := 0
+	for i, v := range vs {
+		if n+len(v) > l.MaxBytes {
+			truncated := make([]string, i+1)
+			copy(truncated, vs[:i])
+			truncated[i] = cutElems(v, l.MaxBytes-n)
+			return truncated
+		}
+		n += len(v)
+	}
+	return vs
+}
+
+// cutElems returns the elements of the comma-separated list v that end
+// before offset max, dropping the element that spans it. Commas inside
+// quoted strings, comments and angle brackets (as in Link) do not
+// separate elements.
+func cutElems(v string, max int) string {
+	cut, nesting := 0, 0
+	var closer byte // of the quoted string or URI reference we are in, if any
+	for i := 0; i < len(v) && i <= max; i++ {
+		c := v[i]
+		switch {
+		case closer != 0:
+			if c == '\\' && closer == '"' {
+				i++
+			} else if c == closer {
+				closer = 0
+			}
+		case nesting > 0:
+			switch c {
+			case '\\':
+				i++
+			case '(':
+				nesting++
+			case ')':
+				nesting--
+			}
+		case c == '"':
+			closer = '"'
+		case c == '<':
+			closer = '>'
+		case c == '(':
+			nesting++
+		case c == ',':
+			cut = i
+		}
+	}
+	return v[:cut]
+}
+
+// tooLong reports whether vs exceed l.MaxBytes in total.
+func (l Limits) tooLong(vs []string) bool {
+	if l.MaxBytes <= 0 {
+		return false
+	}
+	n := 0
+	for _, v := range vs {
+		n += len(v)
+	}
+	return n > l.MaxBytes
+}
+
+// value returns v, or an empty string if it exceeds l.MaxBytes.
+func (l Limits) value(v string) string {
+	if l.MaxBytes > 0 && len(v) > l.MaxBytes {
+		return ""
+	}
+	return v
+}
+
+// elemsDone reports whether n elements reach l.MaxElems.
+func (l Limits) elemsDone(n int) bool {
+	return l.MaxElems > 0 && n >= l.MaxElems
+}
+
+// paramsDone reports whether n parameters reach l.MaxParams.
+func (l Limits) paramsDone(n int) bool {
+	return l.MaxParams > 0 && n >= l.MaxParams
+}
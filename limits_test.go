@@ -0,0 +1,326 @@
+package httpheader
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func ExampleLimits() {
+	header := http.Header{"Accept": {strings.Repeat("text/html, ", 10000)}}
+	fmt.Println(len(Accept(header)))
+	fmt.Println(len(Limits{MaxElems: 3}.Accept(header)))
+	// Output:
+	// 1024
+	// 3
+}
+
+func TestLimits(t *testing.T) {
+	limits := Limits{MaxBytes: 40, MaxElems: 2, MaxParams: 2, MaxNesting: 2}
+	tests := []struct {
+		header http.Header
+		parse  func(l Limits, h http.Header) interface{}
+		result interface{}
+	}{
+		{
+			// q is parsed beyond MaxParams.
+			http.Header{"Accept": {"text/html;a=1;b=2;c=3;q=0, text/plain", "*/*"}},
+			func(l Limits, h http.Header) interface{} { return l.Accept(h) },
+			[]AcceptElem{
+				{Type: "text/html", Params: map[string]string{"a": "1", "b": "2"}, Q: 0},
+				{Type: "text/plain", Q: 1},
+			},
+		},
+		{
+			// An element that does not end within MaxBytes is dropped
+			// rather than cut short, which could lose its q=0.
+			http.Header{"Accept": {"text/plain, text/html;q=0"}},
+			func(l Limits, h http.Header) interface{} {
+				l.MaxBytes = 20
+				return l.Accept(h)
+			},
+			[]AcceptElem{{Type: "text/plain", Q: 1}},
+		},
+		{
+			http.Header{"Allow": {"GET, HEAD", "OPTIONS"}},
+			func(l Limits, h http.Header) interface{} { return l.Allow(h) },
+			[]string{"GET", "HEAD"},
+		},
+		{
+			// Cut off by MaxBytes in the middle of the second line.
+			http.Header{"Connection": {"close, upgrade", "keep-alive, te, foo, bar,baz"}},
+			func(l Limits, h http.Header) interface{} {
+				l.MaxElems = 0
+				return l.Connection(h)
+			},
+			[]string{"close", "upgrade", "keep-alive", "te", "foo", "bar"},
+		},
+		{
+			http.Header{"Vary": {"Accept, Origin, Cookie"}},
+			func(l Limits, h http.Header) interface{} { return l.Vary(h) },
+			map[string]bool{"Accept": true, "Origin": true},
+		},
+		{
+			http.Header{"User-Agent": {"A/1 (x (y (z)) w) B/2 C/3"}},
+			func(l Limits, h http.Header) interface{} { return l.UserAgent(h) },
+			[]Product{{Name: "A", Version: "1", Comment: "x (y "}},
+		},
+		{
+			http.Header{"Server": {"A/1 B/2 C/3"}},
+			func(l Limits, h http.Header) interface{} { return l.Server(h) },
+			[]Product{{Name: "A", Version: "1"}, {Name: "B", Version: "2"}},
+		},
+		{
+			http.Header{"Content-Type": {"text/plain; a=1; b=2; charset=utf-8"}},
+			func(l Limits, h http.Header) interface{} {
+				mtype, params := l.ContentType(h)
+				return []interface{}{mtype, params}
+			},
+			[]interface{}{"text/plain", map[string]string{"a": "1", "b": "2"}},
+		},
+		{
+			http.Header{"Content-Type": {"text/plain; charset=utf-8; format=flowed; delsp=yes"}},
+			func(l Limits, h http.Header) interface{} {
+				mtype, params := l.ContentType(h)
+				return []interface{}{mtype, params}
+			},
+			[]interface{}{"", map[string]string(nil)},
+		},
+		{
+			http.Header{"Cache-Control": {"no-store, no-cache, max-age=0"}},
+			func(l Limits, h http.Header) interface{} { return l.CacheControl(h) },
+			CacheDirectives{NoStore: true, NoCache: true},
+		},
+		{
+			// Not max-age=31.
+			http.Header{"Cache-Control": {"no-cache, max-age=31536000"}},
+			func(l Limits, h http.Header) interface{} {
+				l.MaxBytes = 20
+				return l.CacheControl(h)
+			},
+			CacheDirectives{NoCache: true},
+		},
+		{
+			http.Header{"Www-Authenticate": {`Basic a=1, b=2, c=3, realm="x"`}},
+			func(l Limits, h http.Header) interface{} { return l.WWWAuthenticate(h) },
+			[]Auth{{
+				Scheme: "basic",
+				Realm:  "x",
+				Params: map[string]string{"a": "1", "b": "2"},
+			}},
+		},
+		{
+			http.Header{"Forwarded": {"for=a;by=b;host=c;x=1;y=2;z=3, for=d", "for=e"}},
+			func(l Limits, h http.Header) interface{} {
+				l.MaxBytes = 0
+				return l.Forwarded(h)
+			},
+			[]ForwardedElem{
+				{
+					For:  Node{ObfuscatedNode: "a"},
+					By:   Node{ObfuscatedNode: "b"},
+					Host: "c",
+					Ext:  map[string]string{"x": "1", "y": "2"},
+				},
+				{For: Node{ObfuscatedNode: "d"}},
+			},
+		},
+		{
+			http.Header{"Link": {`</a>; rel="next prev last"`}},
+			func(l Limits, h http.Header) interface{} { return l.Link(h, nil) },
+			[]LinkElem{{Rel: "next", Target: U("/a")}, {Rel: "prev", Target: U("/a")}},
+		},
+		{
+			// The anchor is parsed beyond MaxParams.
+			http.Header{"Link": {`</a>; a=1; b=2; c=3; anchor="/b"; rel=x`}},
+			func(l Limits, h http.Header) interface{} { return l.Link(h, nil) },
+			[]LinkElem{{
+				Anchor: U("/b"),
+				Rel:    "x",
+				Target: U("/a"),
+				Ext:    map[string]string{"a": "1", "b": "2"},
+			}},
+		},
+		{
+			// Commas in URI references and quoted strings do not end
+			// an element.
+			http.Header{"Link": {`</a>; rel=next, </b,c>; rel=prev; title="d, e"`}},
+			func(l Limits, h http.Header) interface{} { return l.Link(h, nil) },
+			[]LinkElem{{Rel: "next", Target: U("/a")}},
+		},
+		{
+			nil,
+			func(l Limits, h http.Header) interface{} {
+				links, err := l.ParseLinkset([]byte("</a>; rel=next,\n</b>; rel=prev,\n</c>; rel=up\n"), nil)
+				return []interface{}{links, err}
+			},
+			[]interface{}{
+				[]LinkElem{{Rel: "next", Target: U("/a")}, {Rel: "prev", Target: U("/b")}},
+				ErrTruncated,
+			},
+		},
+		{
+			nil,
+			func(l Limits, h http.Header) interface{} {
+				links, err := l.ParseCoRELinkFormat([]byte("</a>,</b>,</c>"), nil)
+				return []interface{}{links, err}
+			},
+			[]interface{}{
+				[]LinkElem{{Rel: "hosts", Target: U("/a")}, {Rel: "hosts", Target: U("/b")}},
+				ErrTruncated,
+			},
+		},
+		{
+			http.Header{"Prefer": {"a, b, c"}},
+			func(l Limits, h http.Header) interface{} { return l.Prefer(h) },
+			map[string]Pref{"a": {}, "b": {}},
+		},
+		{
+			http.Header{"Priority": {"u=1, i, x=?0"}},
+			func(l Limits, h http.Header) interface{} { return l.Priority(h) },
+			DefaultPriority,
+		},
+		{
+			http.Header{"X-Foo": {"a;p=1;q=2;r=3, b, c"}},
+			func(l Limits, h http.Header) interface{} { return l.ListElems(h, "X-Foo") },
+			[]ListElem{
+				{Name: "a", Params: []ListParam{{Name: "p", Value: "1"}, {Name: "q", Value: "2"}}},
+				{Name: "b"},
+			},
+		},
+		{
+			http.Header{"X-Foo": {"aaa, bbb, ccc"}},
+			func(l Limits, h http.Header) interface{} {
+				l.MaxBytes, l.MaxElems = 10, 0
+				return l.ListElems(h, "X-Foo")
+			},
+			[]ListElem{{Name: "aaa"}, {Name: "bbb"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			checkParse(t, test.header, test.result, test.parse(limits, test.header))
+		})
+	}
+}
+
+func TestCutElems(t *testing.T) {
+	tests := []struct {
+		v      string
+		max    int
+		result string
+	}{
+		{"a, b, c", 3, "a"},
+		{"a, b, c", 4, "a, b"},
+		{"a, b, c", 5, "a, b"},
+		{"abc", 1, ""},
+		{`"x,y", z`, 3, ""},
+		{`"x\",y", z`, 8, `"x\",y"`},
+		{"(a, (b, c)), d", 12, "(a, (b, c))"},
+		{"(a, (b, c)), d", 8, ""},
+		{"<a,b>, <c>", 8, "<a,b>"},
+	}
+	for _, test := range tests {
+		t.Run(test.v, func(t *testing.T) {
+			if actual := cutElems(test.v, test.max); actual != test.result {
+				t.Errorf("expected %q, got %q", test.result, actual)
+			}
+		})
+	}
+}
+
+func TestLimitsDisabled(t *testing.T) {
+	header := http.Header{"Accept": {strings.Repeat("a/b;p=1;q=1;r, ", 2000)}}
+	accept := Limits{}.Accept(header)
+	if len(accept) != 2000 || len(accept[0].Params) != 1 || len(accept[0].Ext) != 1 {
+		t.Errorf("unexpected truncation: %d elements", len(accept))
+	}
+}
+
+func TestViaLoopIgnoresLimits(t *testing.T) {
+	// A comment too deep for Via, which ignores the rest of the line.
+	depth := DefaultLimits().MaxNesting + 1
+	hop := "1.1 a " + strings.Repeat("(", depth) + strings.Repeat(")", depth) + ", "
+	header := http.Header{"Via": {
+		strings.Repeat(hop, DefaultLimits().MaxElems) + "1.1 proxy",
+	}}
+	loop, hops := ViaLoop(header, "proxy")
+	if !loop || hops != DefaultLimits().MaxElems+1 {
+		t.Errorf("expected a loop after %d hops, got %v, %d",
+			DefaultLimits().MaxElems+1, loop, hops)
+	}
+	if loop, _ := ViaLoop(header, "unrelated"); loop {
+		t.Errorf("unexpected loop")
+	}
+}
+
+func TestRemoveHopByHopIgnoresLimits(t *testing.T) {
+	header := http.Header{
+		"Connection": {strings.Repeat("a, ", DefaultLimits().MaxElems) + "x-secret"},
+		"X-Secret":   {"1"},
+	}
+	RemoveHopByHop(header)
+	checkGenerate(t, nil, http.Header{}, header)
+}
+
+func TestCheckLimits(t *testing.T) {
+	limits := Limits{MaxBytes: 40, MaxElems: 2, MaxParams: 2, MaxNesting: 2}
+	tests := []struct {
+		header http.Header
+		result error
+	}{
+		{
+			http.Header{"Allow": {"GET, HEAD"}},
+			nil,
+		},
+		{
+			http.Header{"Allow": {"GET", "HEAD, PUT"}},
+			&SyntaxError{"Allow", 2, 6, "more than 2 elements"},
+		},
+		{
+			http.Header{"Vary": {"Accept-Encoding, Accept-Language", "User-Agent"}},
+			&SyntaxError{"Vary", 2, 8, "longer than 40 bytes"},
+		},
+		{
+			http.Header{"Accept": {"text/html;a=1;b=2;q=0.5"}},
+			&SyntaxError{"Accept", 1, 17, "more than 2 parameters"},
+		},
+		{
+			http.Header{"User-Agent": {"A/1 (x (y (z)))"}},
+			&SyntaxError{"User-Agent", 1, 10, "comments nested deeper than 2"},
+		},
+		{
+			http.Header{"User-Agent": {"A/1 (x) B/2 C/3"}},
+			&SyntaxError{"User-Agent", 1, 12, "more than 2 products"},
+		},
+		{
+			http.Header{"X-Unknown": {strings.Repeat("x", 100)}},
+			nil,
+		},
+		{
+			http.Header{"Priority": {"u=1, i, x"}},
+			&SyntaxError{"Priority", 1, 8, "more than 2 members"},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			checkParse(t, test.header, test.result, limits.Check(test.header))
+		})
+	}
+}
+
+func BenchmarkAcceptHostile(b *testing.B) {
+	header := http.Header{"Accept": {strings.Repeat("a/b;c=d, ", 64<<10/9)}}
+	for i := 0; i < b.N; i++ {
+		Accept(header)
+	}
+}
+
+func BenchmarkLinkHostile(b *testing.B) {
+	header := http.Header{"Link": {strings.Repeat("<a>;rel=x, ", 64<<10/11)}}
+	base := U("https://example.com/")
+	for i := 0; i < b.N; i++ {
+		Link(header, base)
+	}
+}
@@ -0,0 +1,345 @@
+package httpheader
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Severity classifies a Problem found by Lint.
+type Severity int
+
+// Severities of problems, from least to most severe.
+const (
+	SeverityInfo    Severity = iota // worth knowing, but harmless
+	SeverityWarning                 // likely to be misunderstood by recipients
+	SeverityError                   // violates the protocol
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// A Problem is a finding reported by Lint.
+type Problem struct {
+	Header   string // canonical name of the header
+	Severity Severity
+	Msg      string
+	Ref      string // the relevant specification, such as "RFC 9111 Section 5.2"
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", p.Severity, p.Header, p.Msg, p.Ref)
+}
+
+// Lint diagnoses problems with the headers in h, which belong to a request
+// if isRequest is true, or to a response otherwise. Problems are returned
+// in order of header name.
+//
+// Lint checks headers against their grammar, like Check, but reports all
+// malformed headers instead of the first one. Where it can tell why a header
+// is malformed, such as an unquoted IPv6 address in Forwarded, it reports that
+// instead of the syntax error. It also looks for headers that
+// are well-formed but questionable, such as conflicting Cache-Control
+// directives, weak entity-tags in If-Match, unbracketed IPv6 addresses
+// in Forwarded, non-ASCII plain filenames in Content-Disposition, request
+// headers sent in a response and vice versa, and the obsolete Warning header.
+// Lint is meant for tests and diagnostic tools; see also LintHandler.
+func Lint(h http.Header, isRequest bool) []Problem {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var problems []Problem
+	for _, name := range names {
+		values := h[name]
+		name = http.CanonicalHeaderKey(name)
+		ref := lintRefs[name]
+		if err := DefaultLimits().checkHeader(name, values, false); err != nil {
+			if rule := syntaxRules[name]; rule != nil {
+				if found := rule(nil, values); len(found) > 0 {
+					problems = append(problems, found...)
+					continue
+				}
+			}
+			serr := err.(*SyntaxError)
+			problems = append(problems, Problem{name, SeverityError,
+				fmt.Sprintf("line %d, offset %d: %s", serr.Line, serr.Offset, serr.Msg),
+				ref})
+			continue
+		}
+		if requestOnly[name] && !isRequest {
+			problems = append(problems, Problem{name, SeverityWarning,
+				"request header in a response", ref})
+		}
+		if responseOnly[name] && isRequest {
+			problems = append(problems, Problem{name, SeverityWarning,
+				"response header in a request", ref})
+		}
+		if rule := lintRules[name]; rule != nil {
+			problems = rule(problems, h, isRequest)
+		}
+	}
+	return problems
+}
+
+// LintHandler wraps next into a handler that lints each request and response
+// with Lint and passes any problems found to report. It is useful for finding
+// protocol violations in a test server or a development proxy.
+// Response headers are linted when they are written.
+func LintHandler(next http.Handler, report func(r *http.Request, isRequest bool, problems []Problem)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if problems := Lint(r.Header, true); len(problems) > 0 {
+			report(r, true, problems)
+		}
+		hw := &hookWriter{ResponseWriter: w}
+		hw.before = func() {
+			if problems := Lint(hw.Header(), false); len(problems) > 0 {
+				report(r, false, problems)
+			}
+		}
+		next.ServeHTTP(hw.withOptional(), r)
+		hw.finish()
+	})
+}
+
+var lintRefs = map[string]string{
+	"Accept":              "RFC 9110 Section 12.5.1",
+	"Accept-Ranges":       "RFC 9110 Section 14.3",
+	"Allow":               "RFC 9110 Section 10.2.1",
+	"Authorization":       "RFC 9110 Section 11.6.2",
+	"Cache-Control":       "RFC 9111 Section 5.2",
+	"Connection":          "RFC 9110 Section 7.6.1",
+	"Content-Disposition": "RFC 6266 Section 4",
+	"Content-Location":    "RFC 9110 Section 8.7",
+	"Content-Type":        "RFC 9110 Section 8.3",
+	"Date":                "RFC 9110 Section 6.6.1",
+	"Etag":                "RFC 9110 Section 8.8.3",
+	"Forwarded":           "RFC 7239 Section 4",
+	"If-Match":            "RFC 9110 Section 13.1.1",
+	"If-None-Match":       "RFC 9110 Section 13.1.2",
+	"Link":                "RFC 8288 Section 3",
+	"Link-Template":       "RFC 9652 Section 2",
+	"Max-Forwards":        "RFC 9110 Section 7.6.2",
+	"Prefer":              "RFC 7240 Section 2",
+	"Preference-Applied":  "RFC 7240 Section 3",
+	"Priority":            "RFC 9218 Section 5",
+	"Proxy-Authenticate":  "RFC 9110 Section 11.7.1",
+	"Proxy-Authorization": "RFC 9110 Section 11.7.2",
+	"Retry-After":         "RFC 9110 Section 10.2.3",
+	"Server":              "RFC 9110 Section 10.2.4",
+	"Te":                  "RFC 9110 Section 10.1.4",
+	"User-Agent":          "RFC 9110 Section 10.1.5",
+	"Vary":                "RFC 9110 Section 12.5.5",
+	"Via":                 "RFC 9110 Section 7.6.3",
+	"Warning":             "RFC 9111 Section 5.5",
+	"Www-Authenticate":    "RFC 9110 Section 11.6.1",
+}
+
+var requestOnly = map[string]bool{
+	"Accept":              true,
+	"Authorization":       true,
+	"Forwarded":           true,
+	"If-Match":            true,
+	"If-None-Match":       true,
+	"Max-Forwards":        true,
+	"Prefer":              true,
+	"Proxy-Authorization": true,
+	"Te":                  true,
+	"User-Agent":          true,
+}
+
+var responseOnly = map[string]bool{
+	"Accept-Ranges":      true,
+	"Etag":               true,
+	"Preference-Applied": true,
+	"Proxy-Authenticate": true,
+	"Retry-After":        true,
+	"Server":             true,
+	"Vary":               true,
+	"Www-Authenticate":   true,
+}
+
+// A lintRule appends to problems any it finds in a well-formed header.
+type lintRule func(problems []Problem, h http.Header, isRequest bool) []Problem
+
+var lintRules map[string]lintRule
+
+// A syntaxRule appends to problems any it finds in a malformed header
+// that explain the syntax error better than a SyntaxError does.
+type syntaxRule func(problems []Problem, values []string) []Problem
+
+var syntaxRules = map[string]syntaxRule{
+	"Forwarded": lintForwardedSyntax,
+}
+
+func init() {
+	lintRules = map[string]lintRule{
+		"Cache-Control":       lintCacheControl,
+		"Content-Disposition": lintContentDisposition,
+		"Forwarded":           lintForwarded,
+		"If-Match":            lintIfMatch,
+		"Link":                lintLink,
+		"Vary":                lintVary,
+		"Warning":             lintWarning,
+	}
+}
+
+func lintCacheControl(problems []Problem, h http.Header, isRequest bool) []Problem {
+	add := func(severity Severity, msg, ref string) {
+		problems = append(problems, Problem{"Cache-Control", severity, msg, ref})
+	}
+	var seen []string
+	for v, vs := iterElems("", h["Cache-Control"]); v != ""; v, vs = iterElems(v, vs) {
+		var name string
+		name, _, v = consumeParam(v)
+		seen = append(seen, name)
+	}
+	has := func(name string) bool {
+		for _, s := range seen {
+			if s == name {
+				return true
+			}
+		}
+		return false
+	}
+	if has("no-store") {
+		for _, name := range []string{"max-age", "s-maxage"} {
+			if has(name) && !isRequest {
+				add(SeverityWarning, "no-store conflicts with "+name,
+					"RFC 9111 Section 5.2.2.5")
+			}
+		}
+	}
+	if has("public") && has("private") {
+		add(SeverityError, "public conflicts with private", "RFC 9111 Section 5.2.2")
+	}
+	if isRequest {
+		for _, name := range []string{"must-revalidate", "proxy-revalidate",
+			"public", "private", "s-maxage", "immutable"} {
+			if has(name) {
+				add(SeverityWarning, "response directive "+name+" in a request",
+					"RFC 9111 Section 5.2.2")
+			}
+		}
+	} else {
+		for _, name := range []string{"max-stale", "min-fresh", "only-if-cached"} {
+			if has(name) {
+				add(SeverityWarning, "request directive "+name+" in a response",
+					"RFC 9111 Section 5.2.1")
+			}
+		}
+	}
+	return problems
+}
+
+func lintContentDisposition(problems []Problem, h http.Header, isRequest bool) []Problem {
+	_, v := consumeItem(h.Get("Content-Disposition"))
+	for {
+		var name, value string
+		name, value, v = consumeParam(v)
+		if name == "" {
+			break
+		}
+		if name == "filename" && strings.IndexFunc(value, isNonASCII) != -1 {
+			problems = append(problems, Problem{"Content-Disposition", SeverityWarning,
+				"non-ASCII characters in filename, use filename* instead",
+				"RFC 6266 Section 4.3"})
+		}
+	}
+	return problems
+}
+
+func isNonASCII(r rune) bool {
+	return r > 0x7F
+}
+
+func lintForwarded(problems []Problem, h http.Header, isRequest bool) []Problem {
+	for v, vs := iterElems("", h["Forwarded"]); v != ""; v, vs = iterElems(v, vs) {
+		for {
+			var name, value string
+			name, value, v = consumeParam(v)
+			if name == "" {
+				break
+			}
+			problems = lintForwardedNode(problems, name, value)
+		}
+	}
+	return problems
+}
+
+// lintForwardedSyntax finds IPv6 addresses in Forwarded that are not even
+// quoted, such as for=2001:db8::1, which makes the header malformed.
+func lintForwardedSyntax(problems []Problem, values []string) []Problem {
+	for _, v := range values {
+		pairs := strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ';' })
+		for _, pair := range pairs {
+			i := strings.IndexByte(pair, '=')
+			if i < 0 {
+				continue
+			}
+			name := strings.ToLower(strings.TrimSpace(pair[:i]))
+			value := strings.Trim(strings.TrimSpace(pair[i+1:]), `"`)
+			problems = lintForwardedNode(problems, name, value)
+		}
+	}
+	return problems
+}
+
+func lintForwardedNode(problems []Problem, name, value string) []Problem {
+	if (name == "for" || name == "by") && strings.Contains(value, ":") &&
+		net.ParseIP(value) != nil {
+		problems = append(problems, Problem{"Forwarded", SeverityError,
+			fmt.Sprintf("IPv6 address %q must be enclosed in brackets", value),
+			"RFC 7239 Section 6"})
+	}
+	return problems
+}
+
+func lintIfMatch(problems []Problem, h http.Header, isRequest bool) []Problem {
+	for _, tag := range IfMatch(h) {
+		if tag.Weak {
+			problems = append(problems, Problem{"If-Match", SeverityWarning,
+				fmt.Sprintf("weak entity-tag %q never matches", tag.Opaque),
+				"RFC 9110 Section 13.1.1"})
+		}
+	}
+	return problems
+}
+
+func lintLink(problems []Problem, h http.Header, isRequest bool) []Problem {
+	for _, link := range Link(h, &url.URL{}) {
+		if !ValidRel(link.Rel) {
+			problems = append(problems, Problem{"Link", SeverityWarning,
+				fmt.Sprintf("unregistered relation type %q", link.Rel),
+				"RFC 8288 Section 2.1"})
+		}
+	}
+	return problems
+}
+
+func lintVary(problems []Problem, h http.Header, isRequest bool) []Problem {
+	if vary := Vary(h); vary["*"] && len(vary) > 1 {
+		problems = append(problems, Problem{"Vary", SeverityInfo,
+			"other names are redundant with *", "RFC 9110 Section 12.5.5"})
+	}
+	return problems
+}
+
+func lintWarning(problems []Problem, h http.Header, isRequest bool) []Problem {
+	return append(problems, Problem{"Warning", SeverityWarning,
+		"the Warning header is obsolete", "RFC 9111 Section 5.5"})
+}
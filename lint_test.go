@@ -0,0 +1,180 @@
+package httpheader
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func ExampleLint() {
+	header := http.Header{
+		"Cache-Control": {"no-store, max-age=3600"},
+		"Content-Type":  {"text/html; charset"},
+		"Warning":       {`299 - "Deprecated API"`},
+	}
+	for _, problem := range Lint(header, false) {
+		fmt.Println(problem)
+	}
+	// Output:
+	// warning: Cache-Control: no-store conflicts with max-age (RFC 9111 Section 5.2.2.5)
+	// error: Content-Type: line 1, offset 18: expected '=', got end of line (RFC 9110 Section 8.3)
+	// warning: Warning: the Warning header is obsolete (RFC 9111 Section 5.5)
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		header    http.Header
+		isRequest bool
+		result    []Problem
+	}{
+		{
+			http.Header{},
+			false,
+			nil,
+		},
+		{
+			http.Header{
+				"Cache-Control": {"max-age=0"},
+				"Accept":        {"text/html"},
+				"X-Custom":      {"whatever"},
+			},
+			true,
+			nil,
+		},
+		{
+			http.Header{"Cache-Control": {"public", "private, no-store, s-maxage=60"}},
+			false,
+			[]Problem{
+				{"Cache-Control", SeverityWarning, "no-store conflicts with s-maxage", "RFC 9111 Section 5.2.2.5"},
+				{"Cache-Control", SeverityError, "public conflicts with private", "RFC 9111 Section 5.2.2"},
+			},
+		},
+		{
+			http.Header{"Cache-Control": {"no-store, max-age=0, immutable"}},
+			true,
+			[]Problem{
+				{"Cache-Control", SeverityWarning, "response directive immutable in a request", "RFC 9111 Section 5.2.2"},
+			},
+		},
+		{
+			http.Header{"Cache-Control": {"max-age=60, only-if-cached"}},
+			false,
+			[]Problem{
+				{"Cache-Control", SeverityWarning, "request directive only-if-cached in a response", "RFC 9111 Section 5.2.1"},
+			},
+		},
+		{
+			http.Header{"If-Match": {`"xyzzy", W/"r2d2xxxx"`}},
+			true,
+			[]Problem{
+				{"If-Match", SeverityWarning, `weak entity-tag "r2d2xxxx" never matches`, "RFC 9110 Section 13.1.1"},
+			},
+		},
+		{
+			http.Header{"Forwarded": {`for="[2001:db8:cafe::17]:4711", for="2001:db8:cafe::17";by=unknown`}},
+			true,
+			[]Problem{
+				{"Forwarded", SeverityError, `IPv6 address "2001:db8:cafe::17" must be enclosed in brackets`, "RFC 7239 Section 6"},
+			},
+		},
+		{
+			http.Header{"Forwarded": {"for=192.0.2.60;By=2001:db8::1", `for="[::1]", for=::1`}},
+			true,
+			[]Problem{
+				{"Forwarded", SeverityError, `IPv6 address "2001:db8::1" must be enclosed in brackets`, "RFC 7239 Section 6"},
+				{"Forwarded", SeverityError, `IPv6 address "::1" must be enclosed in brackets`, "RFC 7239 Section 6"},
+			},
+		},
+		{
+			http.Header{"Forwarded": {"for=192.0.2.60"}},
+			false,
+			[]Problem{
+				{"Forwarded", SeverityWarning, "request header in a response", "RFC 7239 Section 4"},
+			},
+		},
+		{
+			http.Header{"Content-Disposition": {`attachment; filename="€ rates"; filename*=UTF-8''%e2%82%ac%20rates`}},
+			false,
+			[]Problem{
+				{"Content-Disposition", SeverityWarning, "non-ASCII characters in filename, use filename* instead", "RFC 6266 Section 4.3"},
+			},
+		},
+		{
+			http.Header{
+				"Etag": {`"abc"`},
+				"Link": {`</a>; rel=next, </b>; rel="Foo https://example.com/rel/Bar"`},
+				"Vary": {"Accept, *"},
+			},
+			true,
+			[]Problem{
+				{"Etag", SeverityWarning, "response header in a request", "RFC 9110 Section 8.8.3"},
+				{"Link", SeverityWarning, `unregistered relation type "foo"`, "RFC 8288 Section 2.1"},
+				{"Vary", SeverityWarning, "response header in a request", "RFC 9110 Section 12.5.5"},
+				{"Vary", SeverityInfo, "other names are redundant with *", "RFC 9110 Section 12.5.5"},
+			},
+		},
+		{
+			http.Header{
+				"Date":         {"yesterday"},
+				"Max-Forwards": {"1", "2"},
+			},
+			true,
+			[]Problem{
+				{"Date", SeverityError, `line 1, offset 0: bad HTTP-date "yesterday"`, "RFC 9110 Section 6.6.1"},
+				{"Max-Forwards", SeverityError, "line 2, offset 0: duplicate field line for a singleton header", "RFC 9110 Section 7.6.2"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			checkParse(t, test.header, test.result, Lint(test.header, test.isRequest))
+		})
+	}
+}
+
+func TestLintHandler(t *testing.T) {
+	type report struct {
+		isRequest bool
+		problems  []Problem
+	}
+	var reports []report
+	handler := LintHandler(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "min-fresh=10")
+			w.Write([]byte("hello"))
+		}),
+		func(r *http.Request, isRequest bool, problems []Problem) {
+			reports = append(reports, report{isRequest, problems})
+		},
+	)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Server", "test")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	checkParse(t, nil, []report{
+		{true, []Problem{
+			{"Server", SeverityWarning, "response header in a request", "RFC 9110 Section 10.2.4"},
+		}},
+		{false, []Problem{
+			{"Cache-Control", SeverityWarning, "request directive min-fresh in a response", "RFC 9111 Section 5.2.1"},
+		}},
+	}, reports)
+}
+
+func TestLintFuzz(t *testing.T) {
+	for name := range grammars {
+		checkFuzz(t, name, func(h http.Header) []Problem { return Lint(h, true) }, nil)
+	}
+}
+
+func BenchmarkLint(b *testing.B) {
+	header := http.Header{
+		"Cache-Control": {"no-store, max-age=0"},
+		"Forwarded":     {`for="[2001:db8:cafe::17]:4711"`},
+		"If-Match":      {`"xyzzy", W/"r2d2xxxx"`},
+		"Link":          {`</style.css>; rel=preload; as=style`},
+	}
+	for i := 0; i < b.N; i++ {
+		Lint(header, true)
+	}
+}
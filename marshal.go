@@ -0,0 +1,742 @@
+package httpheader
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// This file implements encoding.TextMarshaler, encoding.TextUnmarshaler,
+// json.Marshaler and json.Unmarshaler for the types in this package.
+//
+// The text form of an element type is its wire form, as generated by
+// the corresponding SetFooBar function. UnmarshalText checks the text
+// against the header's grammar (see Check) before parsing it, and fails
+// unless it contains exactly one element.
+//
+// The JSON form of a struct type is an object with its fields, but
+// UnmarshalJSON also accepts a JSON string containing the wire form,
+// which is convenient in configuration files.
+// The JSON form of value-like types, such as EntityTag and Node, is a string
+// containing the text form.
+
+// marshalText returns the value of the header with the given name
+// after set has written it into an empty header.
+func marshalText(name string, set func(h http.Header)) ([]byte, error) {
+	h := http.Header{}
+	set(h)
+	return []byte(h.Get(name)), nil
+}
+
+// unmarshalText checks text against the grammar of the header with the given
+// name, then calls parse on a header with text as its only field line.
+// parse returns the number of elements it found, storing the element only
+// if there is exactly one; otherwise, unmarshalText returns an error
+// mentioning what.
+func unmarshalText(name, what string, text []byte, parse func(h http.Header) int) error {
+	values := []string{string(text)}
+	if err := DefaultLimits().checkHeader(name, values, false); err != nil {
+		return err
+	}
+	if n := parse(http.Header{name: values}); n != 1 {
+		return fmt.Errorf("expected one %s element, got %d", what, n)
+	}
+	return nil
+}
+
+// unmarshalJSON decodes data into plain if it is a JSON object,
+// or into text if it is a JSON string containing the wire form.
+func unmarshalJSON(data []byte, text encoding.TextUnmarshaler, plain interface{}) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return text.UnmarshalText([]byte(s))
+	}
+	return json.Unmarshal(data, plain)
+}
+
+func urlString(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
+
+func parseURLString(s string) (*url.URL, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return url.Parse(s)
+}
+
+// MarshalText returns the wire form of elem, as in the Via header.
+func (elem ViaElem) MarshalText() ([]byte, error) {
+	return marshalText("Via", func(h http.Header) { SetVia(h, []ViaElem{elem}) })
+}
+
+// UnmarshalText parses elem from its wire form.
+func (elem *ViaElem) UnmarshalText(text []byte) error {
+	return unmarshalText("Via", "Via", text, func(h http.Header) int {
+		elems := Via(h)
+		if len(elems) == 1 {
+			*elem = elems[0]
+		}
+		return len(elems)
+	})
+}
+
+// MarshalJSON returns the JSON object representing elem.
+func (elem ViaElem) MarshalJSON() ([]byte, error) {
+	type plain ViaElem
+	return json.Marshal(plain(elem))
+}
+
+// UnmarshalJSON parses elem from a JSON object or from a JSON string
+// containing its wire form.
+func (elem *ViaElem) UnmarshalJSON(data []byte) error {
+	type plain ViaElem
+	return unmarshalJSON(data, elem, (*plain)(elem))
+}
+
+// MarshalText returns the wire form of p, as in the User-Agent header.
+func (p Product) MarshalText() ([]byte, error) {
+	return marshalText("User-Agent", func(h http.Header) { SetUserAgent(h, []Product{p}) })
+}
+
+// UnmarshalText parses p from its wire form.
+func (p *Product) UnmarshalText(text []byte) error {
+	return unmarshalText("User-Agent", "User-Agent", text, func(h http.Header) int {
+		products := UserAgent(h)
+		if len(products) == 1 {
+			*p = products[0]
+		}
+		return len(products)
+	})
+}
+
+// MarshalJSON returns the JSON object representing p.
+func (p Product) MarshalJSON() ([]byte, error) {
+	type plain Product
+	return json.Marshal(plain(p))
+}
+
+// UnmarshalJSON parses p from a JSON object or from a JSON string
+// containing its wire form.
+func (p *Product) UnmarshalJSON(data []byte) error {
+	type plain Product
+	return unmarshalJSON(data, p, (*plain)(p))
+}
+
+// MarshalText returns the wire form of elem, as in the Accept header.
+func (elem AcceptElem) MarshalText() ([]byte, error) {
+	return marshalText("Accept", func(h http.Header) { SetAccept(h, []AcceptElem{elem}) })
+}
+
+// UnmarshalText parses elem from its wire form.
+func (elem *AcceptElem) UnmarshalText(text []byte) error {
+	return unmarshalText("Accept", "Accept", text, func(h http.Header) int {
+		elems := Accept(h)
+		if len(elems) == 1 {
+			*elem = elems[0]
+		}
+		return len(elems)
+	})
+}
+
+// MarshalJSON returns the JSON object representing elem.
+func (elem AcceptElem) MarshalJSON() ([]byte, error) {
+	type plain AcceptElem
+	return json.Marshal(plain(elem))
+}
+
+// UnmarshalJSON parses elem from a JSON object or from a JSON string
+// containing its wire form.
+func (elem *AcceptElem) UnmarshalJSON(data []byte) error {
+	type plain AcceptElem
+	return unmarshalJSON(data, elem, (*plain)(elem))
+}
+
+// MarshalText returns the wire form of tag, such as W/"xyzzy" or *.
+// Its JSON form is the same, as a string.
+func (tag EntityTag) MarshalText() ([]byte, error) {
+	if tag.wildcard {
+		return []byte("*"), nil
+	}
+	return marshalText("Etag", func(h http.Header) { SetETag(h, tag) })
+}
+
+// UnmarshalText parses tag from its wire form.
+func (tag *EntityTag) UnmarshalText(text []byte) error {
+	return unmarshalText("If-Match", "entity-tag", text, func(h http.Header) int {
+		tags := IfMatch(h)
+		if len(tags) == 1 {
+			*tag = tags[0]
+		}
+		return len(tags)
+	})
+}
+
+// MarshalText returns the wire form of elem, as in the Warning header.
+func (elem WarningElem) MarshalText() ([]byte, error) {
+	return marshalText("Warning", func(h http.Header) { SetWarning(h, []WarningElem{elem}) })
+}
+
+// UnmarshalText parses elem from its wire form.
+func (elem *WarningElem) UnmarshalText(text []byte) error {
+	return unmarshalText("Warning", "Warning", text, func(h http.Header) int {
+		elems := Warning(h)
+		if len(elems) == 1 {
+			*elem = elems[0]
+		}
+		return len(elems)
+	})
+}
+
+// MarshalJSON returns the JSON object representing elem.
+func (elem WarningElem) MarshalJSON() ([]byte, error) {
+	type plain WarningElem
+	return json.Marshal(plain(elem))
+}
+
+// UnmarshalJSON parses elem from a JSON object or from a JSON string
+// containing its wire form.
+func (elem *WarningElem) UnmarshalJSON(data []byte) error {
+	type plain WarningElem
+	return unmarshalJSON(data, elem, (*plain)(elem))
+}
+
+// MarshalText returns the wire form of cc, as in the Cache-Control header.
+// The text form of the zero CacheDirectives is empty.
+func (cc CacheDirectives) MarshalText() ([]byte, error) {
+	return marshalText("Cache-Control", func(h http.Header) { SetCacheControl(h, cc) })
+}
+
+// UnmarshalText parses cc from its wire form.
+func (cc *CacheDirectives) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*cc = CacheDirectives{}
+		return nil
+	}
+	return unmarshalText("Cache-Control", "Cache-Control", text, func(h http.Header) int {
+		*cc = CacheControl(h)
+		return 1
+	})
+}
+
+// MarshalJSON returns the JSON object representing cc.
+func (cc CacheDirectives) MarshalJSON() ([]byte, error) {
+	type plain CacheDirectives
+	return json.Marshal(plain(cc))
+}
+
+// UnmarshalJSON parses cc from a JSON object or from a JSON string
+// containing its wire form.
+func (cc *CacheDirectives) UnmarshalJSON(data []byte) error {
+	type plain CacheDirectives
+	return unmarshalJSON(data, cc, (*plain)(cc))
+}
+
+// MarshalText returns the number of seconds in d, or an empty string
+// if d is not set.
+func (d Delta) MarshalText() ([]byte, error) {
+	if !d.ok {
+		return []byte{}, nil
+	}
+	return []byte(strconv.Itoa(d.seconds)), nil
+}
+
+// UnmarshalText parses d from a number of seconds. Empty text means
+// that d is not set.
+func (d *Delta) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*d = Delta{}
+		return nil
+	}
+	seconds, err := strconv.Atoi(string(text))
+	if err != nil || seconds < 0 {
+		return fmt.Errorf("bad delta-seconds %q", text)
+	}
+	*d = DeltaSeconds(seconds)
+	return nil
+}
+
+// MarshalJSON returns the number of seconds in d, or null if d is not set.
+func (d Delta) MarshalJSON() ([]byte, error) {
+	if !d.ok {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.Itoa(d.seconds)), nil
+}
+
+// UnmarshalJSON parses d from a number of seconds, or null.
+func (d *Delta) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = Delta{}
+		return nil
+	}
+	return d.UnmarshalText(data)
+}
+
+// MarshalText returns the wire form of auth, as in the Authorization header.
+// Beware that it includes any credentials in Token and Params.
+func (auth Auth) MarshalText() ([]byte, error) {
+	return marshalText("Authorization", func(h http.Header) { SetAuthorization(h, auth) })
+}
+
+// UnmarshalText parses auth from its wire form.
+func (auth *Auth) UnmarshalText(text []byte) error {
+	return unmarshalText("Authorization", "Authorization", text, func(h http.Header) int {
+		*auth = Authorization(h)
+		return 1
+	})
+}
+
+// MarshalJSON returns the JSON object representing auth.
+// Beware that it includes any credentials in Token and Params.
+func (auth Auth) MarshalJSON() ([]byte, error) {
+	type plain Auth
+	return json.Marshal(plain(auth))
+}
+
+// UnmarshalJSON parses auth from a JSON object or from a JSON string
+// containing its wire form.
+func (auth *Auth) UnmarshalJSON(data []byte) error {
+	type plain Auth
+	return unmarshalJSON(data, auth, (*plain)(auth))
+}
+
+// MarshalText returns the wire form of elem, as in the Forwarded header.
+func (elem ForwardedElem) MarshalText() ([]byte, error) {
+	return marshalText("Forwarded", func(h http.Header) { SetForwarded(h, []ForwardedElem{elem}) })
+}
+
+// UnmarshalText parses elem from its wire form.
+func (elem *ForwardedElem) UnmarshalText(text []byte) error {
+	return unmarshalText("Forwarded", "Forwarded", text, func(h http.Header) int {
+		elems := Forwarded(h)
+		if len(elems) == 1 {
+			*elem = elems[0]
+		}
+		return len(elems)
+	})
+}
+
+// MarshalJSON returns the JSON object representing elem.
+func (elem ForwardedElem) MarshalJSON() ([]byte, error) {
+	type plain ForwardedElem
+	return json.Marshal(plain(elem))
+}
+
+// UnmarshalJSON parses elem from a JSON object or from a JSON string
+// containing its wire form.
+func (elem *ForwardedElem) UnmarshalJSON(data []byte) error {
+	type plain ForwardedElem
+	return unmarshalJSON(data, elem, (*plain)(elem))
+}
+
+// MarshalText returns the node identifier for node (RFC 7239 Section 6),
+// without quotes, such as "[2001:db8::1]:8080" or "_hidden". Its JSON form
+// is the same, as a string.
+func (node Node) MarshalText() ([]byte, error) {
+	return []byte(formatNode(node)), nil
+}
+
+// UnmarshalText parses node from a node identifier.
+func (node *Node) UnmarshalText(text []byte) error {
+	*node = parseNode(string(text))
+	return nil
+}
+
+// MarshalText returns the wire form of pref as in the Prefer header, but
+// without the preference name, which is its key in the map passed to SetPrefer:
+// for example, "=10;foo". The text form of a Pref without Value or Params
+// is empty.
+func (pref Pref) MarshalText() ([]byte, error) {
+	text, err := marshalText("Prefer", func(h http.Header) {
+		SetPrefer(h, map[string]Pref{"p": pref})
+	})
+	return text[len("p"):], err
+}
+
+// UnmarshalText parses pref from its wire form without the preference name.
+func (pref *Pref) UnmarshalText(text []byte) error {
+	return unmarshalText("Prefer", "Prefer", append([]byte("p"), text...), func(h http.Header) int {
+		prefs := Prefer(h)
+		if len(prefs) == 1 {
+			*pref = prefs["p"]
+		}
+		return len(prefs)
+	})
+}
+
+// MarshalJSON returns the JSON object representing pref.
+func (pref Pref) MarshalJSON() ([]byte, error) {
+	type plain Pref
+	return json.Marshal(plain(pref))
+}
+
+// UnmarshalJSON parses pref from a JSON object or from a JSON string
+// containing its wire form without the preference name.
+func (pref *Pref) UnmarshalJSON(data []byte) error {
+	type plain Pref
+	return unmarshalJSON(data, pref, (*plain)(pref))
+}
+
+// MarshalText returns the wire form of link, as in the Link header.
+func (link LinkElem) MarshalText() ([]byte, error) {
+	if link.Target == nil {
+		return nil, errors.New("link without target")
+	}
+	return marshalText("Link", func(h http.Header) { SetLink(h, []LinkElem{link}) })
+}
+
+// UnmarshalText parses link from its wire form. Relative URLs are left
+// unresolved.
+func (link *LinkElem) UnmarshalText(text []byte) error {
+	return unmarshalText("Link", "Link", text, func(h http.Header) int {
+		links := Link(h, nil)
+		if len(links) == 1 {
+			*link = links[0]
+		}
+		return len(links)
+	})
+}
+
+// MarshalJSON returns the JSON object representing link,
+// with URLs as strings.
+func (link LinkElem) MarshalJSON() ([]byte, error) {
+	type plain LinkElem
+	return json.Marshal(struct {
+		plain
+		Anchor string `json:",omitempty"`
+		Target string
+	}{plain(link), urlString(link.Anchor), urlString(link.Target)})
+}
+
+// UnmarshalJSON parses link from a JSON object or from a JSON string
+// containing its wire form.
+func (link *LinkElem) UnmarshalJSON(data []byte) error {
+	type plain LinkElem
+	var obj struct {
+		*plain
+		Anchor string
+		Target string
+	}
+	obj.plain = (*plain)(link)
+	obj.Anchor, obj.Target = urlString(link.Anchor), urlString(link.Target)
+	if err := unmarshalJSON(data, link, &obj); err != nil || data[0] == '"' {
+		return err
+	}
+	var err error
+	if link.Anchor, err = parseURLString(obj.Anchor); err != nil {
+		return err
+	}
+	link.Target, err = parseURLString(obj.Target)
+	return err
+}
+
+// MarshalText returns the wire form of p, as in the Link header.
+// It fails if p's Target is nil.
+func (p Preload) MarshalText() ([]byte, error) {
+	if p.Target == nil {
+		return nil, errors.New("preload without a target")
+	}
+	return p.Link().MarshalText()
+}
+
+// UnmarshalText parses p from its wire form.
+func (p *Preload) UnmarshalText(text []byte) error {
+	var link LinkElem
+	if err := link.UnmarshalText(text); err != nil {
+		return err
+	}
+	var ok bool
+	if *p, ok = ParsePreload(link); !ok {
+		return fmt.Errorf("not a preload link: %q", text)
+	}
+	return nil
+}
+
+// MarshalJSON returns the JSON object representing p, with Target as a string.
+func (p Preload) MarshalJSON() ([]byte, error) {
+	type plain Preload
+	return json.Marshal(struct {
+		plain
+		Target string
+	}{plain(p), urlString(p.Target)})
+}
+
+// UnmarshalJSON parses p from a JSON object or from a JSON string
+// containing its wire form.
+func (p *Preload) UnmarshalJSON(data []byte) error {
+	type plain Preload
+	var obj struct {
+		*plain
+		Target string
+	}
+	obj.plain = (*plain)(p)
+	obj.Target = urlString(p.Target)
+	if err := unmarshalJSON(data, p, &obj); err != nil || data[0] == '"' {
+		return err
+	}
+	var err error
+	p.Target, err = parseURLString(obj.Target)
+	return err
+}
+
+// MarshalText returns the wire form of p, as in the Priority header.
+// The text form of DefaultPriority is empty.
+func (p PriorityParams) MarshalText() ([]byte, error) {
+	return marshalText("Priority", func(h http.Header) { SetPriority(h, p) })
+}
+
+// UnmarshalText parses p from its wire form.
+func (p *PriorityParams) UnmarshalText(text []byte) error {
+	return unmarshalText("Priority", "Priority", text, func(h http.Header) int {
+		*p = Priority(h)
+		return 1
+	})
+}
+
+// MarshalJSON returns the JSON object representing p.
+func (p PriorityParams) MarshalJSON() ([]byte, error) {
+	type plain PriorityParams
+	return json.Marshal(plain(p))
+}
+
+// UnmarshalJSON parses p from a JSON object or from a JSON string
+// containing its wire form.
+func (p *PriorityParams) UnmarshalJSON(data []byte) error {
+	type plain PriorityParams
+	return unmarshalJSON(data, p, (*plain)(p))
+}
+
+// MarshalText returns the wire form of elem, as in the Link-Template header.
+func (elem LinkTemplateElem) MarshalText() ([]byte, error) {
+	return marshalText("Link-Template", func(h http.Header) {
+		SetLinkTemplate(h, []LinkTemplateElem{elem})
+	})
+}
+
+// UnmarshalText parses elem from its wire form.
+func (elem *LinkTemplateElem) UnmarshalText(text []byte) error {
+	return unmarshalText("Link-Template", "Link-Template", text, func(h http.Header) int {
+		elems := LinkTemplate(h)
+		if len(elems) == 1 {
+			*elem = elems[0]
+		}
+		return len(elems)
+	})
+}
+
+// MarshalJSON returns the JSON object representing elem.
+func (elem LinkTemplateElem) MarshalJSON() ([]byte, error) {
+	type plain LinkTemplateElem
+	return json.Marshal(plain(elem))
+}
+
+// UnmarshalJSON parses elem from a JSON object or from a JSON string
+// containing its wire form.
+func (elem *LinkTemplateElem) UnmarshalJSON(data []byte) error {
+	type plain LinkTemplateElem
+	return unmarshalJSON(data, elem, (*plain)(elem))
+}
+
+// MarshalText returns the wire form of elem, as generated by SetListElems.
+func (elem ListElem) MarshalText() ([]byte, error) {
+	return marshalText("List", func(h http.Header) {
+		SetListElems(h, "List", []ListElem{elem})
+	})
+}
+
+// UnmarshalText parses elem from its wire form.
+func (elem *ListElem) UnmarshalText(text []byte) error {
+	return unmarshalText("List", "list", text, func(h http.Header) int {
+		elems := ListElems(h, "List")
+		if len(elems) == 1 {
+			*elem = elems[0]
+		}
+		return len(elems)
+	})
+}
+
+// MarshalJSON returns the JSON object representing elem.
+func (elem ListElem) MarshalJSON() ([]byte, error) {
+	type plain ListElem
+	return json.Marshal(plain(elem))
+}
+
+// UnmarshalJSON parses elem from a JSON object or from a JSON string
+// containing its wire form.
+func (elem *ListElem) UnmarshalJSON(data []byte) error {
+	type plain ListElem
+	return unmarshalJSON(data, elem, (*plain)(elem))
+}
+
+// MarshalText returns the wire form of param as it follows a semicolon
+// in SetListElems, such as `a="b c"`.
+func (param ListParam) MarshalText() ([]byte, error) {
+	text, err := ListElem{Name: "p", Params: []ListParam{param}}.MarshalText()
+	return text[len("p;"):], err
+}
+
+// UnmarshalText parses param from its wire form.
+func (param *ListParam) UnmarshalText(text []byte) error {
+	var elem ListElem
+	if err := elem.UnmarshalText(append([]byte("p;"), text...)); err != nil {
+		return err
+	}
+	if len(elem.Params) != 1 {
+		return fmt.Errorf("expected one parameter, got %d", len(elem.Params))
+	}
+	*param = elem.Params[0]
+	return nil
+}
+
+// MarshalJSON returns the JSON object representing param.
+func (param ListParam) MarshalJSON() ([]byte, error) {
+	type plain ListParam
+	return json.Marshal(plain(param))
+}
+
+// UnmarshalJSON parses param from a JSON object or from a JSON string
+// containing its wire form.
+func (param *ListParam) UnmarshalJSON(data []byte) error {
+	type plain ListParam
+	return unmarshalJSON(data, param, (*plain)(param))
+}
+
+// MarshalText serializes item as a structured field Item (RFC 9651).
+// Its JSON form is the same, as a string, because JSON cannot distinguish
+// all bare item types.
+func (item Item) MarshalText() ([]byte, error) {
+	s, err := SerializeItem(item)
+	return []byte(s), err
+}
+
+// UnmarshalText parses item as a structured field Item.
+func (item *Item) UnmarshalText(text []byte) error {
+	var err error
+	*item, err = ParseItem([]string{string(text)})
+	return err
+}
+
+// MarshalText serializes list as a structured field List (RFC 9651).
+// Its JSON form is the same, as a string.
+func (list List) MarshalText() ([]byte, error) {
+	s, err := SerializeList(list)
+	return []byte(s), err
+}
+
+// UnmarshalText parses list as a structured field List.
+func (list *List) UnmarshalText(text []byte) error {
+	var err error
+	*list, err = ParseList([]string{string(text)})
+	return err
+}
+
+// MarshalText serializes dict as a structured field Dictionary (RFC 9651).
+// Its JSON form is the same, as a string.
+func (dict Dictionary) MarshalText() ([]byte, error) {
+	s, err := SerializeDictionary(dict)
+	return []byte(s), err
+}
+
+// UnmarshalText parses dict as a structured field Dictionary.
+func (dict *Dictionary) UnmarshalText(text []byte) error {
+	var err error
+	*dict, err = ParseDictionary([]string{string(text)})
+	return err
+}
+
+// MarshalText serializes member as a structured field Dictionary (RFC 9651)
+// with only that member.
+func (member DictMember) MarshalText() ([]byte, error) {
+	return Dictionary{member}.MarshalText()
+}
+
+// UnmarshalText parses member from a structured field Dictionary
+// with exactly one member.
+func (member *DictMember) UnmarshalText(text []byte) error {
+	dict, err := ParseDictionary([]string{string(text)})
+	if err != nil {
+		return err
+	}
+	if len(dict) != 1 {
+		return fmt.Errorf("expected one dictionary member, got %d", len(dict))
+	}
+	*member = dict[0]
+	return nil
+}
+
+// MarshalJSON returns the JSON object representing member, with Item
+// in its text form. Without this method, the MarshalText method
+// of the embedded Item would be used, losing the Name.
+func (member DictMember) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name string
+		Item Item
+	}{member.Name, member.Item})
+}
+
+// UnmarshalJSON parses member from a JSON object or from a JSON string
+// containing its wire form.
+func (member *DictMember) UnmarshalJSON(data []byte) error {
+	obj := struct {
+		Name string
+		Item Item
+	}{member.Name, member.Item}
+	if err := unmarshalJSON(data, member, &obj); err != nil || data[0] == '"' {
+		return err
+	}
+	member.Name, member.Item = obj.Name, obj.Item
+	return nil
+}
+
+// MarshalText serializes param as a structured field parameter (RFC 9651),
+// without the leading semicolon, such as `a="b"`. Its JSON form is the same,
+// as a string.
+func (param Param) MarshalText() ([]byte, error) {
+	b := &strings.Builder{}
+	if err := writeSFParams(b, Params{param}); err != nil {
+		return nil, err
+	}
+	return []byte(b.String()[len(";"):]), nil
+}
+
+// UnmarshalText parses param from a structured field parameter
+// without the leading semicolon.
+func (param *Param) UnmarshalText(text []byte) error {
+	item, err := ParseItem([]string{"?1;" + string(text)})
+	if err != nil {
+		return err
+	}
+	if len(item.Params) != 1 {
+		return fmt.Errorf("expected one parameter, got %d", len(item.Params))
+	}
+	*param = item.Params[0]
+	return nil
+}
+
+// MarshalText returns the name of s, such as "warning".
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses s from its name.
+func (s *Severity) UnmarshalText(text []byte) error {
+	for _, severity := range []Severity{SeverityInfo, SeverityWarning, SeverityError} {
+		if string(text) == severity.String() {
+			*s = severity
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown severity %q", text)
+}
@@ -0,0 +1,297 @@
+package httpheader
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func ExampleForwardedElem_MarshalJSON() {
+	header := http.Header{"Forwarded": {`for="[2001:db8:cafe::17]:4711";proto=https`}}
+	b, _ := json.Marshal(Forwarded(header))
+	fmt.Println(string(b))
+	// Output: [{"By":"","For":"[2001:db8:cafe::17]:4711","Host":"","Proto":"https","Ext":null}]
+}
+
+func ExampleAcceptElem_UnmarshalJSON() {
+	var config struct {
+		Accept []AcceptElem
+		ETag   EntityTag
+	}
+	json.Unmarshal([]byte(`{
+		"Accept": [
+			"application/json;q=0.9",
+			{"Type": "text/html", "Q": 1}
+		],
+		"ETag": "W/\"xyzzy\""
+	}`), &config)
+	fmt.Printf("%+v\n%+v\n", config.Accept, config.ETag)
+	// Output:
+	// [{Type:application/json Params:map[] Q:0.9 Ext:map[]} {Type:text/html Params:map[] Q:1 Ext:map[]}]
+	// {wildcard:false Weak:true Opaque:xyzzy}
+}
+
+func TestMarshalText(t *testing.T) {
+	tests := []struct {
+		value encoding.TextMarshaler
+		text  string
+	}{
+		{ViaElem{"HTTP/1.1", "proxy.example", "Apache"}, "1.1 proxy.example (Apache)"},
+		{Product{"curl", "7.64.1", ""}, "curl/7.64.1"},
+		{
+			AcceptElem{Type: "text/html", Params: map[string]string{"level": "1"}, Q: 0.5},
+			"text/html;level=1;q=0.5",
+		},
+		{EntityTag{Weak: true, Opaque: "xyzzy"}, `W/"xyzzy"`},
+		{AnyTag, "*"},
+		{
+			WarningElem{Code: 299, Agent: "-", Text: "Deprecated"},
+			`299 - "Deprecated"`,
+		},
+		{
+			CacheDirectives{Public: true, MaxAge: DeltaSeconds(60)},
+			"public, max-age=60",
+		},
+		{CacheDirectives{}, ""},
+		{DeltaSeconds(60), "60"},
+		{Delta{}, ""},
+		{
+			Auth{Scheme: "bearer", Token: "mF_9.B5f-4.1JqM"},
+			"Bearer mF_9.B5f-4.1JqM",
+		},
+		{
+			ForwardedElem{For: Node{IP: net.IPv6loopback, Port: 8080}, Proto: "https"},
+			`for="[::1]:8080";proto=https`,
+		},
+		{Node{IP: net.IPv4(192, 0, 2, 60)}, "192.0.2.60"},
+		{Node{ObfuscatedNode: "_hidden", ObfuscatedPort: "_p"}, "_hidden:_p"},
+		{Pref{Value: "10", Params: map[string]string{"foo": "", "bar": "a b"}}, `=10;bar="a b";foo`},
+		{Pref{}, ""},
+		{
+			LinkElem{Rel: "next", Target: U("/items?page=2")},
+			"</items?page=2>; rel=next",
+		},
+		{
+			Preload{Rel: "preload", Target: U("/style.css"), As: "style"},
+			"</style.css>; rel=preload; as=style",
+		},
+		{PriorityParams{Urgency: 1, Incremental: true}, "u=1, i"},
+		{DefaultPriority, ""},
+		{
+			LinkTemplateElem{Template: "/{id}", Rel: "item"},
+			`"/{id}";rel="item"`,
+		},
+		{
+			ListElem{Name: "wait", Value: "10", Params: []ListParam{
+				{Name: "z", Value: ""},
+				{Name: "a", Value: "b c"},
+			}},
+			`wait=10;z;a="b c"`,
+		},
+		{ListParam{Name: "a", Value: "b c"}, `a="b c"`},
+		{ListParam{Name: "z", EmptyValue: true}, `z=""`},
+		{Item{Value: Token("foo"), Params: Params{{"a", true}}}, "foo;a"},
+		{List{{Value: int64(1)}, {Value: "x"}}, `1, "x"`},
+		{Dictionary{{"u", Item{Value: int64(1)}}, {"i", Item{Value: true}}}, "u=1, i"},
+		{DictMember{"u", Item{Value: int64(1), Params: Params{{"a", "b"}}}}, `u=1;a="b"`},
+		{Param{"a", "b"}, `a="b"`},
+		{Param{"i", true}, "i"},
+		{Param{"t", Token("x")}, "t=x"},
+		{SeverityWarning, "warning"},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%T", test.value), func(t *testing.T) {
+			text, err := test.value.MarshalText()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(text) != test.text {
+				t.Errorf("marshaling %#v\nexpected: %s\nactual:   %s",
+					test.value, test.text, text)
+			}
+			out := reflect.New(reflect.TypeOf(test.value))
+			if err := out.Interface().(encoding.TextUnmarshaler).UnmarshalText(text); err != nil {
+				t.Fatal(err)
+			}
+			if actual := out.Elem().Interface(); !reflect.DeepEqual(test.value, actual) {
+				t.Errorf("unmarshaling %q\nexpected: %#v\nactual:   %#v",
+					text, test.value, actual)
+			}
+		})
+	}
+}
+
+func TestUnmarshalTextErrors(t *testing.T) {
+	tests := []struct {
+		value encoding.TextUnmarshaler
+		text  string
+	}{
+		{&ViaElem{}, "1.1"},
+		{&Product{}, "curl/7 wget/1"},
+		{&AcceptElem{}, "text/html, text/plain"},
+		{&AcceptElem{}, ""},
+		{&EntityTag{}, "xyzzy"},
+		{&WarningElem{}, "299"},
+		{&CacheDirectives{}, "max-age = 60"},
+		{&Delta{}, "-1"},
+		{&Auth{}, "Basic a b"},
+		{&ForwardedElem{}, "for=[::1]"},
+		{&LinkElem{}, "/foo; rel=next"},
+		{&Preload{}, "</a>; rel=next"},
+		{&PriorityParams{}, "u=?1x"},
+		{&LinkTemplateElem{}, `"/a", "/b"`},
+		{&Pref{}, "=1, q"},
+		{&ListElem{}, "a, b"},
+		{&ListParam{}, ""},
+		{&ListParam{}, "a;b"},
+		{&ListParam{}, "a, b"},
+		{&Item{}, "a, b"},
+		{&DictMember{}, "a, b"},
+		{&DictMember{}, ""},
+		{&Param{}, ""},
+		{&Param{}, "a;b"},
+		{&Param{}, "a=("},
+		{new(Severity), "fatal"},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%T", test.value), func(t *testing.T) {
+			if err := test.value.UnmarshalText([]byte(test.text)); err == nil {
+				t.Errorf("unmarshaling %q: expected error", test.text)
+			}
+		})
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	date := time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)
+	tests := []struct {
+		value interface{}
+		json  string
+	}{
+		{
+			AcceptElem{Type: "text/html", Q: 0.5},
+			`{"Type":"text/html","Params":null,"Q":0.5,"Ext":null}`,
+		},
+		{
+			EntityTag{Opaque: "xyzzy"},
+			`"\"xyzzy\""`,
+		},
+		{
+			WarningElem{Code: 112, Agent: "-", Text: "cache down", Date: date},
+			`{"Code":112,"Agent":"-","Text":"cache down","Date":"2015-10-21T07:28:00Z"}`,
+		},
+		{
+			CacheDirectives{NoStore: true, MaxAge: DeltaSeconds(0)},
+			`{"NoStore":true,"NoTransform":false,"OnlyIfCached":false,"MustRevalidate":false,` +
+				`"Public":false,"ProxyRevalidate":false,"Immutable":false,"MustUnderstand":false,` +
+				`"NoCache":false,"Private":false,"NoCacheHeaders":null,"PrivateHeaders":null,"MaxAge":0,` +
+				`"SMaxage":null,"MinFresh":null,"StaleWhileRevalidate":null,"StaleIfError":null,` +
+				`"MaxStale":null,"Ext":null}`,
+		},
+		{
+			ForwardedElem{For: Node{IP: net.IPv4(192, 0, 2, 60)}, Host: "example.com"},
+			`{"By":"","For":"192.0.2.60","Host":"example.com","Proto":"","Ext":null}`,
+		},
+		{
+			LinkElem{Rel: "next", Target: U("https://example.com/2")},
+			`{"Rel":"next","Title":"","TitleLang":"","Type":"","HrefLang":null,"Media":"","Ext":null,` +
+				`"Target":"https://example.com/2"}`,
+		},
+		{
+			Preload{Rel: "preload", Target: U("/a.js"), As: "script"},
+			`{"Rel":"preload","As":"script","Type":"","CrossOrigin":"","Integrity":"",` +
+				`"FetchPriority":"","NoPush":false,"Target":"/a.js"}`,
+		},
+		{
+			PriorityParams{Urgency: 1},
+			`{"Urgency":1,"Incremental":false}`,
+		},
+		{
+			ListElem{Name: "foo", Params: []ListParam{{Name: "a", Value: "1"}}},
+			`{"Name":"foo","Value":"","EmptyValue":false,"Params":[{"Name":"a","Value":"1","EmptyValue":false}]}`,
+		},
+		{
+			Pref{Value: "minimal"},
+			`{"Value":"minimal","Params":null}`,
+		},
+		{
+			ListParam{Name: "a", Value: "1"},
+			`{"Name":"a","Value":"1","EmptyValue":false}`,
+		},
+		{
+			Param{"a", []byte("hi")},
+			`"a=:aGk=:"`,
+		},
+		{
+			List{{Value: Token("a")}, {Value: []byte("hi")}},
+			`"a, :aGk=:"`,
+		},
+		{
+			DictMember{"u", Item{Value: int64(1)}},
+			`{"Name":"u","Item":"1"}`,
+		},
+		{
+			Dictionary{{"i", Item{Value: true}}},
+			`"i"`,
+		},
+		{
+			Problem{"Warning", SeverityWarning, "obsolete", "RFC 9111 Section 5.5"},
+			`{"Header":"Warning","Severity":"warning","Msg":"obsolete","Ref":"RFC 9111 Section 5.5"}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%T", test.value), func(t *testing.T) {
+			b, err := json.Marshal(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != test.json {
+				t.Errorf("marshaling %#v\nexpected: %s\nactual:   %s",
+					test.value, test.json, b)
+			}
+			out := reflect.New(reflect.TypeOf(test.value))
+			if err := json.Unmarshal(b, out.Interface()); err != nil {
+				t.Fatal(err)
+			}
+			if actual := out.Elem().Interface(); !reflect.DeepEqual(test.value, actual) {
+				t.Errorf("unmarshaling %s\nexpected: %#v\nactual:   %#v",
+					b, test.value, actual)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSONWireForm(t *testing.T) {
+	var v struct {
+		Via       []ViaElem
+		Link      LinkElem
+		Delta     Delta
+		Forwarded ForwardedElem
+		Members   []DictMember
+	}
+	err := json.Unmarshal([]byte(`{
+		"Via": ["1.1 fred", {"ReceivedProto": "HTTP/2.0", "ReceivedBy": "bob"}],
+		"Link": "<https://example.com/>; rel=preconnect",
+		"Delta": 3600,
+		"Forwarded": "for=\"[2001:db8::1]\"",
+		"Members": ["u=1", {"Name": "i", "Item": "?1"}]
+	}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkParse(t, nil, []ViaElem{{"HTTP/1.1", "fred", ""}, {"HTTP/2.0", "bob", ""}}, v.Via)
+	checkParse(t, nil, LinkElem{Rel: "preconnect", Target: U("https://example.com/")}, v.Link)
+	checkParse(t, nil, DeltaSeconds(3600), v.Delta)
+	checkParse(t, nil, Node{IP: net.ParseIP("2001:db8::1")}, v.Forwarded.For)
+	checkParse(t, nil,
+		[]DictMember{{"u", Item{Value: int64(1)}}, {"i", Item{Value: true}}}, v.Members)
+
+	if err := json.Unmarshal([]byte(`"1.1"`), &ViaElem{}); err == nil {
+		t.Errorf("expected error for malformed wire form")
+	}
+}
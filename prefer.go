@@ -0,0 +1,221 @@
+package httpheader
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+)
+
+// HandlePrefer wraps next into a handler that takes care of the bookkeeping
+// for the Prefer and Preference-Applied headers (RFC 7240). It parses
+// the Prefer header of each request once; handlers access the result with
+// RequestPrefer and report the preferences they honor with ApplyPreference.
+// Before the response header is written, HandlePrefer sets Preference-Applied
+// accordingly and, if RequestPrefer has been called, adds Prefer to Vary,
+// because the response then depends on the request's preferences.
+func HandlePrefer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := &preferState{prefs: Prefer(r.Header)}
+		r = r.WithContext(context.WithValue(r.Context(), preferContextKey{}, state))
+		hw := &hookWriter{ResponseWriter: w}
+		hw.before = func() { state.finish(hw.Header()) }
+		next.ServeHTTP(hw.withOptional(), r)
+		hw.finish()
+	})
+}
+
+type preferContextKey struct{}
+
+type preferState struct {
+	prefs     map[string]Pref
+	applied   map[string]string
+	consulted bool
+}
+
+func preferStateOf(r *http.Request) *preferState {
+	state, _ := r.Context().Value(preferContextKey{}).(*preferState)
+	return state
+}
+
+// RequestPrefer returns the preferences from the Prefer header of r,
+// as parsed by Prefer. When called under HandlePrefer, it returns the already
+// parsed map, which must not be modified, and marks the response as varying
+// by Prefer.
+func RequestPrefer(r *http.Request) map[string]Pref {
+	state := preferStateOf(r)
+	if state == nil {
+		return Prefer(r.Header)
+	}
+	state.consulted = true
+	return state.prefs
+}
+
+// ApplyPreference records that the handler for r has honored the preference
+// with the given name and value, which is usually the value from the request,
+// so that HandlePrefer includes it in Preference-Applied. Outside of
+// HandlePrefer, ApplyPreference does nothing.
+func ApplyPreference(r *http.Request, name, value string) {
+	state := preferStateOf(r)
+	if state == nil {
+		return
+	}
+	if state.applied == nil {
+		state.applied = make(map[string]string)
+	}
+	state.applied[name] = value
+}
+
+// WaitContext returns a copy of r's context that is canceled when
+// the time the client prefers to wait for a response, according to
+// the wait preference (RFC 7240 Section 4.3), runs out, counting from now.
+// If the request context has an earlier deadline, it is kept. If there is
+// no valid wait preference, the returned context only adds cancellation.
+//
+// A handler can use it to bound synchronous processing, after which it can
+// fall back to an asynchronous 202 (Accepted) response if the client has
+// the respond-async preference.
+func WaitContext(r *http.Request) (context.Context, context.CancelFunc) {
+	if wait, ok := PreferWait(RequestPrefer(r)); ok {
+		return context.WithTimeout(r.Context(), wait)
+	}
+	return context.WithCancel(r.Context())
+}
+
+// finish writes Preference-Applied and Vary into h.
+func (state *preferState) finish(h http.Header) {
+	if len(state.applied) > 0 {
+		SetPreferenceApplied(h, state.applied)
+	}
+	if state.consulted {
+		vary := Vary(h)
+		if !vary["Prefer"] && !vary["*"] {
+			AddVary(h, "Prefer")
+		}
+	}
+}
+
+// A hookWriter calls before just before the final response header is written.
+type hookWriter struct {
+	http.ResponseWriter
+	before      func()
+	wroteHeader bool
+}
+
+func (w *hookWriter) WriteHeader(code int) {
+	if code >= 200 {
+		w.finish()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *hookWriter) Write(p []byte) (int, error) {
+	w.finish()
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush implements http.Flusher if the underlying ResponseWriter does.
+func (w *hookWriter) Flush() {
+	w.finish()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (w *hookWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// withOptional returns w with the optional interfaces http.Hijacker,
+// http.Pusher and io.ReaderFrom added if the underlying ResponseWriter
+// implements them, so that handlers that look for them, such as WebSocket
+// upgrades, keep working.
+func (w *hookWriter) withOptional() http.ResponseWriter {
+	_, hijacker := w.ResponseWriter.(http.Hijacker)
+	_, pusher := w.ResponseWriter.(http.Pusher)
+	_, readerFrom := w.ResponseWriter.(io.ReaderFrom)
+	hj, p, rf := hookHijacker{w}, hookPusher{w}, hookReaderFrom{w}
+	switch {
+	case hijacker && pusher && readerFrom:
+		return struct {
+			*hookWriter
+			hookHijacker
+			hookPusher
+			hookReaderFrom
+		}{w, hj, p, rf}
+	case hijacker && pusher:
+		return struct {
+			*hookWriter
+			hookHijacker
+			hookPusher
+		}{w, hj, p}
+	case hijacker && readerFrom:
+		return struct {
+			*hookWriter
+			hookHijacker
+			hookReaderFrom
+		}{w, hj, rf}
+	case pusher && readerFrom:
+		return struct {
+			*hookWriter
+			hookPusher
+			hookReaderFrom
+		}{w, p, rf}
+	case hijacker:
+		return struct {
+			*hookWriter
+			hookHijacker
+		}{w, hj}
+	case pusher:
+		return struct {
+			*hookWriter
+			hookPusher
+		}{w, p}
+	case readerFrom:
+		return struct {
+			*hookWriter
+			hookReaderFrom
+		}{w, rf}
+	default:
+		return w
+	}
+}
+
+type hookHijacker struct{ hw *hookWriter }
+
+// Hijack implements http.Hijacker. The hook is not called after that,
+// because the handler then writes the response itself.
+func (h hookHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := h.hw.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		h.hw.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+type hookPusher struct{ hw *hookWriter }
+
+// Push implements http.Pusher.
+func (p hookPusher) Push(target string, opts *http.PushOptions) error {
+	return p.hw.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+type hookReaderFrom struct{ hw *hookWriter }
+
+// ReadFrom implements io.ReaderFrom, which net/http uses for sendfile.
+func (rf hookReaderFrom) ReadFrom(src io.Reader) (int64, error) {
+	rf.hw.finish()
+	return rf.hw.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+}
+
+// finish calls w.before if it has not been called yet. Handler wrappers
+// must also call it after the wrapped handler returns, because net/http
+// then writes the header without going through w.
+func (w *hookWriter) finish() {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.before()
+	}
+}
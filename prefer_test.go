@@ -0,0 +1,230 @@
+package httpheader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ExampleHandlePrefer() {
+	handler := HandlePrefer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if RequestPrefer(r)["return"].Value == "minimal" {
+			ApplyPreference(r, "return", "minimal")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		fmt.Fprintln(w, `{"id": 42, "name": "Widget"}`)
+	}))
+
+	r := httptest.NewRequest("PUT", "/widgets/42", nil)
+	r.Header.Set("Prefer", "return=minimal")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	fmt.Println(w.Code, w.Header())
+	// Output: 204 map[Preference-Applied:[return=minimal] Vary:[Prefer]]
+}
+
+func TestHandlePrefer(t *testing.T) {
+	tests := []struct {
+		prefer  string
+		handler func(w http.ResponseWriter, r *http.Request)
+		code    int
+		result  http.Header
+	}{
+		{
+			"",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("hello"))
+			},
+			200,
+			http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+		},
+		{
+			"respond-async, wait=10",
+			func(w http.ResponseWriter, r *http.Request) {
+				prefs := RequestPrefer(r)
+				if _, ok := prefs["respond-async"]; ok {
+					ApplyPreference(r, "respond-async", "")
+					w.WriteHeader(http.StatusAccepted)
+				}
+			},
+			202,
+			http.Header{
+				"Preference-Applied": {"respond-async"},
+				"Vary":               {"Prefer"},
+			},
+		},
+		{
+			"",
+			func(w http.ResponseWriter, r *http.Request) {
+				RequestPrefer(r)
+				w.Header().Set("Vary", "Accept, prefer")
+			},
+			200,
+			http.Header{"Vary": {"Accept, prefer"}},
+		},
+		{
+			"handling=lenient",
+			func(w http.ResponseWriter, r *http.Request) {
+				ApplyPreference(r, "handling", "lenient")
+				w.(http.Flusher).Flush()
+				ApplyPreference(r, "return", "minimal")
+			},
+			200,
+			http.Header{"Preference-Applied": {"handling=lenient"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if test.prefer != "" {
+				r.Header.Set("Prefer", test.prefer)
+			}
+			w := httptest.NewRecorder()
+			HandlePrefer(http.HandlerFunc(test.handler)).ServeHTTP(w, r)
+			if w.Code != test.code {
+				t.Errorf("expected status %d, got %d", test.code, w.Code)
+			}
+			checkGenerate(t, test.prefer, test.result, w.Header())
+		})
+	}
+}
+
+func TestHandlePreferOptionalInterfaces(t *testing.T) {
+	wrappers := map[string]func(http.Handler) http.Handler{
+		"HandlePrefer": HandlePrefer,
+		"LintHandler": func(next http.Handler) http.Handler {
+			return LintHandler(next, func(*http.Request, bool, []Problem) {})
+		},
+	}
+	for name, wrap := range wrappers {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(wrap(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					ApplyPreference(r, "return", "minimal")
+					if r.URL.Path == "/read-from" {
+						w.(io.ReaderFrom).ReadFrom(strings.NewReader("read"))
+						return
+					}
+					conn, rw, err := w.(http.Hijacker).Hijack()
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					defer conn.Close()
+					rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\n" +
+						"Connection: close\r\n\r\nhijacked")
+					rw.Flush()
+				})))
+			defer srv.Close()
+			for path, body := range map[string]string{
+				"/read-from": "read",
+				"/hijack":    "hijacked",
+			} {
+				r, err := http.Get(srv.URL + path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				b, _ := ioutil.ReadAll(r.Body)
+				r.Body.Close()
+				if string(b) != body {
+					t.Errorf("%s: expected %q, got %q", path, body, b)
+				}
+				applied := r.Header.Get("Preference-Applied")
+				if name == "HandlePrefer" && path == "/read-from" &&
+					applied != "return=minimal" {
+					t.Errorf("%s: unexpected Preference-Applied: %q", path, applied)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlePreferNoOptionalInterfaces(t *testing.T) {
+	HandlePrefer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Hijacker); ok {
+			t.Errorf("unexpected http.Hijacker")
+		}
+		if _, ok := w.(io.ReaderFrom); ok {
+			t.Errorf("unexpected io.ReaderFrom")
+		}
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func ExampleWaitContext() {
+	handler := HandlePrefer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := WaitContext(r)
+		defer cancel()
+		done := make(chan struct{})
+		go func() {
+			time.Sleep(100 * time.Millisecond) // a long-running job
+			close(done)
+		}()
+		select {
+		case <-done:
+			fmt.Fprintln(w, "job complete")
+		case <-ctx.Done():
+			if !PreferRespondAsync(RequestPrefer(r)) {
+				<-done
+				fmt.Fprintln(w, "job complete")
+				return
+			}
+			ApplyPreference(r, "respond-async", "")
+			w.Header().Set("Location", "/jobs/1")
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+
+	r := httptest.NewRequest("POST", "/jobs", nil)
+	r.Header.Set("Prefer", "respond-async, wait=0")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	fmt.Println(w.Code, w.Header().Get("Preference-Applied"))
+	// Output: 202 respond-async
+}
+
+func TestWaitContext(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Prefer", "wait=10")
+	start := time.Now()
+	ctx, cancel := WaitContext(r)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok || deadline.Before(start.Add(10*time.Second)) ||
+		deadline.After(time.Now().Add(10*time.Second)) {
+		t.Errorf("expected deadline in 10s, got %v (%v)", deadline, ok)
+	}
+
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Second)
+	defer cancelParent()
+	ctx, cancel = WaitContext(r.WithContext(parent))
+	defer cancel()
+	parentDeadline, _ := parent.Deadline()
+	if deadline, _ := ctx.Deadline(); !deadline.Equal(parentDeadline) {
+		t.Errorf("expected parent deadline %v, got %v", parentDeadline, deadline)
+	}
+
+	r.Header.Set("Prefer", "wait=soon")
+	ctx, cancel = WaitContext(r)
+	if _, ok := ctx.Deadline(); ok {
+		t.Errorf("expected no deadline")
+	}
+	cancel()
+	if ctx.Err() == nil {
+		t.Errorf("expected context to be canceled")
+	}
+}
+
+func TestRequestPreferWithoutHandler(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Prefer", "return=minimal")
+	checkParse(t, r.Header, map[string]Pref{"return": {Value: "minimal"}},
+		RequestPrefer(r))
+	ApplyPreference(r, "return", "minimal") // must not panic
+}
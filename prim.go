@@ -1,6 +1,7 @@
 package httpheader
 
 import (
+	"sort"
 	"strings"
 )
 
@@ -27,7 +28,7 @@ func estimateElems(vs []string) int {
 }
 
 // iterElems iterates over elements in comma-separated header fields
-// (RFC 7230 Section 7) spanning multiple field-values (Section 3.2.2).
+// (RFC 9110 Section 5.6.1) spanning multiple field lines (Section 5.3).
 // iterElems moves to the beginning of the next non-empty element in v.
 // If there are no more such elements in v, takes the next v from vs.
 // Returns the new values for v and vs, with v == "" meaning end of iteration.
@@ -96,24 +97,40 @@ func consumeTo(v string, delim byte, including bool) (text, newv string) {
 	return v[:pos], v[pos+1:]
 }
 
-// consumeQuoted returns the text of the quoted string (RFC 7230 Section 3.2.6)
+// consumeQuoted returns the text of the quoted string (RFC 9110 Section 5.6.4)
 // at the beginning of v (with any quoted pairs replaced), and the rest of v after
 // the closing quote. If the quoted string is not terminated properly (e.g. because
 // the closing quote is erroneously escaped as a quoted pair), it consumes
 // the entire v. If v doesn't start with a double quote, it consumes nothing.
 func consumeQuoted(v string) (text, newv string) {
-	return consumeDelimited(v, '"', '"')
+	return Limits{}.consumeDelimited(v, '"', '"') // quoted strings do not nest
 }
 
-// consumeComment is like consumeQuoted but for comments (possibly nested).
-func consumeComment(v string) (text, newv string) {
-	return consumeDelimited(v, '(', ')')
+// consumeComment is like consumeQuoted but for comments (possibly nested
+// up to l.MaxNesting).
+func (l Limits) consumeComment(v string) (text, newv string) {
+	return l.consumeDelimited(v, '(', ')')
 }
 
-func consumeDelimited(v string, opener, closer byte) (text, newv string) {
+func (l Limits) consumeDelimited(v string, opener, closer byte) (text, newv string) {
 	if peek(v) != opener {
 		return "", v
 	}
+	text, newv, ok := l.consumeDelimitedOK(v, opener, closer)
+	if !ok {
+		newv = ""
+	}
+	return text, newv
+}
+
+// consumeDelimitedOK is like consumeDelimited but also reports whether
+// the string is terminated properly. If it is nested deeper than l.MaxNesting,
+// ok is false and newv starts at the offending opener; if it is otherwise
+// unterminated, ok is false and newv is empty.
+func (l Limits) consumeDelimitedOK(v string, opener, closer byte) (text, newv string, ok bool) {
+	if peek(v) != opener {
+		return "", v, false
+	}
 	v = v[1:]
 
 	// In the common case, when there are no quoted pairs,
@@ -125,17 +142,20 @@ func consumeDelimited(v string, opener, closer byte) (text, newv string) {
 		case closer:
 			nesting--
 			if nesting == 0 {
-				return v[:i], v[i+1:]
+				return v[:i], v[i+1:], true
 			}
 		case opener:
 			nesting++
+			if l.tooDeep(nesting) {
+				return v[:i], v[i:], false
+			}
 		case '\\': // start of a quoted pair
 			goto buffered
 		}
 	}
 	// We've reached the end of v, but nesting is still > 0.
 	// This is an unterminated string.
-	return v, ""
+	return v, "", false
 
 buffered:
 	// Once we have encountered a quoted pair, we have to unquote into a buffer.
@@ -150,11 +170,14 @@ buffered:
 		case v[i] == closer:
 			nesting--
 			if nesting == 0 {
-				return b.String(), v[i+1:]
+				return b.String(), v[i+1:], true
 			}
 			b.WriteByte(v[i])
 		case v[i] == opener:
 			nesting++
+			if l.tooDeep(nesting) {
+				return b.String(), v[i:], false
+			}
 			b.WriteByte(v[i])
 		case v[i] == '\\':
 			quoted = true
@@ -164,7 +187,14 @@ buffered:
 	}
 	// We've reached the end of v, but nesting is still > 0.
 	// This is an unterminated string.
-	return b.String(), ""
+	return b.String(), "", false
+}
+
+// tooDeep reports whether nesting of comments exceeds l.MaxNesting.
+// The rest of the field line is then discarded, because it cannot be told
+// where the comment ends without going deeper.
+func (l Limits) tooDeep(nesting int) bool {
+	return l.MaxNesting > 0 && nesting > l.MaxNesting
 }
 
 func writeQuoted(b *strings.Builder, s string) {
@@ -213,13 +243,16 @@ func writeTokenOrQuoted(b *strings.Builder, s string) {
 	}
 }
 
-func consumeParams(v string) (params map[string]string, newv string) {
+func (l Limits) consumeParams(v string) (params map[string]string, newv string) {
 	for {
 		var name, value string
 		name, value, v = consumeParam(v)
 		if name == "" {
 			break
 		}
+		if l.paramsDone(len(params)) {
+			continue
+		}
 		// Use only the first occurrence of each param name.
 		// This is required in some other places that don't use consumeParams,
 		// but it seems like reasonable behavior in general.
@@ -235,18 +268,31 @@ func consumeParams(v string) (params map[string]string, newv string) {
 }
 
 func consumeParam(v string) (name, value, newv string) {
+	name, value, v = consumeRawParam(v)
+	return strings.ToLower(name), value, v
+}
+
+// consumeRawParam is like consumeParam but doesn't lowercase the name.
+func consumeRawParam(v string) (name, value, newv string) {
+	name, value, _, newv = consumeRawParamEq(v)
+	return
+}
+
+// consumeRawParamEq is like consumeRawParam but also reports whether
+// the parameter has an equals sign, so that "a" can be told from a="".
+func consumeRawParamEq(v string) (name, value string, eq bool, newv string) {
 	v = skipWSAnd(v, ';')
 	name, v = consumeItem(v)
 	if name == "" {
-		return "", "", v
+		return "", "", false, v
 	}
-	name = strings.ToLower(name)
 	v = skipWS(v)
 	if peek(v) == '=' {
 		v = skipWS(v[1:])
 		value, v = consumeItemOrQuoted(v)
+		eq = true
 	}
-	return name, value, v
+	return name, value, eq, v
 }
 
 func writeDirective(b *strings.Builder, wrote bool, name, value string) bool {
@@ -274,14 +320,29 @@ func writeParam(b *strings.Builder, wrote bool, name, value string) bool {
 	return true
 }
 
+// sortedKeys returns the keys of m in sorted order. Generators iterate maps
+// in this order, so that their output is deterministic.
+func sortedKeys(m map[string]string) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func writeParams(b *strings.Builder, params map[string]string) {
-	for name, value := range params {
-		writeParam(b, true, name, value)
+	for _, name := range sortedKeys(params) {
+		writeParam(b, true, name, params[name])
 	}
 }
 
 func writeNullableParams(b *strings.Builder, params map[string]string) {
-	for name, value := range params {
+	for _, name := range sortedKeys(params) {
+		value := params[name]
 		write(b, ";", name)
 		if value != "" {
 			write(b, "=")
@@ -299,6 +360,9 @@ func insertVariform(params map[string]string, name, value string) map[string]str
 	}
 	if strings.HasSuffix(name, "*") {
 		plainName := name[:len(name)-1]
+		if plainName == "" || strings.HasSuffix(plainName, "*") {
+			return params // not a valid parameter name
+		}
 		if decoded, _, err := DecodeExtValue(value); err == nil {
 			params[plainName] = decoded
 		}
@@ -0,0 +1,131 @@
+package httpheader
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// A Hop describes how a proxy identifies itself in the Forwarded
+// (RFC 7239) and Via (RFC 9110 Section 7.6.3) headers that it adds
+// to requests passing through it. The zero Hop is ready to use.
+type Hop struct {
+	// ReceivedBy is the received-by part of the Via element, such as
+	// a pseudonym of the proxy. If empty, the pseudonym "proxy" is used,
+	// so as not to reveal the proxy's own address.
+	ReceivedBy string
+
+	// Obfuscate, if not nil, is applied to the For and By nodes
+	// before they are added to the Forwarded header, so that it can hide
+	// IP addresses and ports (RFC 7239 Section 6.3). See Obfuscator.
+	Obfuscate func(Node) Node
+}
+
+// AddHop appends to r.Header one Forwarded element and one Via element
+// describing how r was received by the proxy: for is the remote address,
+// by is the local address (if known), host is r.Host, and proto is "https"
+// or "http" depending on r.TLS.
+//
+// r must be a server request, as passed to an http.Handler or to the Director
+// of httputil.ReverseProxy. In the latter case, AddHop must be called before
+// rewriting r.Host and r.URL; see the Director and Rewrite methods.
+func AddHop(r *http.Request, hop Hop) {
+	hop.add(r.Header, r)
+}
+
+// add appends to h the Forwarded and Via elements describing how
+// the server request r was received.
+func (hop Hop) add(h http.Header, r *http.Request) {
+	elem := ForwardedElem{
+		For:   addrNode(r.RemoteAddr),
+		Host:  r.Host,
+		Proto: "http",
+	}
+	if r.TLS != nil {
+		elem.Proto = "https"
+	}
+	localAddr, _ := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	if localAddr != nil {
+		elem.By = addrNode(localAddr.String())
+	}
+	if hop.Obfuscate != nil {
+		if !elem.For.isZero() {
+			elem.For = hop.Obfuscate(elem.For)
+		}
+		if !elem.By.isZero() {
+			elem.By = hop.Obfuscate(elem.By)
+		}
+	}
+	AddForwarded(h, elem)
+
+	AddVia(h, ViaElem{
+		ReceivedProto: canonicalProto(r.Proto),
+		ReceivedBy:    hop.receivedBy(),
+	})
+}
+
+func (hop Hop) receivedBy() string {
+	if hop.ReceivedBy == "" {
+		return "proxy"
+	}
+	return hop.ReceivedBy
+}
+
+// Check determines if r, a request received by the proxy, may be forwarded,
+// in which case it returns 0 and decrements Max-Forwards in r.Header
+// as necessary (see DecrementMaxForwards). Otherwise, it returns the status code
+// that the proxy should respond with:
+//
+//   - http.StatusLoopDetected if ReceivedBy is not empty and the Via header
+//     of r shows that r has already passed through this proxy (see ViaLoop);
+//   - http.StatusBadGateway if maxHops is positive and Via already has
+//     at least maxHops elements;
+//   - http.StatusOK if r is a TRACE or OPTIONS request with Max-Forwards: 0,
+//     meaning that the proxy must respond to r as the final recipient.
+//
+// Loops are only detected when ReceivedBy is a pseudonym unique to this proxy.
+// Check must be called before AddHop.
+func (hop Hop) Check(r *http.Request, maxHops int) int {
+	loop, hops := ViaLoop(r.Header, hop.ReceivedBy)
+	loop = loop && hop.ReceivedBy != ""
+	switch {
+	case loop:
+		return http.StatusLoopDetected
+	case maxHops > 0 && hops >= maxHops:
+		return http.StatusBadGateway
+	case !DecrementMaxForwards(r):
+		return http.StatusOK
+	default:
+		return 0
+	}
+}
+
+// Director wraps director, which may be nil, into a function that calls AddHop
+// before calling director. It is intended for the Director field of
+// httputil.ReverseProxy. Note that httputil.ReverseProxy also adds
+// X-Forwarded-For, unless director sets it to nil in the request header.
+func (hop Hop) Director(director func(*http.Request)) func(*http.Request) {
+	return func(r *http.Request) {
+		AddHop(r, hop)
+		if director != nil {
+			director(r)
+		}
+	}
+}
+
+// addrNode converts an address in the form of http.Request's RemoteAddr
+// to a Node. IPv6 zones are dropped, because RFC 7239 has no place for them.
+func addrNode(addr string) Node {
+	var node Node
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if zone := strings.IndexByte(host, '%'); zone != -1 {
+		host = host[:zone]
+	}
+	node.IP = net.ParseIP(host)
+	node.Port, _ = strconv.Atoi(port)
+	return node
+}
@@ -0,0 +1,29 @@
+//go:build go1.20
+// +build go1.20
+
+package httpheader
+
+import "net/http/httputil"
+
+// Rewrite wraps rewrite, which may be nil, into a function that adds
+// a Forwarded element and a Via element to pr.Out, as AddHop does for pr.In,
+// before calling rewrite. It is intended for the Rewrite field of
+// httputil.ReverseProxy.
+//
+// httputil.ReverseProxy removes Forwarded from pr.Out before calling Rewrite.
+// The function returned by Rewrite restores the elements of Forwarded
+// that pr.In was received with, and appends its own after them. If they come
+// from untrusted clients, rewrite may remove them from pr.Out.Header,
+// keeping only the last element. Unlike with Director, X-Forwarded-For
+// is not added unless rewrite calls pr.SetXForwarded.
+func (hop Hop) Rewrite(rewrite func(*httputil.ProxyRequest)) func(*httputil.ProxyRequest) {
+	return func(pr *httputil.ProxyRequest) {
+		if forwarded := pr.In.Header["Forwarded"]; forwarded != nil {
+			pr.Out.Header["Forwarded"] = append([]string(nil), forwarded...)
+		}
+		hop.add(pr.Out.Header, pr.In)
+		if rewrite != nil {
+			rewrite(pr)
+		}
+	}
+}
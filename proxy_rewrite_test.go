@@ -0,0 +1,55 @@
+//go:build go1.20
+// +build go1.20
+
+package httpheader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+)
+
+func ExampleHop_Rewrite() {
+	target, _ := url.Parse("http://backend.internal:8080")
+	proxy := &httputil.ReverseProxy{
+		Rewrite: Hop{ReceivedBy: "gw1"}.Rewrite(func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+		}),
+	}
+	_ = proxy
+}
+
+func TestHopRewrite(t *testing.T) {
+	in := httptest.NewRequest("GET", "http://example.com/", nil)
+	in.Header.Set("Forwarded", "for=_a")
+	in.Header.Set("Via", "1.0 fred")
+	out := in.Clone(in.Context())
+	out.Header.Del("Forwarded") // as done by httputil.ReverseProxy
+	pr := &httputil.ProxyRequest{In: in, Out: out}
+	rewrite := Hop{ReceivedBy: "gw1"}.Rewrite(func(pr *httputil.ProxyRequest) {
+		pr.Out.Host = "backend.internal"
+	})
+	rewrite(pr)
+	expected := http.Header{
+		"Forwarded": {"for=_a", `for="192.0.2.1:1234";host=example.com;proto=http`},
+		"Via":       {"1.0 fred", "1.1 gw1"},
+	}
+	checkGenerate(t, in, expected, out.Header)
+	checkGenerate(t, in, http.Header{
+		"Forwarded": {"for=_a"},
+		"Via":       {"1.0 fred"},
+	}, in.Header)
+}
+
+func TestHopRewriteNil(t *testing.T) {
+	in := httptest.NewRequest("GET", "http://example.com/", nil)
+	out := in.Clone(in.Context())
+	Hop{}.Rewrite(nil)(&httputil.ProxyRequest{In: in, Out: out})
+	expected := http.Header{
+		"Forwarded": {`for="192.0.2.1:1234";host=example.com;proto=http`},
+		"Via":       {"1.1 proxy"},
+	}
+	checkGenerate(t, in, expected, out.Header)
+}
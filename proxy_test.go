@@ -0,0 +1,207 @@
+package httpheader
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func ExampleHop_Director() {
+	target, _ := url.Parse("http://backend.internal:8080")
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Director = Hop{ReceivedBy: "gw1"}.Director(proxy.Director)
+}
+
+func TestAddHop(t *testing.T) {
+	tests := []struct {
+		remoteAddr string
+		localAddr  net.Addr
+		tls        bool
+		hop        Hop
+		result     http.Header
+	}{
+		{
+			"192.0.2.43:50123",
+			nil,
+			false,
+			Hop{},
+			http.Header{
+				"Forwarded": {`for="192.0.2.43:50123";host=example.com;proto=http`},
+				"Via":       {"1.1 proxy"},
+			},
+		},
+		{
+			"[2001:db8:cafe::17]:4711",
+			&net.TCPAddr{IP: mustParseIP("2001:db8::1"), Port: 443},
+			true,
+			Hop{},
+			http.Header{
+				"Forwarded": {`for="[2001:db8:cafe::17]:4711";by="[2001:db8::1]:443";host=example.com;proto=https`},
+				"Via":       {"1.1 proxy"},
+			},
+		},
+		{
+			"[fe80::1%eth0]:4711",
+			&net.TCPAddr{IP: net.IPv4(198, 51, 100, 1), Port: 80},
+			false,
+			Hop{ReceivedBy: "gw1"},
+			http.Header{
+				"Forwarded": {`for="[fe80::1]:4711";by="198.51.100.1:80";host=example.com;proto=http`},
+				"Via":       {"1.1 gw1"},
+			},
+		},
+		{
+			"192.0.2.43:50123",
+			&net.TCPAddr{IP: net.IPv4(198, 51, 100, 1), Port: 80},
+			false,
+			Hop{
+				ReceivedBy: "gw1",
+				Obfuscate: func(node Node) Node {
+					return Node{ObfuscatedNode: "_hidden"}
+				},
+			},
+			http.Header{
+				"Forwarded": {`for=_hidden;by=_hidden;host=example.com;proto=http`},
+				"Via":       {"1.1 gw1"},
+			},
+		},
+		{
+			// The zero By node is not passed to Obfuscate.
+			"192.0.2.43:50123",
+			nil,
+			false,
+			Hop{
+				Obfuscate: func(node Node) Node {
+					return Node{ObfuscatedNode: "_hidden"}
+				},
+			},
+			http.Header{
+				"Forwarded": {`for=_hidden;host=example.com;proto=http`},
+				"Via":       {"1.1 proxy"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://example.com/", nil)
+			r.RemoteAddr = test.remoteAddr
+			if test.localAddr != nil {
+				ctx := context.WithValue(r.Context(),
+					http.LocalAddrContextKey, test.localAddr)
+				r = r.WithContext(ctx)
+			}
+			if test.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+			AddHop(r, test.hop)
+			checkGenerate(t, test.hop, test.result, r.Header)
+		})
+	}
+}
+
+func TestHopDirector(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://example.com/", nil)
+	r.Header.Set("Via", "1.0 fred")
+	director := Hop{ReceivedBy: "gw1"}.Director(func(r *http.Request) {
+		r.Host = "backend.internal"
+	})
+	director(r)
+	expected := http.Header{
+		"Forwarded": {`for="192.0.2.1:1234";host=example.com;proto=http`},
+		"Via":       {"1.0 fred", "1.1 gw1"},
+	}
+	checkGenerate(t, r, expected, r.Header)
+}
+
+func TestHopCheck(t *testing.T) {
+	tests := []struct {
+		method  string
+		header  http.Header
+		maxHops int
+		status  int
+		result  http.Header
+	}{
+		{
+			"GET",
+			http.Header{"Via": {"1.1 foo, 1.1 bar"}},
+			0,
+			0,
+			http.Header{"Via": {"1.1 foo, 1.1 bar"}},
+		},
+		{
+			"GET",
+			http.Header{"Via": {"1.1 foo, 1.1 gw1"}},
+			0,
+			http.StatusLoopDetected,
+			http.Header{"Via": {"1.1 foo, 1.1 gw1"}},
+		},
+		{
+			"GET",
+			http.Header{"Via": {"1.1 foo, 1.1 bar"}},
+			2,
+			http.StatusBadGateway,
+			http.Header{"Via": {"1.1 foo, 1.1 bar"}},
+		},
+		{
+			"TRACE",
+			http.Header{"Max-Forwards": {"0"}},
+			2,
+			http.StatusOK,
+			http.Header{"Max-Forwards": {"0"}},
+		},
+		{
+			"TRACE",
+			http.Header{"Max-Forwards": {"1"}},
+			2,
+			0,
+			http.Header{"Max-Forwards": {"0"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			r := httptest.NewRequest(test.method, "http://example.com/", nil)
+			r.Header = test.header
+			status := Hop{ReceivedBy: "gw1"}.Check(r, test.maxHops)
+			if status != test.status {
+				t.Errorf("%s with %#v: expected %d, got %d",
+					test.method, test.header, test.status, status)
+			}
+			checkGenerate(t, test.method, test.result, r.Header)
+		})
+	}
+}
+
+func TestHopCheckLongVia(t *testing.T) {
+	via := strings.Repeat("1.1 foo, ", 1099) + "1.1 bar"
+	tests := []struct {
+		maxHops int
+		status  int
+	}{
+		{0, 0},
+		{1100, http.StatusBadGateway},
+		{1101, 0},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "http://example.com/", nil)
+		r.Header.Set("Via", via)
+		status := Hop{ReceivedBy: "gw1"}.Check(r, test.maxHops)
+		if status != test.status {
+			t.Errorf("with maxHops %d: expected %d, got %d",
+				test.maxHops, test.status, status)
+		}
+	}
+}
+
+func TestHopCheckNoReceivedBy(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://example.com/", nil)
+	r.Header.Set("Via", "1.1 example.com, 1.1 proxy, 1.1")
+	if status := (Hop{}).Check(r, 0); status != 0 {
+		t.Errorf("expected no loop without ReceivedBy, got %d", status)
+	}
+}
@@ -0,0 +1,50 @@
+package httpheader
+
+import (
+	"net/http"
+	"net/textproto"
+)
+
+// A FieldSource provides the field values of a header by its canonical name,
+// returning nil if there is no such header. Since Go 1.14, http.Header
+// (including the Trailer of http.Request and http.Response) and
+// textproto.MIMEHeader (such as the header of a multipart part) are
+// FieldSources. Servers that keep headers as raw bytes can use RawFields.
+type FieldSource interface {
+	Values(name string) []string
+}
+
+// Fields returns an http.Header with the headers of the given names from src,
+// for the functions in this package. Only these names are looked up in src,
+// and the field values returned by src are shared, not copied. Names are
+// canonicalized.
+//
+//	h := httpheader.Fields(httpheader.RawFields(ctx.Request.Header.Peek), "Accept")
+//	accept := httpheader.Accept(h)
+func Fields(src FieldSource, names ...string) http.Header {
+	h := make(http.Header, len(names))
+	for _, name := range names {
+		name = textproto.CanonicalMIMEHeaderKey(name)
+		if values := src.Values(name); values != nil {
+			h[name] = values
+		}
+	}
+	return h
+}
+
+// RawFields adapts a function that returns the raw value of a header,
+// or nil if there is none, to FieldSource. Its signature matches the Peek
+// method of servers like fasthttp, which join repeated field lines
+// with commas. Because Go strings are immutable, the value is copied once,
+// but only when it is looked up.
+type RawFields func(name string) []byte
+
+// Values returns the value of the header with the given name as a single
+// field line, or nil.
+func (f RawFields) Values(name string) []string {
+	value := f(name)
+	if value == nil {
+		return nil
+	}
+	return []string{string(value)}
+}
@@ -0,0 +1,16 @@
+//go:build go1.14
+// +build go1.14
+
+package httpheader
+
+import (
+	"net/http"
+	"net/textproto"
+)
+
+// Since Go 1.14, headers and trailers from net/http, as well as MIME headers,
+// can be used as they are.
+var (
+	_ FieldSource = http.Header(nil)
+	_ FieldSource = textproto.MIMEHeader(nil)
+)
@@ -0,0 +1,102 @@
+package httpheader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func ExampleRawFields() {
+	// Raw fields as kept by a server that doesn't use net/http.
+	raw := map[string][]byte{"accept": []byte("text/html;q=0.9, application/json")}
+	peek := func(name string) []byte { return raw[strings.ToLower(name)] }
+	h := Fields(RawFields(peek), "Accept")
+	fmt.Println(MatchAccept(Accept(h), "application/json").Q)
+	// Output: 1
+}
+
+// sliceFields is a FieldSource that records the names looked up in it.
+type sliceFields struct {
+	fields map[string][]string
+	looked []string
+}
+
+func (s *sliceFields) Values(name string) []string {
+	s.looked = append(s.looked, name)
+	return s.fields[name]
+}
+
+func TestFields(t *testing.T) {
+	values := []string{"no-cache", "max-age=60"}
+	src := &sliceFields{fields: map[string][]string{
+		"Cache-Control": values,
+		"Vary":          {"Accept"},
+	}}
+	h := Fields(src, "cache-control", "X-Missing")
+	checkParse(t, nil,
+		http.Header{"Cache-Control": values}, h,
+		[]string{"Cache-Control", "X-Missing"}, src.looked,
+	)
+	if &h["Cache-Control"][0] != &values[0] {
+		t.Errorf("field values were copied")
+	}
+
+	raw := RawFields(func(name string) []byte {
+		if name == "Accept" {
+			return []byte{}
+		}
+		return nil
+	})
+	checkParse(t, nil,
+		[]string{""}, raw.Values("Accept"),
+		[]string(nil), raw.Values("Vary"),
+	)
+}
+
+// Example_multipart shows how to parse headers of a multipart body part,
+// which are a textproto.MIMEHeader. It has the same underlying type
+// as http.Header, so it can be converted without copying.
+func Example_multipart() {
+	const body = "--BOUNDARY\r\n" +
+		"Content-Disposition: form-data; name=\"file\"; filename*=UTF-8''%e2%82%ac.txt\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"rates\r\n" +
+		"--BOUNDARY--\r\n"
+	mr := multipart.NewReader(strings.NewReader(body), "BOUNDARY")
+	part, _ := mr.NextPart()
+	_, filename, params := ContentDisposition(http.Header(part.Header))
+	fmt.Println(params["name"], filename)
+	// Output: file €.txt
+}
+
+// TestTrailer checks that functions in this package work on trailers,
+// which net/http stores in an http.Header that is filled in
+// once the body has been read.
+func TestTrailer(t *testing.T) {
+	const response = "HTTP/1.1 200 OK\r\n" +
+		"Transfer-Encoding: chunked\r\n" +
+		"Trailer: Server-Timing, Warning\r\n" +
+		"\r\n" +
+		"5\r\nhello\r\n0\r\n" +
+		"Warning: 299 - \"Deprecated\"\r\n" +
+		"\r\n"
+	resp, err := http.ReadResponse(bufio.NewReader(strings.NewReader(response)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elems := Warning(resp.Trailer); elems != nil {
+		t.Errorf("expected no Warning before the body is read, got %#v", elems)
+	}
+	if _, err := io.Copy(ioutil.Discard, resp.Body); err != nil {
+		t.Fatal(err)
+	}
+	checkParse(t, resp.Trailer,
+		[]WarningElem{{Code: 299, Agent: "-", Text: "Deprecated"}},
+		Warning(resp.Trailer))
+}
@@ -13,7 +13,13 @@ import (
 // 'filename'. Similarly for any other parameter whose name ends in an asterisk.
 // UTF-8 is not validated in such strings.
 func ContentDisposition(h http.Header) (dtype, filename string, params map[string]string) {
-	v := h.Get("Content-Disposition")
+	return DefaultLimits().ContentDisposition(h)
+}
+
+// ContentDisposition is like the ContentDisposition function, but applies l
+// instead of DefaultLimits.
+func (l Limits) ContentDisposition(h http.Header) (dtype, filename string, params map[string]string) {
+	v := l.value(h.Get("Content-Disposition"))
 	dtype, v = consumeItem(v)
 	dtype = strings.ToLower(dtype)
 ParamsLoop:
@@ -32,12 +38,30 @@ ParamsLoop:
 				filename = decoded
 			}
 		default:
+			if l.paramsDone(len(params)) {
+				continue
+			}
 			params = insertVariform(params, name, value)
 		}
 	}
 	return
 }
 
+// StrictContentDisposition is like ContentDisposition, but calls Check first.
+func StrictContentDisposition(h http.Header) (dtype, filename string, params map[string]string, err error) {
+	return DefaultLimits().StrictContentDisposition(h)
+}
+
+// StrictContentDisposition is like the StrictContentDisposition function, but
+// applies l instead of DefaultLimits.
+func (l Limits) StrictContentDisposition(h http.Header) (dtype, filename string, params map[string]string, err error) {
+	if err := l.Check(h, "Content-Disposition"); err != nil {
+		return "", "", nil, err
+	}
+	dtype, filename, params = l.ContentDisposition(h)
+	return dtype, filename, params, nil
+}
+
 // SetContentDisposition replaces the Content-Disposition header in h.
 //
 // If filename is not empty, it must be valid UTF-8, which is serialized into
@@ -53,7 +77,8 @@ func SetContentDisposition(h http.Header, dtype, filename string, params map[str
 	if filename != "" {
 		writeVariform(b, "filename", filename)
 	}
-	for name, value := range params {
+	for _, name := range sortedKeys(params) {
+		value := params[name]
 		if strings.ToLower(strings.TrimSuffix(name, "*")) == "filename" {
 			continue
 		}
@@ -109,6 +109,10 @@ func TestContentDisposition(t *testing.T) {
 			http.Header{"Content-Disposition": {"attachment; filename*='"}},
 			"attachment", "", nil,
 		},
+		{
+			http.Header{"Content-Disposition": {"attachment; *=UTF-8''foo"}},
+			"attachment", "", map[string]string{},
+		},
 		{
 			http.Header{"Content-Disposition": {"attachment; filename*=''"}},
 			"attachment", "", nil,
@@ -141,6 +145,13 @@ func TestContentDisposition(t *testing.T) {
 			}},
 			"attachment", "", nil,
 		},
+		{
+			// Not valid parameter names.
+			http.Header{"Content-Disposition": {
+				"attachment; *=UTF-8''a; **=UTF-8''b; x**=UTF-8''c; x=y",
+			}},
+			"attachment", "", map[string]string{"x": "y"},
+		},
 	}
 	for _, test := range tests {
 		t.Run("", func(t *testing.T) {
@@ -0,0 +1,283 @@
+package httpheader
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ExpandURITemplate expands template, which is a URI Template (RFC 6570),
+// up to and including Level 4, with the given variables. The value of each
+// variable may be:
+//
+//	string             a simple string value
+//	[]string           a list
+//	map[string]string  an associative array, expanded in order of keys
+//	[][2]string        an associative array of name-value pairs, in order
+//
+// Variables that are missing from vars, are nil, or are empty lists or
+// associative arrays are undefined, and are skipped as specified in RFC 6570.
+// An error is returned if template is malformed or a variable has a value
+// of some other type.
+func ExpandURITemplate(template string, vars map[string]interface{}) (string, error) {
+	b := &strings.Builder{}
+	for i := 0; i < len(template); {
+		switch template[i] {
+		case '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end == -1 {
+				return "", templateError(i, "unclosed expression")
+			}
+			if err := expandExpression(b, template[i+1:i+end], i+1, vars); err != nil {
+				return "", err
+			}
+			i += end + 1
+		case '}':
+			return "", templateError(i, "unexpected '}'")
+		default:
+			// Literals are copied, percent-encoding any characters
+			// not allowed in a URI.
+			n := strings.IndexAny(template[i:], "{}")
+			if n == -1 {
+				n = len(template) - i
+			}
+			templateEncode(b, template[i:i+n], true)
+			i += n
+		}
+	}
+	return b.String(), nil
+}
+
+func templateError(offset int, msg string) error {
+	return fmt.Errorf("bad URI template at offset %d: %s", offset, msg)
+}
+
+// A templateOp describes the expansion behavior of an expression operator
+// (RFC 6570 Appendix A).
+type templateOp struct {
+	first, sep string
+	named      bool
+	ifEmpty    string
+	reserved   bool // allow reserved characters unencoded
+}
+
+var templateOps = map[byte]templateOp{
+	'+': {"", ",", false, "", true},
+	'#': {"#", ",", false, "", true},
+	'.': {".", ".", false, "", false},
+	'/': {"/", "/", false, "", false},
+	';': {";", ";", true, "", false},
+	'?': {"?", "&", true, "=", false},
+	'&': {"&", "&", true, "=", false},
+}
+
+func expandExpression(b *strings.Builder, expr string, offset int,
+	vars map[string]interface{}) error {
+	op := templateOp{"", ",", false, "", false}
+	if expr != "" {
+		if o, ok := templateOps[expr[0]]; ok {
+			op = o
+			expr, offset = expr[1:], offset+1
+		} else if strings.IndexByte("=,!@|", expr[0]) != -1 {
+			return templateError(offset, "reserved operator")
+		}
+	}
+	first := true
+	for _, spec := range strings.Split(expr, ",") {
+		name, prefix, explode, err := parseVarSpec(spec, offset)
+		if err != nil {
+			return err
+		}
+		offset += len(spec) + 1
+		value, err := templateValue(vars[name], name)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			continue
+		}
+		if first {
+			write(b, op.first)
+			first = false
+		} else {
+			write(b, op.sep)
+		}
+		expandVar(b, op, name, value, prefix, explode)
+	}
+	return nil
+}
+
+// parseVarSpec parses a varspec (RFC 6570 Section 2.3 and 2.4).
+// A prefix of 0 means no prefix modifier.
+func parseVarSpec(spec string, offset int) (name string, prefix int, explode bool, err error) {
+	name = spec
+	if strings.HasSuffix(name, "*") {
+		name, explode = name[:len(name)-1], true
+	} else if i := strings.IndexByte(name, ':'); i != -1 {
+		name = spec[:i]
+		prefix, err = strconv.Atoi(spec[i+1:])
+		if err != nil || prefix <= 0 || prefix >= 10000 || spec[i+1] == '0' {
+			return "", 0, false, templateError(offset+i, "bad prefix modifier")
+		}
+	}
+	if name == "" {
+		return "", 0, false, templateError(offset, "empty variable name")
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case isAlpha(c), isDigit(c), c == '_':
+		case c == '.' && i > 0 && i < len(name)-1 && name[i-1] != '.':
+		case c == '%' && i+2 < len(name) && isHex(name[i+1]) && isHex(name[i+2]):
+			i += 2
+		default:
+			return "", 0, false, templateError(offset+i, "bad variable name")
+		}
+	}
+	return name, prefix, explode, nil
+}
+
+// templateValue converts v into a string, a []string, or a [][2]string,
+// or nil if it is undefined.
+func templateValue(v interface{}, name string) (interface{}, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return v, nil
+	case []string:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		return v, nil
+	case [][2]string:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		return v, nil
+	case map[string]string:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		pairs := make([][2]string, 0, len(v))
+		for _, key := range keys {
+			pairs = append(pairs, [2]string{key, v[key]})
+		}
+		return pairs, nil
+	default:
+		return nil, fmt.Errorf("cannot expand URI template variable %q of type %T", name, v)
+	}
+}
+
+func expandVar(b *strings.Builder, op templateOp, name string, value interface{},
+	prefix int, explode bool) {
+	switch value := value.(type) {
+	case string:
+		if op.named {
+			write(b, name)
+			if value == "" {
+				write(b, op.ifEmpty)
+				return
+			}
+			write(b, "=")
+		}
+		if prefix > 0 && utf8.RuneCountInString(value) > prefix {
+			n := 0
+			for i := range value {
+				if n == prefix {
+					value = value[:i]
+					break
+				}
+				n++
+			}
+		}
+		templateEncode(b, value, op.reserved)
+
+	case []string:
+		if !explode {
+			if op.named {
+				write(b, name, "=")
+			}
+			for i, item := range value {
+				if i > 0 {
+					write(b, ",")
+				}
+				templateEncode(b, item, op.reserved)
+			}
+			return
+		}
+		for i, item := range value {
+			if i > 0 {
+				write(b, op.sep)
+			}
+			if op.named {
+				write(b, name)
+				if item == "" {
+					write(b, op.ifEmpty)
+					continue
+				}
+				write(b, "=")
+			}
+			templateEncode(b, item, op.reserved)
+		}
+
+	case [][2]string:
+		if !explode {
+			if op.named {
+				write(b, name, "=")
+			}
+			for i, pair := range value {
+				if i > 0 {
+					write(b, ",")
+				}
+				templateEncode(b, pair[0], op.reserved)
+				write(b, ",")
+				templateEncode(b, pair[1], op.reserved)
+			}
+			return
+		}
+		for i, pair := range value {
+			if i > 0 {
+				write(b, op.sep)
+			}
+			templateEncode(b, pair[0], op.reserved)
+			if op.named && pair[1] == "" {
+				write(b, op.ifEmpty)
+				continue
+			}
+			write(b, "=")
+			templateEncode(b, pair[1], op.reserved)
+		}
+	}
+}
+
+// templateEncode writes s, percent-encoding any characters other than
+// unreserved characters or, if reserved is true, reserved characters
+// and existing pct-encoded triplets (RFC 6570 Section 3.2.1).
+func templateEncode(b *strings.Builder, s string, reserved bool) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case isAlpha(c), isDigit(c), strings.IndexByte("-._~", c) != -1:
+			b.WriteByte(c)
+		case reserved && strings.IndexByte(":/?#[]@!$&'()*+,;=", c) != -1:
+			b.WriteByte(c)
+		case reserved && c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			write(b, s[i:i+3])
+			i += 2
+		default:
+			fmt.Fprintf(b, "%%%02X", c)
+		}
+	}
+}
+
+func isHex(c byte) bool {
+	return isDigit(c) || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
@@ -0,0 +1,240 @@
+package httpheader
+
+import (
+	"fmt"
+	"testing"
+)
+
+func ExampleExpandURITemplate() {
+	u, _ := ExpandURITemplate("/search{?q,lang}", map[string]interface{}{
+		"q":    "cat pictures",
+		"lang": "en",
+	})
+	fmt.Println(u)
+	// Output: /search?q=cat%20pictures&lang=en
+}
+
+// Variables from RFC 6570 Section 3.2.
+var rfc6570Vars = map[string]interface{}{
+	"count":      []string{"one", "two", "three"},
+	"dom":        []string{"example", "com"},
+	"dub":        "me/too",
+	"hello":      "Hello World!",
+	"half":       "50%",
+	"var":        "value",
+	"who":        "fred",
+	"base":       "http://example.com/home/",
+	"path":       "/foo/bar",
+	"list":       []string{"red", "green", "blue"},
+	"keys":       [][2]string{{"semi", ";"}, {"dot", "."}, {"comma", ","}},
+	"v":          "6",
+	"x":          "1024",
+	"y":          "768",
+	"empty":      "",
+	"empty_keys": [][2]string{},
+	"undef":      nil,
+}
+
+func TestExpandURITemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		result   string
+	}{
+		// Examples from RFC 6570 Section 1.2.
+		{"{var}", "value"},
+		{"{hello}", "Hello%20World%21"},
+		{"{+var}", "value"},
+		{"{+hello}", "Hello%20World!"},
+		{"{+path}/here", "/foo/bar/here"},
+		{"here?ref={+path}", "here?ref=/foo/bar"},
+		{"X{#var}", "X#value"},
+		{"X{#hello}", "X#Hello%20World!"},
+		{"map?{x,y}", "map?1024,768"},
+		{"{x,hello,y}", "1024,Hello%20World%21,768"},
+		{"{+x,hello,y}", "1024,Hello%20World!,768"},
+		{"{+path,x}/here", "/foo/bar,1024/here"},
+		{"{#x,hello,y}", "#1024,Hello%20World!,768"},
+		{"{#path,x}/here", "#/foo/bar,1024/here"},
+		{"X{.var}", "X.value"},
+		{"X{.x,y}", "X.1024.768"},
+		{"{/var}", "/value"},
+		{"{/var,x}/here", "/value/1024/here"},
+		{"{;x,y}", ";x=1024;y=768"},
+		{"{;x,y,empty}", ";x=1024;y=768;empty"},
+		{"{?x,y}", "?x=1024&y=768"},
+		{"{?x,y,empty}", "?x=1024&y=768&empty="},
+		{"?fixed=yes{&x}", "?fixed=yes&x=1024"},
+		{"{&x,y,empty}", "&x=1024&y=768&empty="},
+		{"{var:3}", "val"},
+		{"{var:30}", "value"},
+		{"{list}", "red,green,blue"},
+		{"{list*}", "red,green,blue"},
+		{"{keys}", "semi,%3B,dot,.,comma,%2C"},
+		{"{keys*}", "semi=%3B,dot=.,comma=%2C"},
+		{"{+path:6}/here", "/foo/b/here"},
+		{"{+list}", "red,green,blue"},
+		{"{+list*}", "red,green,blue"},
+		{"{+keys}", "semi,;,dot,.,comma,,"},
+		{"{+keys*}", "semi=;,dot=.,comma=,"},
+		{"{#path:6}/here", "#/foo/b/here"},
+		{"{#list}", "#red,green,blue"},
+		{"{#list*}", "#red,green,blue"},
+		{"{#keys}", "#semi,;,dot,.,comma,,"},
+		{"{#keys*}", "#semi=;,dot=.,comma=,"},
+		{"X{.var:3}", "X.val"},
+		{"X{.list}", "X.red,green,blue"},
+		{"X{.list*}", "X.red.green.blue"},
+		{"X{.keys}", "X.semi,%3B,dot,.,comma,%2C"},
+		{"X{.keys*}", "X.semi=%3B.dot=..comma=%2C"},
+		{"{/var:1,var}", "/v/value"},
+		{"{/list}", "/red,green,blue"},
+		{"{/list*}", "/red/green/blue"},
+		{"{/list*,path:4}", "/red/green/blue/%2Ffoo"},
+		{"{/keys}", "/semi,%3B,dot,.,comma,%2C"},
+		{"{/keys*}", "/semi=%3B/dot=./comma=%2C"},
+		{"{;hello:5}", ";hello=Hello"},
+		{"{;list}", ";list=red,green,blue"},
+		{"{;list*}", ";list=red;list=green;list=blue"},
+		{"{;keys}", ";keys=semi,%3B,dot,.,comma,%2C"},
+		{"{;keys*}", ";semi=%3B;dot=.;comma=%2C"},
+		{"{?var:3}", "?var=val"},
+		{"{?list}", "?list=red,green,blue"},
+		{"{?list*}", "?list=red&list=green&list=blue"},
+		{"{?keys}", "?keys=semi,%3B,dot,.,comma,%2C"},
+		{"{?keys*}", "?semi=%3B&dot=.&comma=%2C"},
+		{"{&var:3}", "&var=val"},
+		{"{&list}", "&list=red,green,blue"},
+		{"{&list*}", "&list=red&list=green&list=blue"},
+		{"{&keys}", "&keys=semi,%3B,dot,.,comma,%2C"},
+		{"{&keys*}", "&semi=%3B&dot=.&comma=%2C"},
+
+		// Examples from RFC 6570 Section 3.2.
+		{"{count}", "one,two,three"},
+		{"{count*}", "one,two,three"},
+		{"{/count}", "/one,two,three"},
+		{"{/count*}", "/one/two/three"},
+		{"{;count}", ";count=one,two,three"},
+		{"{;count*}", ";count=one;count=two;count=three"},
+		{"{?count}", "?count=one,two,three"},
+		{"{?count*}", "?count=one&count=two&count=three"},
+		{"{&count*}", "&count=one&count=two&count=three"},
+		{"{half}", "50%25"},
+		{"O{empty}X", "OX"},
+		{"O{undef}X", "OX"},
+		{"{x,y}", "1024,768"},
+		{"{x,hello,y}", "1024,Hello%20World%21,768"},
+		{"?{x,empty}", "?1024,"},
+		{"?{x,undef}", "?1024"},
+		{"?{undef,y}", "?768"},
+		{"{var:30}", "value"},
+		{"{+half}", "50%25"},
+		{"{base}index", "http%3A%2F%2Fexample.com%2Fhome%2Findex"},
+		{"{+base}index", "http://example.com/home/index"},
+		{"O{+empty}X", "OX"},
+		{"O{+undef}X", "OX"},
+		{"{+path}/here", "/foo/bar/here"},
+		{"{#half}", "#50%25"},
+		{"foo{#empty}", "foo#"},
+		{"foo{#undef}", "foo"},
+		{"{.who}", ".fred"},
+		{"{.who,who}", ".fred.fred"},
+		{"{.half,who}", ".50%25.fred"},
+		{"www{.dom*}", "www.example.com"},
+		{"X{.empty}", "X."},
+		{"X{.undef}", "X"},
+		{"{/who}", "/fred"},
+		{"{/who,who}", "/fred/fred"},
+		{"{/half,who}", "/50%25/fred"},
+		{"{/who,dub}", "/fred/me%2Ftoo"},
+		{"{/var,empty}", "/value/"},
+		{"{/var,undef}", "/value"},
+		{"{;v,empty,who}", ";v=6;empty;who=fred"},
+		{"{;v,bar,who}", ";v=6;who=fred"},
+		{"{;x,y,undef}", ";x=1024;y=768"},
+		{"{?x,y,undef}", "?x=1024&y=768"},
+		{"{?empty_keys}", ""},
+		{"{?empty_keys*}", ""},
+		{"?fixed=yes{&x}", "?fixed=yes&x=1024"},
+
+		// Literals and other edge cases.
+		{"", ""},
+		{"/plain/path", "/plain/path"},
+		{"/caf\u00e9 {var}", "/caf%C3%A9%20value"},
+		{"/50%25/{var}", "/50%25/value"},
+		{"{?q}", "?q="},
+		{"{var:2}", "va"},
+		{"{who.name}", ""},
+		{"{%41}", ""},
+	}
+	vars := make(map[string]interface{})
+	for name, value := range rfc6570Vars {
+		vars[name] = value
+	}
+	vars["q"] = ""
+	for _, test := range tests {
+		t.Run(test.template, func(t *testing.T) {
+			actual, err := ExpandURITemplate(test.template, vars)
+			if err != nil {
+				t.Fatalf("expanding %q: %v", test.template, err)
+			}
+			if actual != test.result {
+				t.Errorf("expanding %q:\nexpected: %q\nactual:   %q",
+					test.template, test.result, actual)
+			}
+		})
+	}
+}
+
+func TestExpandURITemplateMap(t *testing.T) {
+	actual, err := ExpandURITemplate("/search{?params*}", map[string]interface{}{
+		"params": map[string]string{"q": "cat", "lang": "en", "page": "2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "/search?lang=en&page=2&q=cat"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestExpandURITemplateErrors(t *testing.T) {
+	tests := []struct {
+		template string
+		vars     map[string]interface{}
+	}{
+		{"{var", nil},
+		{"var}", nil},
+		{"{}", nil},
+		{"{=var}", nil},
+		{"{!var}", nil},
+		{"{var:}", nil},
+		{"{var:0}", nil},
+		{"{var:03}", nil},
+		{"{var:10000}", nil},
+		{"{var:3*}", nil},
+		{"{va r}", nil},
+		{"{.var.}", nil},
+		{"{var..name}", nil},
+		{"{var,}", nil},
+		{"{%4}", nil},
+		{"{var}", map[string]interface{}{"var": 42}},
+	}
+	for _, test := range tests {
+		t.Run(test.template, func(t *testing.T) {
+			actual, err := ExpandURITemplate(test.template, test.vars)
+			if err == nil {
+				t.Errorf("expanding %q: expected error, got %q", test.template, actual)
+			}
+		})
+	}
+}
+
+func BenchmarkExpandURITemplate(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		ExpandURITemplate("/books{/id}{?fields*,q}", map[string]interface{}{
+			"id":     "1234",
+			"fields": []string{"title", "author"},
+			"q":      "hello world",
+		})
+	}
+}
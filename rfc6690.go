@@ -0,0 +1,213 @@
+package httpheader
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ParseCoRELinkFormat parses a document in the CoRE Link Format
+// (application/link-format, RFC 6690), such as served by CoAP servers
+// at /.well-known/core.
+//
+// Relative Anchor URLs are resolved against origin, which is the URL that
+// the document was obtained from, and relative Target URLs are resolved
+// against the Anchor, if any, or else against origin (RFC 6690 Section 2.1).
+// As required by that section, only the scheme and authority of origin
+// are used; the path is ignored. If origin is nil, relative URLs are left
+// unresolved.
+// Links without a rel parameter get the default relation type "hosts".
+// Otherwise, links are parsed like in the Link function, with CoRE-specific
+// attributes such as rt and ct stored in Ext. Use the functions
+// CoREResourceTypes, CoREInterfaces, CoRESize, CoREContentFormats
+// and CoREObservable to access them.
+//
+// ParseCoRELinkFormat applies DefaultDocumentLimits. If doc exceeds them,
+// it returns the links that fit, and ErrTruncated.
+func ParseCoRELinkFormat(doc []byte, origin *url.URL) ([]LinkElem, error) {
+	return DefaultDocumentLimits().ParseCoRELinkFormat(doc, origin)
+}
+
+// ParseCoRELinkFormat is like the ParseCoRELinkFormat function, but applies l
+// instead of DefaultDocumentLimits.
+func (l Limits) ParseCoRELinkFormat(doc []byte, origin *url.URL) ([]LinkElem, error) {
+	links, err := l.parseLinkDoc(doc, nil, "hosts")
+	if origin == nil {
+		return links, err
+	}
+	base := &url.URL{
+		Scheme: origin.Scheme,
+		User:   origin.User,
+		Host:   origin.Host,
+		Path:   "/",
+	}
+	for i := range links {
+		context := base
+		if links[i].Anchor != nil {
+			links[i].Anchor = base.ResolveReference(links[i].Anchor)
+			context = links[i].Anchor
+		}
+		links[i].Target = context.ResolveReference(links[i].Target)
+	}
+	return links, err
+}
+
+// MarshalCoRELinkFormat serializes links into the CoRE Link Format
+// (RFC 6690). Target and Anchor URLs with the same scheme and authority
+// as origin (which may be nil) are written as path-absolute references.
+// The default rel=hosts is omitted. A Title with TitleLang is written
+// as 'title*' under the same rules as in SetLink. Extension attributes
+// with empty values, such as obs, are written without a value. The names
+// of CoRE-specific attributes, such as rt, are lowercased; if Ext has
+// the same attribute in different case, only one of them is written.
+func MarshalCoRELinkFormat(links []LinkElem, origin *url.URL) []byte {
+	b := &strings.Builder{}
+	for i, link := range links {
+		if i > 0 {
+			write(b, ",")
+		}
+		// A relative Target is resolved against the Anchor, if any.
+		targetOrigin := origin
+		if link.Anchor != nil && !sameOrigin(link.Anchor, origin) {
+			targetOrigin = nil
+		}
+		write(b, "<", relativeToOrigin(link.Target, targetOrigin), ">")
+		if link.Anchor != nil {
+			write(b, `;anchor="`, relativeToOrigin(link.Anchor, origin), `"`)
+		}
+		if link.Rel != "hosts" {
+			write(b, ";rel=")
+			writeTokenOrQuoted(b, link.Rel)
+		}
+		switch {
+		case isLangTag(link.TitleLang):
+			write(b, ";title*=")
+			writeExtValue(b, link.Title, link.TitleLang)
+		case link.Title != "":
+			write(b, ";title=")
+			writeQuoted(b, link.Title)
+		}
+		if link.Type != "" {
+			write(b, ";type=")
+			writeQuoted(b, link.Type)
+		}
+		for _, lang := range link.HrefLang {
+			write(b, ";hreflang=", lang)
+		}
+		if link.Media != "" {
+			write(b, ";media=")
+			writeTokenOrQuoted(b, link.Media)
+		}
+		// Write well-known CoRE attributes first, for readability.
+		for _, name := range coreAttrs {
+			if value, ok := coreAttr(link.Ext, name); ok {
+				writeCoREAttr(b, name, value)
+			}
+		}
+		for _, name := range sortedKeys(link.Ext) {
+			value := link.Ext[name]
+			switch strings.ToLower(name) {
+			case "anchor", "rel", "title", "title*", "type", "hreflang", "media",
+				"rt", "if", "sz", "ct", "obs":
+				continue
+			default:
+				writeCoREAttr(b, name, value)
+			}
+		}
+	}
+	return []byte(b.String())
+}
+
+var coreAttrs = []string{"rt", "if", "sz", "ct", "obs"}
+
+// coreAttr returns the value of the attribute name in ext, preferring
+// the key in lowercase to any in other case.
+func coreAttr(ext map[string]string, name string) (value string, ok bool) {
+	if value, ok = ext[name]; ok {
+		return value, true
+	}
+	for _, key := range sortedKeys(ext) {
+		if strings.EqualFold(key, name) {
+			return ext[key], true
+		}
+	}
+	return "", false
+}
+
+func writeCoREAttr(b *strings.Builder, name, value string) {
+	write(b, ";", name)
+	switch {
+	case value == "":
+	case name == "rt" || name == "if":
+		// RFC 6690 Section 2 requires quoted-string for these.
+		write(b, "=")
+		writeQuoted(b, value)
+	default:
+		write(b, "=")
+		writeTokenOrQuoted(b, value)
+	}
+}
+
+func relativeToOrigin(u, origin *url.URL) string {
+	if sameOrigin(u, origin) && u.Opaque == "" && strings.HasPrefix(u.Path, "/") {
+		rel := *u
+		rel.Scheme, rel.Host = "", ""
+		return rel.String()
+	}
+	return u.String()
+}
+
+func sameOrigin(u, origin *url.URL) bool {
+	return origin != nil && u.Scheme == origin.Scheme && u.Host == origin.Host &&
+		u.User == nil
+}
+
+// CoREResourceTypes returns the resource types of link from its rt attribute
+// (RFC 6690 Section 3.1), which is a space-separated list.
+func CoREResourceTypes(link LinkElem) []string {
+	return spaceList(link.Ext["rt"])
+}
+
+// CoREInterfaces returns the interface descriptions of link from its
+// if attribute (RFC 6690 Section 3.2), which is a space-separated list.
+func CoREInterfaces(link LinkElem) []string {
+	return spaceList(link.Ext["if"])
+}
+
+func spaceList(v string) []string {
+	if strings.TrimSpace(v) == "" {
+		return nil
+	}
+	return strings.Fields(v)
+}
+
+// CoRESize returns the maximum size estimate of link's target resource
+// from its sz attribute (RFC 6690 Section 3.3). If there is no valid sz,
+// ok is false.
+func CoRESize(link LinkElem) (size int, ok bool) {
+	size, err := strconv.Atoi(link.Ext["sz"])
+	if err != nil || size < 0 {
+		return 0, false
+	}
+	return size, true
+}
+
+// CoREContentFormats returns the CoAP Content-Format codes of link's target
+// resource from its ct attribute (RFC 7252 Section 7.2.1), which may be
+// a space-separated list. Invalid codes are skipped.
+func CoREContentFormats(link LinkElem) []int {
+	var formats []int
+	for _, s := range strings.Fields(link.Ext["ct"]) {
+		if format, err := strconv.Atoi(s); err == nil && format >= 0 && format <= 65535 {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
+
+// CoREObservable returns true if link has the obs attribute (RFC 7641
+// Section 6), indicating that its target resource can be observed with CoAP.
+func CoREObservable(link LinkElem) bool {
+	_, ok := link.Ext["obs"]
+	return ok
+}
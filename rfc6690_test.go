@@ -0,0 +1,197 @@
+package httpheader
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+func ExampleParseCoRELinkFormat() {
+	origin, _ := url.Parse("coap://[2001:db8::2:1]/.well-known/core")
+	doc := []byte(`</sensors/temp>;rt="temperature-c";if="sensor";obs,` +
+		`</sensors/light>;rt="light-lux core.s";if="sensor";ct="0 41"`)
+	links, _ := ParseCoRELinkFormat(doc, origin)
+	for _, link := range links {
+		fmt.Println(link.Target, CoREResourceTypes(link),
+			CoREContentFormats(link), CoREObservable(link))
+	}
+	// Output: coap://[2001:db8::2:1]/sensors/temp [temperature-c] [] true
+	// coap://[2001:db8::2:1]/sensors/light [light-lux core.s] [0 41] false
+}
+
+func TestParseCoRELinkFormat(t *testing.T) {
+	origin := U("coap://node.test:5683/.well-known/core?rt=foo")
+	tests := []struct {
+		doc    string
+		result []LinkElem
+	}{
+		{
+			"",
+			[]LinkElem{},
+		},
+		{
+			`</sensors>;ct=40;title="Sensor Index",` +
+				`</sensors/temp>;rt="temperature-c";if="sensor",` + "\n" +
+				`<firmware/v2.1>;rt="firmware";sz=262144,` +
+				`<coap://other.test/t>;anchor="/sensors/temp";rel="describedby"`,
+			[]LinkElem{
+				{
+					Rel:    "hosts",
+					Target: U("coap://node.test:5683/sensors"),
+					Title:  "Sensor Index",
+					Ext:    map[string]string{"ct": "40"},
+				},
+				{
+					Rel:    "hosts",
+					Target: U("coap://node.test:5683/sensors/temp"),
+					Ext:    map[string]string{"rt": "temperature-c", "if": "sensor"},
+				},
+				{
+					Rel:    "hosts",
+					Target: U("coap://node.test:5683/firmware/v2.1"),
+					Ext:    map[string]string{"rt": "firmware", "sz": "262144"},
+				},
+				{
+					Anchor: U("coap://node.test:5683/sensors/temp"),
+					Rel:    "describedby",
+					Target: U("coap://other.test/t"),
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			links, err := ParseCoRELinkFormat([]byte(test.doc), origin)
+			checkParse(t, nil, test.result, links, nil, err)
+		})
+	}
+}
+
+func TestParseCoRELinkFormatAnchor(t *testing.T) {
+	origin := U("coap://node.test/.well-known/core")
+	links, err := ParseCoRELinkFormat(
+		[]byte(`<temp>;anchor="/sensors/";rel=item,<firmware>;anchor="coap://other.test/a/b"`),
+		origin)
+	checkParse(t, nil,
+		[]LinkElem{
+			{
+				Anchor: U("coap://node.test/sensors/"),
+				Rel:    "item",
+				Target: U("coap://node.test/sensors/temp"),
+			},
+			{
+				Anchor: U("coap://other.test/a/b"),
+				Rel:    "hosts",
+				Target: U("coap://other.test/a/firmware"),
+			},
+		}, links,
+		nil, err)
+}
+
+func TestParseCoRELinkFormatNilOrigin(t *testing.T) {
+	links, err := ParseCoRELinkFormat([]byte(`</sensors>;anchor="/";rel=item`), nil)
+	checkParse(t, nil,
+		[]LinkElem{{Anchor: U("/"), Rel: "item", Target: U("/sensors")}}, links,
+		nil, err)
+}
+
+func TestParseCoRELinkFormatLarge(t *testing.T) {
+	origin := U("coap://node.test")
+	links := make([]LinkElem, 2000)
+	for i := range links {
+		links[i] = LinkElem{
+			Rel:    "hosts",
+			Target: U(fmt.Sprintf("coap://node.test/sensors/%d", i)),
+			Ext:    map[string]string{"rt": "temperature-c"},
+		}
+	}
+	doc := MarshalCoRELinkFormat(links, origin)
+	if len(doc) <= DefaultLimits().MaxBytes {
+		t.Fatalf("document of %d bytes is too small for this test", len(doc))
+	}
+	parsed, err := ParseCoRELinkFormat(doc, origin)
+	checkParse(t, nil, links, parsed, nil, err)
+
+	parsed, err = Limits{MaxElems: 100}.ParseCoRELinkFormat(doc, origin)
+	checkParse(t, nil, links[:100], parsed, ErrTruncated, err)
+}
+
+func TestCoREAccessors(t *testing.T) {
+	link := LinkElem{Ext: map[string]string{
+		"rt":  " core.rd  core.s ",
+		"if":  "core.b",
+		"sz":  "1024",
+		"ct":  "0 x 60 70000",
+		"obs": "",
+	}}
+	checkParse(t, nil,
+		[]string{"core.rd", "core.s"}, CoREResourceTypes(link),
+		[]string{"core.b"}, CoREInterfaces(link),
+		[]int{0, 60}, CoREContentFormats(link),
+		true, CoREObservable(link),
+	)
+	size, ok := CoRESize(link)
+	checkParse(t, nil, 1024, size, true, ok)
+
+	var empty LinkElem
+	size, ok = CoRESize(empty)
+	checkParse(t, nil,
+		[]string(nil), CoREResourceTypes(empty),
+		[]int(nil), CoREContentFormats(empty),
+		false, CoREObservable(empty),
+		0, size, false, ok,
+	)
+}
+
+func TestMarshalCoRELinkFormat(t *testing.T) {
+	origin := U("coap://node.test:5683/.well-known/core")
+	links := []LinkElem{
+		{
+			Rel:    "hosts",
+			Target: U("coap://node.test:5683/sensors/temp"),
+			Ext:    map[string]string{"obs": "", "rt": "temperature-c", "if": "sensor"},
+		},
+		{
+			Anchor: U("coap://node.test:5683/sensors/temp"),
+			Rel:    "describedby",
+			Target: U("coap://other.test/t"),
+			Ext:    map[string]string{"ct": "0 41"},
+		},
+		{
+			Rel:    "hosts",
+			Target: U("coap://node.test:5683/q"),
+			Type:   `text/x-"quoted"`,
+		},
+		{
+			Anchor: U("coap://other.test/t"),
+			Rel:    "describes",
+			Target: U("coap://node.test:5683/sensors/temp"),
+		},
+	}
+	expected := `</sensors/temp>;rt="temperature-c";if="sensor";obs,` +
+		`<coap://other.test/t>;anchor="/sensors/temp";rel=describedby;ct="0 41",` +
+		`</q>;type="text/x-\"quoted\"",` +
+		`<coap://node.test:5683/sensors/temp>;anchor="coap://other.test/t";rel=describes`
+	doc := MarshalCoRELinkFormat(links, origin)
+	if string(doc) != expected {
+		t.Errorf("expected: %s\nactual:   %s", expected, doc)
+	}
+	parsed, err := ParseCoRELinkFormat(doc, origin)
+	checkParse(t, nil, links, parsed, nil, err)
+}
+
+func TestMarshalCoRELinkFormatCase(t *testing.T) {
+	links := []LinkElem{
+		{
+			Rel:       "hosts",
+			Target:    U("/t"),
+			Title:     "Température",
+			TitleLang: "fr",
+			Ext:       map[string]string{"RT": "temperature-c", "Obs": "", "CT": "0"},
+		},
+	}
+	expected := `</t>;title*=UTF-8'fr'Temp%C3%A9rature;rt="temperature-c";ct=0;obs`
+	if doc := MarshalCoRELinkFormat(links, nil); string(doc) != expected {
+		t.Errorf("expected: %s\nactual:   %s", expected, doc)
+	}
+}
@@ -5,14 +5,14 @@ import (
 	"strings"
 )
 
-// A ViaElem represents one element of the Via header (RFC 7230 Section 5.7.1).
+// A ViaElem represents one element of the Via header (RFC 9110 Section 7.6.3).
 type ViaElem struct {
 	ReceivedProto string
 	ReceivedBy    string
 	Comment       string
 }
 
-// Via parses the Via header from h (RFC 7230 Section 5.7.1).
+// Via parses the Via header from h (RFC 9110 Section 7.6.3).
 //
 // ReceivedProto in returned elements is canonicalized to always include name:
 // ``1.1'' becomes ``HTTP/1.1''. As a special case, ``2'' and ``HTTP/2'' become
@@ -21,26 +21,97 @@ type ViaElem struct {
 // BUG(vfaronov): Incorrectly parses some extravagant values of uri-host
 // that do not occur in practice but are theoretically admitted by RFC 3986.
 func Via(h http.Header) []ViaElem {
-	values := h["Via"]
+	return DefaultLimits().Via(h)
+}
+
+// Via is like the Via function, but applies l instead of DefaultLimits.
+func (l Limits) Via(h http.Header) []ViaElem {
+	values := l.values(h["Via"])
 	if values == nil {
 		return nil
 	}
 	elems := make([]ViaElem, 0, estimateElems(values))
 	for v, vs := iterElems("", values); v != ""; v, vs = iterElems(v, vs) {
+		if l.elemsDone(len(elems)) {
+			break
+		}
 		var elem ViaElem
-		elem.ReceivedProto, v = consumeItem(v)
-		elem.ReceivedProto = canonicalProto(elem.ReceivedProto)
-		v = skipWS(v)
-		elem.ReceivedBy, v = consumeReceivedBy(v)
-		v = skipWS(v)
-		if peek(v) == '(' {
-			elem.Comment, v = consumeComment(v)
+		var ok bool
+		if elem, v, ok = l.consumeVia(v); !ok {
+			continue
 		}
+		elem.ReceivedProto = canonicalProto(elem.ReceivedProto)
 		elems = append(elems, elem)
 	}
 	return elems
 }
 
+// StrictVia is like Via, but calls Check first.
+func StrictVia(h http.Header) ([]ViaElem, error) {
+	return DefaultLimits().StrictVia(h)
+}
+
+// StrictVia is like the StrictVia function, but applies l instead of
+// DefaultLimits.
+func (l Limits) StrictVia(h http.Header) ([]ViaElem, error) {
+	if err := l.Check(h, "Via"); err != nil {
+		return nil, err
+	}
+	return l.Via(h), nil
+}
+
+// ViaLoop checks the Via header in h for receivedBy, which is the received-by
+// part (RFC 9110 Section 7.6.3) that this proxy puts into its own Via elements.
+// If any element of Via has the same received-by (compared case-insensitively),
+// then loop is true, meaning that the request has looped back to this proxy.
+// Also returned is the total number of hops (elements) in Via.
+//
+// Unlike Via, ViaLoop does not apply DefaultLimits: it reads all of Via,
+// because the proxy's own element might be hidden beyond a limit. It does not
+// build a slice of elements, so its memory use does not grow with Via.
+//
+// See also Hop's Check method.
+func ViaLoop(h http.Header, receivedBy string) (loop bool, hops int) {
+	for v, vs := iterElems("", h["Via"]); v != ""; v, vs = iterElems(v, vs) {
+		var elem ViaElem
+		var ok bool
+		if elem, v, ok = (Limits{}).consumeVia(v); !ok {
+			continue
+		}
+		if strings.EqualFold(elem.ReceivedBy, receivedBy) {
+			loop = true
+		}
+		hops++
+	}
+	return
+}
+
+// consumeVia returns the Via element at the beginning of v, with
+// ReceivedProto not yet canonicalized, and the rest of v. If there is
+// no valid received-protocol, ok is false.
+func (l Limits) consumeVia(v string) (elem ViaElem, newv string, ok bool) {
+	elem.ReceivedProto, v = consumeItem(v)
+	if !isProto(elem.ReceivedProto) {
+		return elem, v, false
+	}
+	v = skipWS(v)
+	elem.ReceivedBy, v = consumeReceivedBy(v)
+	v = skipWS(v)
+	if peek(v) == '(' {
+		elem.Comment, v = l.consumeComment(v)
+	}
+	return elem, v, true
+}
+
+// isProto reports whether s is a received-protocol: an optional
+// protocol-name and a slash, followed by protocol-version.
+func isProto(s string) bool {
+	if sep := strings.IndexByte(s, '/'); sep >= 0 {
+		return isToken(s[:sep]) && isToken(s[sep+1:])
+	}
+	return isToken(s)
+}
+
 func canonicalProto(proto string) string {
 	// Special-case typical values to avoid allocating them every time.
 	// Also use this opportunity to canonicalize "2" to "2.0",
@@ -110,3 +181,100 @@ func buildVia(elems []ViaElem) string {
 	}
 	return b.String()
 }
+
+// Connection parses the Connection header from h (RFC 9110 Section 7.6.1),
+// returning the lowercased connection options, such as "close" or names
+// of hop-by-hop header fields.
+func Connection(h http.Header) []string {
+	return DefaultLimits().Connection(h)
+}
+
+// Connection is like the Connection function, but applies l instead of
+// DefaultLimits.
+func (l Limits) Connection(h http.Header) []string {
+	values := l.values(h["Connection"])
+	if values == nil {
+		return nil
+	}
+	options := make([]string, 0, estimateElems(values))
+	for v, vs := iterElems("", values); v != ""; v, vs = iterElems(v, vs) {
+		if l.elemsDone(len(options)) {
+			break
+		}
+		var option string
+		option, v = consumeItem(v)
+		if option == "" {
+			continue
+		}
+		options = append(options, strings.ToLower(option))
+	}
+	return options
+}
+
+// StrictConnection is like Connection, but calls Check first.
+func StrictConnection(h http.Header) ([]string, error) {
+	return DefaultLimits().StrictConnection(h)
+}
+
+// StrictConnection is like the StrictConnection function, but applies l
+// instead of DefaultLimits.
+func (l Limits) StrictConnection(h http.Header) ([]string, error) {
+	if err := l.Check(h, "Connection"); err != nil {
+		return nil, err
+	}
+	return l.Connection(h), nil
+}
+
+// SetConnection replaces the Connection header in h.
+func SetConnection(h http.Header, options []string) {
+	if len(options) == 0 {
+		h.Del("Connection")
+		return
+	}
+	h.Set("Connection", strings.Join(options, ", "))
+}
+
+// RemoveHopByHop deletes from h all hop-by-hop header fields, which must not
+// be forwarded by a proxy (RFC 9110 Section 7.6.1): Connection, every field
+// named in Connection, and the standard hop-by-hop fields Keep-Alive,
+// Proxy-Connection, Proxy-Authenticate, Proxy-Authorization, TE,
+// Transfer-Encoding and Upgrade.
+//
+// The Trailer field is end-to-end (RFC 9110 Section 6.6.2), so it is preserved
+// unless named in Connection. If TE contains the "trailers" option, a TE with
+// only that option is kept, because it signals a property of the whole chain
+// (support for trailers by the client) rather than of this connection.
+func RemoveHopByHop(h http.Header) {
+	trailers := false
+	for v, vs := iterElems("", h["Te"]); v != ""; v, vs = iterElems(v, vs) {
+		var coding string
+		coding, v = consumeItem(v)
+		if strings.EqualFold(coding, "trailers") {
+			trailers = true
+		}
+	}
+	// Not Connection(h), which would stop at the limits and leave
+	// some hop-by-hop fields in place.
+	for v, vs := iterElems("", h["Connection"]); v != ""; v, vs = iterElems(v, vs) {
+		var option string
+		option, v = consumeItem(v)
+		h.Del(option)
+	}
+	for _, name := range hopByHop {
+		h.Del(name)
+	}
+	if trailers {
+		h.Set("Te", "trailers")
+	}
+}
+
+var hopByHop = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Connection", // non-standard but still sent by some clients
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Transfer-Encoding",
+	"Upgrade",
+}
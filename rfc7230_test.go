@@ -161,6 +161,10 @@ func TestVia(t *testing.T) {
 				{"HTTP/1.1", "bar", ""},
 			},
 		},
+		{
+			http.Header{"Via": {"=, HTTP/, /1.1 foo, HTTP//2 bar, 1.1 baz"}},
+			[]ViaElem{{"HTTP/1.1", "baz", ""}},
+		},
 	}
 	for _, test := range tests {
 		t.Run("", func(t *testing.T) {
@@ -206,9 +210,106 @@ func TestViaRoundTrip(t *testing.T) {
 	)
 }
 
+func TestViaLoop(t *testing.T) {
+	tests := []struct {
+		header     http.Header
+		receivedBy string
+		loop       bool
+		hops       int
+	}{
+		{http.Header{}, "gw1", false, 0},
+		{http.Header{"Via": {"1.1 foo, 1.1 bar"}}, "gw1", false, 2},
+		{http.Header{"Via": {"1.1 foo, 1.1 GW1 (loop)", "2 bar"}}, "gw1", true, 3},
+		{http.Header{"Via": {"1.1 [2001:db8::1]:443"}}, "[2001:db8::1]:443", true, 1},
+		{http.Header{"Via": {"1.1 gw1.example.net"}}, "gw1", false, 1},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			loop, hops := ViaLoop(test.header, test.receivedBy)
+			checkParse(t, test.header, test.loop, loop, test.hops, hops)
+		})
+	}
+}
+
 func BenchmarkVia(b *testing.B) {
 	header := http.Header{"Via": {"1.1 proxy2.example.net (CWA (corporate Web accelerator))", "2 api-front.example.com:443 (trace: 97G9Hcio), 2 gw1-3.svc.example.com"}}
 	for i := 0; i < b.N; i++ {
 		Via(header)
 	}
 }
+
+func TestConnection(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		result []string
+	}{
+		{http.Header{}, nil},
+		{http.Header{"Connection": {"close"}}, []string{"close"}},
+		{
+			http.Header{"Connection": {"Keep-Alive, X-Foo ,,", "TE"}},
+			[]string{"keep-alive", "x-foo", "te"},
+		},
+		{http.Header{"Connection": {";close, =x, upgrade"}}, []string{"upgrade"}},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			checkParse(t, test.header, test.result, Connection(test.header))
+		})
+	}
+}
+
+func TestConnectionFuzz(t *testing.T) {
+	checkFuzz(t, "Connection", Connection, SetConnection)
+}
+
+func TestConnectionRoundTrip(t *testing.T) {
+	checkRoundTrip(t, SetConnection, Connection, []string{"lower token"})
+}
+
+func TestRemoveHopByHop(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		result http.Header
+	}{
+		{
+			http.Header{
+				"Connection":        {"keep-alive, x-foo"},
+				"Keep-Alive":        {"timeout=5"},
+				"X-Foo":             {"bar"},
+				"X-Bar":             {"baz"},
+				"Transfer-Encoding": {"chunked"},
+				"Upgrade":           {"websocket"},
+			},
+			http.Header{"X-Bar": {"baz"}},
+		},
+		{
+			http.Header{
+				"Connection": {"TE"},
+				"Te":         {"gzip;q=0.5, trailers"},
+				"Trailer":    {"Server-Timing"},
+			},
+			http.Header{
+				"Te":      {"trailers"},
+				"Trailer": {"Server-Timing"},
+			},
+		},
+		{
+			http.Header{
+				"Connection": {"Trailer"},
+				"Te":         {"gzip"},
+				"Trailer":    {"Server-Timing"},
+			},
+			http.Header{},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			input := http.Header{}
+			for name, values := range test.header {
+				input[name] = values
+			}
+			RemoveHopByHop(test.header)
+			checkGenerate(t, input, test.result, test.header)
+		})
+	}
+}
@@ -1,63 +1,122 @@
 package httpheader
 
 import (
+	"math"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
-// Allow parses the Allow header from h (RFC 7231 Section 7.4.1).
+// Allow parses the Allow header from h (RFC 9110 Section 10.2.1).
 //
 // If there is no such header in h, Allow returns nil.
 // If the header is present but empty (meaning all methods are disallowed),
 // Allow returns a non-nil slice of length 0.
 func Allow(h http.Header) []string {
-	values := h["Allow"]
+	return DefaultLimits().Allow(h)
+}
+
+// Allow is like the Allow function, but applies l instead of DefaultLimits.
+func (l Limits) Allow(h http.Header) []string {
+	values := l.values(h["Allow"])
 	if values == nil {
 		return nil
 	}
 	methods := make([]string, 0, estimateElems(values))
 	for v, vs := iterElems("", values); v != ""; v, vs = iterElems(v, vs) {
+		if l.elemsDone(len(methods)) {
+			break
+		}
 		var method string
 		method, v = consumeItem(v)
+		if method == "" {
+			continue
+		}
 		methods = append(methods, method)
 	}
 	return methods
 }
 
-// SetAllow replaces the Allow header in h.
+// StrictAllow is like Allow, but calls Check first.
+func StrictAllow(h http.Header) ([]string, error) {
+	return DefaultLimits().StrictAllow(h)
+}
+
+// StrictAllow is like the StrictAllow function, but applies l instead of
+// DefaultLimits.
+func (l Limits) StrictAllow(h http.Header) ([]string, error) {
+	if err := l.Check(h, "Allow"); err != nil {
+		return nil, err
+	}
+	return l.Allow(h), nil
+}
+
+// SetAllow replaces the Allow header in h. An empty methods slice produces
+// an empty header, which means that the resource allows no methods,
+// as might be the case in a 405 (Method Not Allowed) response.
 func SetAllow(h http.Header, methods []string) {
 	h.Set("Allow", strings.Join(methods, ", "))
 }
 
-// Vary parses the Vary header from h (RFC 7231 Section 7.1.4), returning a map
+// Vary parses the Vary header from h (RFC 9110 Section 12.5.5), returning a map
 // where keys are header names, canonicalized with http.CanonicalHeaderKey,
 // and values are all true. A wildcard (Vary: *) is returned as map[*:true],
-// so it must be checked explicitly.
+// so it must be checked explicitly. Since RFC 9110, the wildcard is just
+// another list member, and may appear alongside names.
 func Vary(h http.Header) map[string]bool {
-	values := h["Vary"]
+	return DefaultLimits().Vary(h)
+}
+
+// Vary is like the Vary function, but applies l instead of DefaultLimits.
+func (l Limits) Vary(h http.Header) map[string]bool {
+	values := l.values(h["Vary"])
 	if values == nil {
 		return nil
 	}
 	names := make(map[string]bool)
 	for v, vs := iterElems("", values); v != ""; v, vs = iterElems(v, vs) {
+		if l.elemsDone(len(names)) {
+			break
+		}
 		var name string
 		name, v = consumeItem(v)
+		if name == "" {
+			continue
+		}
 		name = http.CanonicalHeaderKey(name)
 		names[name] = true
 	}
 	return names
 }
 
+// StrictVary is like Vary, but calls Check first.
+func StrictVary(h http.Header) (map[string]bool, error) {
+	return DefaultLimits().StrictVary(h)
+}
+
+// StrictVary is like the StrictVary function, but applies l instead of
+// DefaultLimits.
+func (l Limits) StrictVary(h http.Header) (map[string]bool, error) {
+	if err := l.Check(h, "Vary"); err != nil {
+		return nil, err
+	}
+	return l.Vary(h), nil
+}
+
 // SetVary replaces the Vary header in h.
 // Names mapping to false are ignored. See also AddVary.
 func SetVary(h http.Header, names map[string]bool) {
-	b := &strings.Builder{}
+	sorted := make([]string, 0, len(names))
 	for name, value := range names {
-		if !value {
-			continue
+		if value {
+			sorted = append(sorted, name)
 		}
+	}
+	sort.Strings(sorted)
+	b := &strings.Builder{}
+	for _, name := range sorted {
 		if b.Len() > 0 {
 			write(b, ", ")
 		}
@@ -74,8 +133,69 @@ func AddVary(h http.Header, names ...string) {
 	h.Add("Vary", strings.Join(names, ", "))
 }
 
+// MaxForwards parses the Max-Forwards header from h (RFC 9110 Section 7.6.2).
+// If there is no such header in h, or it cannot be parsed, ok is false.
+func MaxForwards(h http.Header) (n int, ok bool) {
+	return DefaultLimits().MaxForwards(h)
+}
+
+// MaxForwards is like the MaxForwards function, but applies l instead of
+// DefaultLimits.
+func (l Limits) MaxForwards(h http.Header) (n int, ok bool) {
+	v := strings.TrimSpace(l.value(h.Get("Max-Forwards")))
+	if v == "" || strings.TrimLeft(v, "0123456789") != "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// StrictMaxForwards is like MaxForwards, but calls Check first.
+func StrictMaxForwards(h http.Header) (n int, ok bool, err error) {
+	return DefaultLimits().StrictMaxForwards(h)
+}
+
+// StrictMaxForwards is like the StrictMaxForwards function, but applies l
+// instead of DefaultLimits.
+func (l Limits) StrictMaxForwards(h http.Header) (n int, ok bool, err error) {
+	if err := l.Check(h, "Max-Forwards"); err != nil {
+		return 0, false, err
+	}
+	n, ok = l.MaxForwards(h)
+	return n, ok, nil
+}
+
+// SetMaxForwards replaces the Max-Forwards header in h.
+func SetMaxForwards(h http.Header, n int) {
+	h.Set("Max-Forwards", strconv.Itoa(n))
+}
+
+// DecrementMaxForwards processes the Max-Forwards header of r as required
+// for a proxy (RFC 9110 Section 7.6.2). If r is a TRACE or OPTIONS request
+// with Max-Forwards: 0, DecrementMaxForwards returns false, meaning that
+// r must not be forwarded: the proxy must respond to it as the final recipient.
+// Otherwise, it decrements Max-Forwards in r.Header, if present, and returns
+// true. Max-Forwards is ignored for other methods.
+func DecrementMaxForwards(r *http.Request) bool {
+	if r.Method != http.MethodTrace && r.Method != http.MethodOptions {
+		return true
+	}
+	n, ok := MaxForwards(r.Header)
+	if !ok {
+		return true
+	}
+	if n == 0 {
+		return false
+	}
+	SetMaxForwards(r.Header, n-1)
+	return true
+}
+
 // A Product contains software information as found in the User-Agent
-// and Server headers (RFC 7231 Section 5.5.3 and Section 7.4.2).
+// and Server headers (RFC 9110 Section 10.1.5 and Section 10.2.4).
 // If multiple comments are associated with a product, they are concatenated
 // with a "; " separator.
 type Product struct {
@@ -84,9 +204,29 @@ type Product struct {
 	Comment string
 }
 
-// UserAgent parses the User-Agent header from h (RFC 7231 Section 5.5.3).
+// UserAgent parses the User-Agent header from h (RFC 9110 Section 10.1.5).
 func UserAgent(h http.Header) []Product {
-	return parseProducts(h.Get("User-Agent"))
+	return DefaultLimits().UserAgent(h)
+}
+
+// UserAgent is like the UserAgent function, but applies l instead of
+// DefaultLimits.
+func (l Limits) UserAgent(h http.Header) []Product {
+	return l.parseProducts(l.value(h.Get("User-Agent")))
+}
+
+// StrictUserAgent is like UserAgent, but calls Check first.
+func StrictUserAgent(h http.Header) ([]Product, error) {
+	return DefaultLimits().StrictUserAgent(h)
+}
+
+// StrictUserAgent is like the StrictUserAgent function, but applies l instead
+// of DefaultLimits.
+func (l Limits) StrictUserAgent(h http.Header) ([]Product, error) {
+	if err := l.Check(h, "User-Agent"); err != nil {
+		return nil, err
+	}
+	return l.UserAgent(h), nil
 }
 
 // SetUserAgent replaces the User-Agent header in h.
@@ -98,9 +238,28 @@ func SetUserAgent(h http.Header, products []Product) {
 	h.Set("User-Agent", serializeProducts(products))
 }
 
-// Server parses the Server header from h (RFC 7231 Section 7.4.2).
+// Server parses the Server header from h (RFC 9110 Section 10.2.4).
 func Server(h http.Header) []Product {
-	return parseProducts(h.Get("Server"))
+	return DefaultLimits().Server(h)
+}
+
+// Server is like the Server function, but applies l instead of DefaultLimits.
+func (l Limits) Server(h http.Header) []Product {
+	return l.parseProducts(l.value(h.Get("Server")))
+}
+
+// StrictServer is like Server, but calls Check first.
+func StrictServer(h http.Header) ([]Product, error) {
+	return DefaultLimits().StrictServer(h)
+}
+
+// StrictServer is like the StrictServer function, but applies l instead of
+// DefaultLimits.
+func (l Limits) StrictServer(h http.Header) ([]Product, error) {
+	if err := l.Check(h, "Server"); err != nil {
+		return nil, err
+	}
+	return l.Server(h), nil
 }
 
 // SetServer replaces the Server header in h.
@@ -112,9 +271,14 @@ func SetServer(h http.Header, products []Product) {
 	h.Set("Server", serializeProducts(products))
 }
 
-func parseProducts(v string) []Product {
+func (l Limits) parseProducts(v string) []Product {
 	var products []Product
-	for v != "" {
+	for v != "" && !l.elemsDone(len(products)) {
+		if peek(v) == '(' {
+			// A comment without a product to attach it to.
+			_, v = l.consumeComment(v)
+			continue
+		}
 		var product Product
 		product.Name, v = consumeItem(v)
 		if product.Name == "" {
@@ -126,6 +290,9 @@ func parseProducts(v string) []Product {
 			product.Version = product.Name[sep+1:]
 			product.Name = product.Name[:sep]
 		}
+		if product.Name == "" {
+			continue
+		}
 		// Collect all comments for this product.
 		for {
 			v = skipWS(v)
@@ -133,7 +300,7 @@ func parseProducts(v string) []Product {
 				break
 			}
 			var comment string
-			comment, v = consumeComment(v)
+			comment, v = l.consumeComment(v)
 			if product.Comment == "" {
 				product.Comment = comment
 			} else {
@@ -163,12 +330,18 @@ func serializeProducts(products []Product) string {
 	return b.String()
 }
 
-// RetryAfter parses the Retry-After header from h (RFC 7231 Section 7.1.3).
+// RetryAfter parses the Retry-After header from h (RFC 9110 Section 10.2.3).
 // When it is specified as delay seconds, those are added to the Date header
 // if one exists in h, otherwise to the current time. If the header cannot
 // be parsed, a zero Time is returned.
 func RetryAfter(h http.Header) time.Time {
-	v := h.Get("Retry-After")
+	return DefaultLimits().RetryAfter(h)
+}
+
+// RetryAfter is like the RetryAfter function, but applies l instead of
+// DefaultLimits.
+func (l Limits) RetryAfter(h http.Header) time.Time {
+	v := l.value(h.Get("Retry-After"))
 	if v == "" {
 		return time.Time{}
 	}
@@ -187,7 +360,7 @@ func RetryAfter(h http.Header) time.Time {
 	if err != nil {
 		return time.Time{}
 	}
-	// Strictly speaking, RFC 7231 says "number of seconds to delay
+	// Strictly speaking, RFC 9110 says "number of seconds to delay
 	// after the response is received", not after it was originated (Date),
 	// but the response may have been stored or processed for a long time
 	// before being fed to us, so Date might even be closer than Now().
@@ -195,24 +368,65 @@ func RetryAfter(h http.Header) time.Time {
 	if err != nil {
 		date = time.Now()
 	}
+	if int64(seconds) > maxDelay {
+		return date.Add(time.Duration(maxDelay) * time.Second)
+	}
 	return date.Add(time.Duration(seconds) * time.Second)
 }
 
+// StrictRetryAfter is like RetryAfter, but calls Check first.
+func StrictRetryAfter(h http.Header) (time.Time, error) {
+	return DefaultLimits().StrictRetryAfter(h)
+}
+
+// StrictRetryAfter is like the StrictRetryAfter function, but applies l
+// instead of DefaultLimits.
+func (l Limits) StrictRetryAfter(h http.Header) (time.Time, error) {
+	if err := l.Check(h, "Retry-After"); err != nil {
+		return time.Time{}, err
+	}
+	return l.RetryAfter(h), nil
+}
+
+// maxDelay is the longest delay-seconds that fits into a time.Duration.
+const maxDelay = int64(math.MaxInt64 / time.Second)
+
 // SetRetryAfter replaces the Retry-After header in h.
 func SetRetryAfter(h http.Header, after time.Time) {
 	h.Set("Retry-After", after.Format(http.TimeFormat))
 }
 
-// ContentType parses the Content-Type header from h (RFC 7231 Section 3.1.1.5),
+// ContentType parses the Content-Type header from h (RFC 9110 Section 8.3),
 // returning the media type/subtype and any parameters.
 func ContentType(h http.Header) (mtype string, params map[string]string) {
-	v := h.Get("Content-Type")
+	return DefaultLimits().ContentType(h)
+}
+
+// ContentType is like the ContentType function, but applies l instead of
+// DefaultLimits.
+func (l Limits) ContentType(h http.Header) (mtype string, params map[string]string) {
+	v := l.value(h.Get("Content-Type"))
 	mtype, v = consumeItem(v)
 	mtype = strings.ToLower(mtype)
-	params, _ = consumeParams(v)
+	params, _ = l.consumeParams(v)
 	return
 }
 
+// StrictContentType is like ContentType, but calls Check first.
+func StrictContentType(h http.Header) (mtype string, params map[string]string, err error) {
+	return DefaultLimits().StrictContentType(h)
+}
+
+// StrictContentType is like the StrictContentType function, but applies l
+// instead of DefaultLimits.
+func (l Limits) StrictContentType(h http.Header) (mtype string, params map[string]string, err error) {
+	if err := l.Check(h, "Content-Type"); err != nil {
+		return "", nil, err
+	}
+	mtype, params = l.ContentType(h)
+	return mtype, params, nil
+}
+
 // SetContentType replaces the Content-Type header in h.
 func SetContentType(h http.Header, mtype string, params map[string]string) {
 	b := &strings.Builder{}
@@ -222,40 +436,111 @@ func SetContentType(h http.Header, mtype string, params map[string]string) {
 }
 
 // An AcceptElem represents one element of the Accept header
-// (RFC 7231 Section 5.3.2).
+// (RFC 9110 Section 12.5.1).
 type AcceptElem struct {
 	Type   string            // media range
 	Params map[string]string // media type parameters (before q)
 	Q      float32           // quality value
-	Ext    map[string]string // extension parameters (after q)
+	Ext    map[string]string // extension parameters (after q), see AcceptRFC9110
 }
 
-// Accept parses the Accept header from h (RFC 7231 Section 5.3.2).
+// Accept parses the Accept header from h (RFC 9110 Section 12.5.1).
 // The function MatchAccept is useful for working with the returned slice.
+//
+// RFC 7231 treated parameters after q as extension parameters, and Accept
+// returns them in Ext. See also AcceptRFC9110.
 func Accept(h http.Header) []AcceptElem {
-	values := h["Accept"]
+	return DefaultLimits().Accept(h)
+}
+
+// Accept is like the Accept function, but applies l instead of DefaultLimits.
+func (l Limits) Accept(h http.Header) []AcceptElem {
+	return l.parseAccept(h, true)
+}
+
+// StrictAccept is like Accept, but calls Check first.
+func StrictAccept(h http.Header) ([]AcceptElem, error) {
+	return DefaultLimits().StrictAccept(h)
+}
+
+// StrictAccept is like the StrictAccept function, but applies l instead of
+// DefaultLimits.
+func (l Limits) StrictAccept(h http.Header) ([]AcceptElem, error) {
+	if err := l.Check(h, "Accept"); err != nil {
+		return nil, err
+	}
+	return l.Accept(h), nil
+}
+
+// AcceptRFC9110 is like Accept, but follows RFC 9110, which has no extension
+// parameters: all parameters except q are returned in Params, and Ext
+// is always nil.
+func AcceptRFC9110(h http.Header) []AcceptElem {
+	return DefaultLimits().AcceptRFC9110(h)
+}
+
+// AcceptRFC9110 is like the AcceptRFC9110 function, but applies l instead of
+// DefaultLimits.
+func (l Limits) AcceptRFC9110(h http.Header) []AcceptElem {
+	return l.parseAccept(h, false)
+}
+
+// StrictAcceptRFC9110 is like AcceptRFC9110, but calls CheckRFC9110 first.
+func StrictAcceptRFC9110(h http.Header) ([]AcceptElem, error) {
+	return DefaultLimits().StrictAcceptRFC9110(h)
+}
+
+// StrictAcceptRFC9110 is like the StrictAcceptRFC9110 function, but applies l
+// instead of DefaultLimits.
+func (l Limits) StrictAcceptRFC9110(h http.Header) ([]AcceptElem, error) {
+	if err := l.CheckRFC9110(h, "Accept"); err != nil {
+		return nil, err
+	}
+	return l.AcceptRFC9110(h), nil
+}
+
+func (l Limits) parseAccept(h http.Header, withExt bool) []AcceptElem {
+	values := l.values(h["Accept"])
 	if values == nil {
 		return nil
 	}
 	elems := make([]AcceptElem, 0, estimateElems(values))
 	for v, vs := iterElems("", values); v != ""; v, vs = iterElems(v, vs) {
+		if l.elemsDone(len(elems)) {
+			break
+		}
 		elem := AcceptElem{Q: 1}
 		elem.Type, v = consumeItem(v)
+		if elem.Type == "" {
+			continue
+		}
 		elem.Type = strings.ToLower(elem.Type)
 		afterQ := false
 	ParamsLoop:
-		for {
+		for n := 0; ; n++ {
 			var name, value string
 			name, value, v = consumeParam(v)
 			switch {
 			case name == "":
 				break ParamsLoop
 			// 'q' separates media type parameters from extension parameters.
+			// It is parsed even beyond MaxParams, because without it,
+			// a type with q=0, which is not acceptable, would become
+			// the most preferred.
 			case name == "q":
 				qvalue, _ := strconv.ParseFloat(value, 32)
-				elem.Q = float32(qvalue)
+				// A qvalue is between 0 and 1, with up to three decimals.
+				switch {
+				case qvalue > 1:
+					qvalue = 1
+				case !(qvalue >= 0): // also NaN
+					qvalue = 0
+				}
+				elem.Q = float32(math.Round(qvalue*1000) / 1000)
 				afterQ = true
-			case afterQ:
+			case l.paramsDone(n):
+				// Keep consuming to find the end of the element.
+			case afterQ && withExt:
 				if elem.Ext == nil {
 					elem.Ext = make(map[string]string)
 				}
@@ -300,7 +585,7 @@ func SetAccept(h http.Header, elems []AcceptElem) {
 }
 
 // MatchAccept searches accept for the element that most closely matches
-// mediaType, according to precedence rules of RFC 7231 Section 5.3.2.
+// mediaType, according to precedence rules of RFC 9110 Section 12.5.1.
 // Only the bare type/subtype can be matched with this function;
 // elements with Params are not considered. If nothing matches mediaType,
 // a zero AcceptElem is returned.
@@ -83,11 +83,11 @@ func TestAllow(t *testing.T) {
 		// They may change as convenient for the parsing code.
 		{
 			http.Header{"Allow": {";;;"}},
-			[]string{""},
+			[]string{},
 		},
 		{
 			http.Header{"Allow": {";;;,GET"}},
-			[]string{"", "GET"},
+			[]string{"GET"},
 		},
 		{
 			http.Header{"Allow": {"GET;;;whatever, HEAD"}},
@@ -134,6 +134,10 @@ func TestVary(t *testing.T) {
 			http.Header{"Vary": {"*"}},
 			map[string]bool{"*": true},
 		},
+		{
+			http.Header{"Vary": {";, =Origin, Cookie"}},
+			map[string]bool{"Cookie": true},
+		},
 	}
 	for _, test := range tests {
 		t.Run("", func(t *testing.T) {
@@ -151,6 +155,10 @@ func TestSetVary(t *testing.T) {
 			map[string]bool{"Accept": true, "Accept-Language": false},
 			http.Header{"Vary": {"Accept"}},
 		},
+		{
+			map[string]bool{"User-Agent": true, "Accept-Encoding": true, "Accept": true},
+			http.Header{"Vary": {"Accept, Accept-Encoding, User-Agent"}},
+		},
 	}
 	for _, test := range tests {
 		t.Run("", func(t *testing.T) {
@@ -172,6 +180,78 @@ func BenchmarkVary(b *testing.B) {
 	}
 }
 
+func TestMaxForwards(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		n      int
+		ok     bool
+	}{
+		{http.Header{}, 0, false},
+		{http.Header{"Max-Forwards": {"0"}}, 0, true},
+		{http.Header{"Max-Forwards": {" 10 "}}, 10, true},
+		{http.Header{"Max-Forwards": {"-1"}}, 0, false},
+		{http.Header{"Max-Forwards": {"+5"}}, 0, false},
+		{http.Header{"Max-Forwards": {"0x10"}}, 0, false},
+		{http.Header{"Max-Forwards": {"ten"}}, 0, false},
+		{http.Header{"Max-Forwards": {"5", "6"}}, 5, true},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			n, ok := MaxForwards(test.header)
+			checkParse(t, test.header, test.n, n, test.ok, ok)
+		})
+	}
+}
+
+func TestMaxForwardsFuzz(t *testing.T) {
+	checkFuzz(t, "Max-Forwards", MaxForwards, nil)
+}
+
+func TestDecrementMaxForwards(t *testing.T) {
+	tests := []struct {
+		method  string
+		header  http.Header
+		forward bool
+		result  http.Header
+	}{
+		{
+			"TRACE",
+			http.Header{"Max-Forwards": {"3"}},
+			true,
+			http.Header{"Max-Forwards": {"2"}},
+		},
+		{
+			"OPTIONS",
+			http.Header{"Max-Forwards": {"0"}},
+			false,
+			http.Header{"Max-Forwards": {"0"}},
+		},
+		{
+			"OPTIONS",
+			http.Header{},
+			true,
+			http.Header{},
+		},
+		{
+			"GET",
+			http.Header{"Max-Forwards": {"0"}},
+			true,
+			http.Header{"Max-Forwards": {"0"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			r := &http.Request{Method: test.method, Header: test.header}
+			forward := DecrementMaxForwards(r)
+			if forward != test.forward {
+				t.Errorf("%s with %#v: expected %v, got %v",
+					test.method, test.header, test.forward, forward)
+			}
+			checkGenerate(t, test.method, test.result, r.Header)
+		})
+	}
+}
+
 func TestUserAgent(t *testing.T) {
 	// Most of the tests are in TestServer. Here, just check a few real-world
 	// examples from browsers, notorious for their exuberant User-Agent strings.
@@ -312,6 +392,10 @@ func TestServer(t *testing.T) {
 			http.Header{"Server": {"foo=1.2.3"}},
 			[]Product{{"foo", "", ""}, {"1.2.3", "", ""}},
 		},
+		{
+			http.Header{"Server": {"(orphan), /1.0 (comment) foo"}},
+			[]Product{{"foo", "", ""}},
+		},
 	}
 	for _, test := range tests {
 		t.Run("", func(t *testing.T) {
@@ -379,6 +463,14 @@ func TestRetryAfter(t *testing.T) {
 			},
 			time.Time{},
 		},
+		{
+			// Too long for a time.Duration.
+			http.Header{
+				"Date":        {"Sun, 07 Jul 2019 08:06:01 GMT"},
+				"Retry-After": {"10000000000"},
+			},
+			time.Date(2311, time.October, 17, 7, 53, 17, 0, time.UTC),
+		},
 	}
 	for _, test := range tests {
 		t.Run("", func(t *testing.T) {
@@ -911,6 +1003,19 @@ func TestAccept(t *testing.T) {
 				},
 			},
 		},
+		{
+			http.Header{"Accept": {"=, ;q=0, text/html"}},
+			[]AcceptElem{{Type: "text/html", Q: 1}},
+		},
+		{
+			http.Header{"Accept": {"a/b;q=1001, c/d;q=0.12345, e/f;q=-1, g/h;q=NaN"}},
+			[]AcceptElem{
+				{Type: "a/b", Q: 1},
+				{Type: "c/d", Q: 0.123},
+				{Type: "e/f", Q: 0},
+				{Type: "g/h", Q: 0},
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run("", func(t *testing.T) {
@@ -5,7 +5,7 @@ import (
 	"strings"
 )
 
-// An EntityTag is an opaque entity tag (RFC 7232 Section 2.3).
+// An EntityTag is an opaque entity tag (RFC 9110 Section 8.8.3).
 type EntityTag struct {
 	wildcard bool
 
@@ -33,33 +33,75 @@ func SetETag(h http.Header, tag EntityTag) {
 	h.Set("Etag", b.String())
 }
 
-// IfMatch parses the If-Match header from h (RFC 7232 Section 3.1).
+// IfMatch parses the If-Match header from h (RFC 9110 Section 13.1.1).
 // A wildcard (If-Match: *) is returned as the special AnyTag value.
 //
 // The function Match is useful for working with the returned slice.
 //
 // There is no SetIfMatch function; see comment on SetETag.
 func IfMatch(h http.Header) []EntityTag {
-	return parseTags(h, "If-Match")
+	return DefaultLimits().IfMatch(h)
 }
 
-// IfNoneMatch parses the If-None-Match header from h (RFC 7232 Section 3.2).
+// IfMatch is like the IfMatch function, but applies l instead of DefaultLimits.
+func (l Limits) IfMatch(h http.Header) []EntityTag {
+	return l.parseTags(h, "If-Match")
+}
+
+// StrictIfMatch is like IfMatch, but calls Check first.
+func StrictIfMatch(h http.Header) ([]EntityTag, error) {
+	return DefaultLimits().StrictIfMatch(h)
+}
+
+// StrictIfMatch is like the StrictIfMatch function, but applies l instead of
+// DefaultLimits.
+func (l Limits) StrictIfMatch(h http.Header) ([]EntityTag, error) {
+	if err := l.Check(h, "If-Match"); err != nil {
+		return nil, err
+	}
+	return l.IfMatch(h), nil
+}
+
+// IfNoneMatch parses the If-None-Match header from h (RFC 9110 Section 13.1.2).
 // A wildcard (If-None-Match: *) is returned as the special AnyTag value.
 //
 // The function MatchWeak is useful for working with the returned slice.
 //
 // There is no SetIfNoneMatch function; see comment on SetETag.
 func IfNoneMatch(h http.Header) []EntityTag {
-	return parseTags(h, "If-None-Match")
+	return DefaultLimits().IfNoneMatch(h)
 }
 
-func parseTags(h http.Header, name string) []EntityTag {
-	values := h[name]
+// IfNoneMatch is like the IfNoneMatch function, but applies l instead of
+// DefaultLimits.
+func (l Limits) IfNoneMatch(h http.Header) []EntityTag {
+	return l.parseTags(h, "If-None-Match")
+}
+
+// StrictIfNoneMatch is like IfNoneMatch, but calls Check first.
+func StrictIfNoneMatch(h http.Header) ([]EntityTag, error) {
+	return DefaultLimits().StrictIfNoneMatch(h)
+}
+
+// StrictIfNoneMatch is like the StrictIfNoneMatch function, but applies l
+// instead of DefaultLimits.
+func (l Limits) StrictIfNoneMatch(h http.Header) ([]EntityTag, error) {
+	if err := l.Check(h, "If-None-Match"); err != nil {
+		return nil, err
+	}
+	return l.IfNoneMatch(h), nil
+}
+
+func (l Limits) parseTags(h http.Header, name string) []EntityTag {
+	values := l.values(h[name])
 	if values == nil {
 		return nil
 	}
 	tags := make([]EntityTag, 0, estimateElems(values))
 	for v, vs := iterElems("", values); v != ""; v, vs = iterElems(v, vs) {
+		if l.elemsDone(len(tags)) {
+			break
+		}
 		if peek(v) == '*' {
 			tags = append(tags, AnyTag)
 			continue
@@ -77,14 +119,14 @@ func parseTags(h http.Header, name string) []EntityTag {
 }
 
 // Match returns true if serverTag is equivalent to any of clientTags by strong
-// comparison (RFC 7232 Section 2.3.2), as necessary for interpreting the If-Match
+// comparison (RFC 9110 Section 8.8.3.2), as necessary for interpreting the If-Match
 // header. For If-None-Match, use MatchWeak instead.
 func Match(clientTags []EntityTag, serverTag EntityTag) bool {
 	return matchTags(clientTags, serverTag, false)
 }
 
 // MatchWeak returns true if serverTag is equivalent to any of clientTags by weak
-// comparison (RFC 7232 Section 2.3.2), as necessary for interpreting
+// comparison (RFC 9110 Section 8.8.3.2), as necessary for interpreting
 // the If-None-Match header. For If-Match, use Match instead.
 func MatchWeak(clientTags []EntityTag, serverTag EntityTag) bool {
 	return matchTags(clientTags, serverTag, true)
@@ -8,7 +8,10 @@ import (
 )
 
 // A WarningElem represents one element of the Warning header
-// (RFC 7234 Section 5.5).
+// (RFC 9111 Section 5.5).
+//
+// Deprecated: RFC 9111 obsoletes the Warning header, because it was
+// not widely generated or surfaced to users.
 type WarningElem struct {
 	Code  int
 	Agent string // defaults to "-" on output
@@ -16,19 +19,34 @@ type WarningElem struct {
 	Date  time.Time // zero if missing
 }
 
-// Warning parses the Warning header from h (RFC 7234 Section 5.5).
+// Warning parses the Warning header from h (RFC 9111 Section 5.5).
+//
+// Deprecated: RFC 9111 obsoletes the Warning header.
 func Warning(h http.Header) []WarningElem {
-	values := h["Warning"]
+	return DefaultLimits().Warning(h)
+}
+
+// Warning is like the Warning function, but applies l instead of DefaultLimits.
+//
+// Deprecated: RFC 9111 obsoletes the Warning header.
+func (l Limits) Warning(h http.Header) []WarningElem {
+	values := l.values(h["Warning"])
 	if values == nil {
 		return nil
 	}
 	elems := make([]WarningElem, 0, estimateElems(values))
 	for v, vs := iterElems("", values); v != ""; v, vs = iterElems(v, vs) {
+		if l.elemsDone(len(elems)) {
+			break
+		}
 		var elem WarningElem
 		var codeStr string
 		codeStr, v = consumeTo(v, ' ', false)
 		elem.Code, _ = strconv.Atoi(codeStr)
 		elem.Agent, v = consumeTo(v, ' ', false)
+		if elem.Agent == "" {
+			continue
+		}
 		elem.Text, v = consumeQuoted(v)
 		v = skipWS(v)
 		if peek(v) == '"' {
@@ -41,7 +59,28 @@ func Warning(h http.Header) []WarningElem {
 	return elems
 }
 
+// StrictWarning is like Warning, but calls Check first.
+//
+// Deprecated: RFC 9111 obsoletes the Warning header.
+func StrictWarning(h http.Header) ([]WarningElem, error) {
+	return DefaultLimits().StrictWarning(h)
+}
+
+// StrictWarning is like the StrictWarning function, but applies l instead of
+// DefaultLimits.
+//
+// Deprecated: RFC 9111 obsoletes the Warning header.
+func (l Limits) StrictWarning(h http.Header) ([]WarningElem, error) {
+	if err := l.Check(h, "Warning"); err != nil {
+		return nil, err
+	}
+	return l.Warning(h), nil
+}
+
 // SetWarning replaces the Warning header in h. See also AddWarning.
+//
+// Deprecated: RFC 9111 obsoletes the Warning header, so it should not
+// be generated.
 func SetWarning(h http.Header, elems []WarningElem) {
 	if len(elems) == 0 {
 		h.Del("Warning")
@@ -51,6 +90,9 @@ func SetWarning(h http.Header, elems []WarningElem) {
 }
 
 // AddWarning is like SetWarning but appends instead of replacing.
+//
+// Deprecated: RFC 9111 obsoletes the Warning header, so it should not
+// be generated.
 func AddWarning(h http.Header, elems ...WarningElem) {
 	if len(elems) == 0 {
 		return
@@ -78,7 +120,7 @@ func buildWarning(elems []WarningElem) string {
 }
 
 // CacheDirectives represents directives of the Cache-Control header
-// (RFC 7234 Section 5.2). Standard directives are stored in the corresponding
+// (RFC 9111 Section 5.2). Standard directives are stored in the corresponding
 // fields; any unknown extensions are stored in Ext.
 type CacheDirectives struct {
 	NoStore         bool
@@ -88,11 +130,14 @@ type CacheDirectives struct {
 	Public          bool
 	ProxyRevalidate bool
 	Immutable       bool // RFC 8246
+	MustUnderstand  bool // RFC 9111 Section 5.2.2.3
 
 	// NoCache is true if the no-cache directive is present without an argument.
 	// If it has an argument -- a list of header names -- these are
 	// stored in NoCacheHeaders, canonicalized with http.CanonicalHeaderKey;
-	// while NoCache remains false. Similarly for the private directive.
+	// while NoCache remains false. If no-cache also occurs without
+	// an argument, it covers the whole response, so only NoCache is set.
+	// Similarly for the private directive.
 	NoCache        bool
 	Private        bool
 	NoCacheHeaders []string
@@ -135,13 +180,27 @@ func DeltaSeconds(s int) Delta {
 // Eternity represents unlimited age for the max-stale cache directive.
 var Eternity = Delta{1<<31 - 1, true}
 
-// CacheControl parses the Cache-Control header from h (RFC 7234 Section 5.2).
+// CacheControl parses the Cache-Control header from h (RFC 9111 Section 5.2).
 func CacheControl(h http.Header) CacheDirectives {
+	return DefaultLimits().CacheControl(h)
+}
+
+// CacheControl is like the CacheControl function, but applies l instead of
+// DefaultLimits.
+func (l Limits) CacheControl(h http.Header) CacheDirectives {
 	var cc CacheDirectives
-	for v, vs := iterElems("", h["Cache-Control"]); v != ""; v, vs = iterElems(v, vs) {
+	values := l.values(h["Cache-Control"])
+	n := 0
+	for v, vs := iterElems("", values); v != ""; v, vs = iterElems(v, vs) {
+		if l.elemsDone(n) {
+			break
+		}
+		n++
 		var name, value string
 		name, value, v = consumeParam(v)
 		switch name {
+		case "":
+			continue
 		case "private":
 			if value == "" {
 				cc.Private = true
@@ -180,6 +239,8 @@ func CacheControl(h http.Header) CacheDirectives {
 			cc.NoTransform = true
 		case "immutable":
 			cc.Immutable = true
+		case "must-understand":
+			cc.MustUnderstand = true
 		case "only-if-cached":
 			cc.OnlyIfCached = true
 		case "proxy-revalidate":
@@ -201,9 +262,29 @@ func CacheControl(h http.Header) CacheDirectives {
 			cc.Ext[name] = value
 		}
 	}
+	if cc.NoCache {
+		cc.NoCacheHeaders = nil
+	}
+	if cc.Private {
+		cc.PrivateHeaders = nil
+	}
 	return cc
 }
 
+// StrictCacheControl is like CacheControl, but calls Check first.
+func StrictCacheControl(h http.Header) (CacheDirectives, error) {
+	return DefaultLimits().StrictCacheControl(h)
+}
+
+// StrictCacheControl is like the StrictCacheControl function, but applies l
+// instead of DefaultLimits.
+func (l Limits) StrictCacheControl(h http.Header) (CacheDirectives, error) {
+	if err := l.Check(h, "Cache-Control"); err != nil {
+		return CacheDirectives{}, err
+	}
+	return l.CacheControl(h), nil
+}
+
 // SetCacheControl replaces the Cache-Control header in h.
 func SetCacheControl(h http.Header, cc CacheDirectives) {
 	b := &strings.Builder{}
@@ -229,6 +310,9 @@ func SetCacheControl(h http.Header, cc CacheDirectives) {
 	if cc.Immutable {
 		wrote = writeDirective(b, wrote, "immutable", "")
 	}
+	if cc.MustUnderstand {
+		wrote = writeDirective(b, wrote, "must-understand", "")
+	}
 	if cc.Private || len(cc.PrivateHeaders) > 0 {
 		// "A sender SHOULD NOT generate the token form"
 		wrote = writeDirective(b, wrote, "private", "")
@@ -270,8 +354,8 @@ func SetCacheControl(h http.Header, cc CacheDirectives) {
 		wrote = writeDirective(b, wrote, "stale-if-error",
 			strconv.Itoa(cc.StaleIfError.seconds))
 	}
-	for name, value := range cc.Ext {
-		wrote = writeDirective(b, wrote, name, value)
+	for _, name := range sortedKeys(cc.Ext) {
+		wrote = writeDirective(b, wrote, name, cc.Ext[name])
 	}
 	if !wrote {
 		h.Del("Cache-Control")
@@ -281,11 +365,14 @@ func SetCacheControl(h http.Header, cc CacheDirectives) {
 }
 
 func headerNames(v string) []string {
-	names := strings.FieldsFunc(v, func(r rune) bool {
+	fields := strings.FieldsFunc(v, func(r rune) bool {
 		return r == ' ' || r == '\t' || r == ','
 	})
-	for i := range names {
-		names[i] = http.CanonicalHeaderKey(names[i])
+	names := fields[:0]
+	for _, name := range fields {
+		if isToken(name) { // anything else cannot be a header name
+			names = append(names, http.CanonicalHeaderKey(name))
+		}
 	}
 	return names
 }
@@ -124,7 +124,7 @@ func TestWarning(t *testing.T) {
 		// They may change as convenient for the parsing code.
 		{
 			http.Header{"Warning": {"299"}},
-			[]WarningElem{{299, "", "", time.Time{}}},
+			[]WarningElem{},
 		},
 		{
 			http.Header{"Warning": {"299 -"}},
@@ -136,7 +136,7 @@ func TestWarning(t *testing.T) {
 		},
 		{
 			http.Header{"Warning": {`299  - "two spaces"`}},
-			[]WarningElem{{299, "", "", time.Time{}}},
+			[]WarningElem{},
 		},
 		{
 			http.Header{"Warning": {`?????,299 - "good"`}},
@@ -144,10 +144,7 @@ func TestWarning(t *testing.T) {
 		},
 		{
 			http.Header{"Warning": {`299  bad, 299 - "good"`}},
-			[]WarningElem{
-				{299, "", "", time.Time{}},
-				{299, "-", "good", time.Time{}},
-			},
+			[]WarningElem{{299, "-", "good", time.Time{}}},
 		},
 		{
 			http.Header{"Warning": {`299 - "good" "bad date"`}},
@@ -253,6 +250,10 @@ func TestCacheControl(t *testing.T) {
 			http.Header{"Cache-Control": {"Immutable, Max-Age=3600"}},
 			CacheDirectives{Immutable: true, MaxAge: DeltaSeconds(3600)},
 		},
+		{
+			http.Header{"Cache-Control": {"no-store, must-understand"}},
+			CacheDirectives{NoStore: true, MustUnderstand: true},
+		},
 		{
 			http.Header{"Cache-Control": {"private,no-cache"}},
 			CacheDirectives{Private: true, NoCache: true},
@@ -335,6 +336,16 @@ func TestCacheControl(t *testing.T) {
 			http.Header{"Cache-Control": {"stale-if-error = 60"}},
 			CacheDirectives{StaleIfError: DeltaSeconds(60)},
 		},
+		{
+			http.Header{"Cache-Control": {`=, private="Set-Cookie, x\"y, (z)"`}},
+			CacheDirectives{PrivateHeaders: []string{"Set-Cookie"}},
+		},
+		{
+			http.Header{"Cache-Control": {
+				`private="Set-Cookie", no-cache, private, no-cache="Warning"`,
+			}},
+			CacheDirectives{Private: true, NoCache: true},
+		},
 	}
 	for _, test := range tests {
 		t.Run("", func(t *testing.T) {
@@ -364,6 +375,10 @@ func TestSetCacheControl(t *testing.T) {
 			CacheDirectives{MustRevalidate: true, ProxyRevalidate: true},
 			http.Header{"Cache-Control": {"must-revalidate, proxy-revalidate"}},
 		},
+		{
+			CacheDirectives{NoStore: true, MustUnderstand: true},
+			http.Header{"Cache-Control": {"no-store, must-understand"}},
+		},
 		{
 			CacheDirectives{
 				Public:    true,
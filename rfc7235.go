@@ -5,16 +5,18 @@ import (
 	"strings"
 )
 
-// Auth represents an authentication challenge or credentials (RFC 7235
-// Section 2.1). When using the token68 form, the Token field is non-zero.
+// Auth represents an authentication challenge or credentials (RFC 9110
+// Section 11.3). When using the token68 form, the Token field is non-zero.
 // When using the auth-param form, the Realm and/or Params fields are non-zero.
 // Realm is the value of the 'realm' parameter, if any. Sending an empty realm=""
-// is not supported, and any 'realm' key in Params is ignored.
+// is not supported, and any 'realm' key in Params is ignored. A realm is always
+// sent as a quoted string, but an unquoted token is accepted on input
+// (RFC 9110 Section 11.5).
 //
 // "Star" parameters like RFC 7616's 'username*' are not treated specially.
 // Call DecodeExtValue and EncodeExtValue manually if needed.
 //
-// Scheme names are case-insensitive according to RFC 7235, but many
+// Scheme names are case-insensitive according to RFC 9110, but many
 // implementations erroneously expect them to be in their canonical spelling
 // as given in https://www.iana.org/assignments/http-authschemes/.
 // Because of this, all functions returning Auth lowercase the Scheme,
@@ -29,9 +31,29 @@ type Auth struct {
 }
 
 // WWWAuthenticate parses the WWW-Authenticate header from h
-// (RFC 7235 Section 4.1).
+// (RFC 9110 Section 11.6.1).
 func WWWAuthenticate(h http.Header) []Auth {
-	return parseChallenges(h["Www-Authenticate"])
+	return DefaultLimits().WWWAuthenticate(h)
+}
+
+// WWWAuthenticate is like the WWWAuthenticate function, but applies l instead
+// of DefaultLimits.
+func (l Limits) WWWAuthenticate(h http.Header) []Auth {
+	return l.parseChallenges(l.values(h["Www-Authenticate"]))
+}
+
+// StrictWWWAuthenticate is like WWWAuthenticate, but calls Check first.
+func StrictWWWAuthenticate(h http.Header) ([]Auth, error) {
+	return DefaultLimits().StrictWWWAuthenticate(h)
+}
+
+// StrictWWWAuthenticate is like the StrictWWWAuthenticate function, but
+// applies l instead of DefaultLimits.
+func (l Limits) StrictWWWAuthenticate(h http.Header) ([]Auth, error) {
+	if err := l.Check(h, "Www-Authenticate"); err != nil {
+		return nil, err
+	}
+	return l.WWWAuthenticate(h), nil
 }
 
 // SetWWWAuthenticate replaces the WWW-Authenticate header in h.
@@ -40,9 +62,29 @@ func SetWWWAuthenticate(h http.Header, challenges []Auth) {
 }
 
 // ProxyAuthenticate parses the Proxy-Authenticate header from h
-// (RFC 7235 Section 4.3).
+// (RFC 9110 Section 11.7.1).
 func ProxyAuthenticate(h http.Header) []Auth {
-	return parseChallenges(h["Proxy-Authenticate"])
+	return DefaultLimits().ProxyAuthenticate(h)
+}
+
+// ProxyAuthenticate is like the ProxyAuthenticate function, but applies l
+// instead of DefaultLimits.
+func (l Limits) ProxyAuthenticate(h http.Header) []Auth {
+	return l.parseChallenges(l.values(h["Proxy-Authenticate"]))
+}
+
+// StrictProxyAuthenticate is like ProxyAuthenticate, but calls Check first.
+func StrictProxyAuthenticate(h http.Header) ([]Auth, error) {
+	return DefaultLimits().StrictProxyAuthenticate(h)
+}
+
+// StrictProxyAuthenticate is like the StrictProxyAuthenticate function, but
+// applies l instead of DefaultLimits.
+func (l Limits) StrictProxyAuthenticate(h http.Header) ([]Auth, error) {
+	if err := l.Check(h, "Proxy-Authenticate"); err != nil {
+		return nil, err
+	}
+	return l.ProxyAuthenticate(h), nil
 }
 
 // SetProxyAuthenticate replaces the Proxy-Authenticate header in h.
@@ -50,10 +92,30 @@ func SetProxyAuthenticate(h http.Header, challenges []Auth) {
 	setChallenges(h, "Proxy-Authenticate", challenges)
 }
 
-// Authorization parses the Authorization header from h (RFC 7235 Section 4.2).
+// Authorization parses the Authorization header from h (RFC 9110 Section 11.6.2).
 // If h doesn't contain Authorization, a zero Auth is returned.
 func Authorization(h http.Header) Auth {
-	return parseCredentials(h.Get("Authorization"))
+	return DefaultLimits().Authorization(h)
+}
+
+// Authorization is like the Authorization function, but applies l instead of
+// DefaultLimits.
+func (l Limits) Authorization(h http.Header) Auth {
+	return l.parseCredentials(l.value(h.Get("Authorization")))
+}
+
+// StrictAuthorization is like Authorization, but calls Check first.
+func StrictAuthorization(h http.Header) (Auth, error) {
+	return DefaultLimits().StrictAuthorization(h)
+}
+
+// StrictAuthorization is like the StrictAuthorization function, but applies l
+// instead of DefaultLimits.
+func (l Limits) StrictAuthorization(h http.Header) (Auth, error) {
+	if err := l.Check(h, "Authorization"); err != nil {
+		return Auth{}, err
+	}
+	return l.Authorization(h), nil
 }
 
 // SetAuthorization replaces the Authorization header in h.
@@ -62,10 +124,30 @@ func SetAuthorization(h http.Header, credentials Auth) {
 }
 
 // ProxyAuthorization parses the Proxy-Authorization header from h
-// (RFC 7235 Section 4.4).
+// (RFC 9110 Section 11.7.2).
 // If h doesn't contain Proxy-Authorization, a zero Auth is returned.
 func ProxyAuthorization(h http.Header) Auth {
-	return parseCredentials(h.Get("Proxy-Authorization"))
+	return DefaultLimits().ProxyAuthorization(h)
+}
+
+// ProxyAuthorization is like the ProxyAuthorization function, but applies l
+// instead of DefaultLimits.
+func (l Limits) ProxyAuthorization(h http.Header) Auth {
+	return l.parseCredentials(l.value(h.Get("Proxy-Authorization")))
+}
+
+// StrictProxyAuthorization is like ProxyAuthorization, but calls Check first.
+func StrictProxyAuthorization(h http.Header) (Auth, error) {
+	return DefaultLimits().StrictProxyAuthorization(h)
+}
+
+// StrictProxyAuthorization is like the StrictProxyAuthorization function, but
+// applies l instead of DefaultLimits.
+func (l Limits) StrictProxyAuthorization(h http.Header) (Auth, error) {
+	if err := l.Check(h, "Proxy-Authorization"); err != nil {
+		return Auth{}, err
+	}
+	return l.ProxyAuthorization(h), nil
 }
 
 // SetProxyAuthorization replaces the Proxy-Authorization header in h.
@@ -73,28 +155,41 @@ func SetProxyAuthorization(h http.Header, credentials Auth) {
 	h.Set("Proxy-Authorization", buildAuth(false, credentials))
 }
 
-func parseChallenges(values []string) []Auth {
+func (l Limits) parseChallenges(values []string) []Auth {
 	if values == nil {
 		return nil
 	}
 	challenges := make([]Auth, 0, estimateElems(values))
 	for v, vs := iterElems("", values); v != ""; v, vs = iterElems(v, vs) {
+		if l.elemsDone(len(challenges)) {
+			break
+		}
 		var challenge Auth
-		challenge, v = consumeAuth(v, true)
+		challenge, v = l.consumeAuth(v, true)
+		if challenge.Scheme == "" {
+			continue
+		}
 		challenges = append(challenges, challenge)
 	}
 	return challenges
 }
 
-func parseCredentials(v string) Auth {
-	var credentials Auth
-	credentials, _ = consumeAuth(v, false)
+func (l Limits) parseCredentials(v string) Auth {
+	credentials, _ := l.consumeAuth(v, false)
+	if credentials.Scheme == "" {
+		return Auth{}
+	}
 	return credentials
 }
 
-func consumeAuth(v string, challenge bool) (Auth, string) {
+func (l Limits) consumeAuth(v string, challenge bool) (Auth, string) {
 	var auth Auth
 	auth.Scheme, v = consumeItem(v)
+	if !isToken(auth.Scheme) {
+		// Not a valid challenge or credentials, but they must be consumed
+		// anyway. The caller discards them.
+		auth.Scheme = ""
+	}
 	auth.Scheme = foldAuthScheme(auth.Scheme)
 	maybeToken68 := true
 ParamsLoop:
@@ -129,11 +224,15 @@ ParamsLoop:
 		maybeToken68 = false
 		var name, value string
 		name, value, v = consumeParam(v)
-		switch name {
-		case "":
+		switch {
+		case name == "":
 			break ParamsLoop
-		case "realm":
+		case !isToken(name):
+			// Cannot be an auth-param.
+		case name == "realm":
 			auth.Realm = value
+		case l.paramsDone(len(auth.Params)):
+			// Keep consuming to find the end of the challenge.
 		default:
 			if auth.Params == nil {
 				auth.Params = make(map[string]string)
@@ -155,6 +254,9 @@ func detectAuthParam(v string) bool {
 func consumeToken68(v string) (token68, newv string) {
 	orig := v
 	token68, v = consumeItem(v)
+	if token68 == "" {
+		return "", orig
+	}
 	// consumeItem didn't consume the trailing equal signs, if any.
 	for peek(v) == '=' {
 		token68 = orig[:len(token68)+1]
@@ -190,13 +292,14 @@ func buildAuth(challenge bool, auths ...Auth) string {
 		}
 		var wrote bool
 		if auth.Realm != "" {
-			// RFC 7235 page 6: ``For historical reasons, a sender MUST only
+			// RFC 9110 Section 11.5: ``For historical reasons, a sender MUST only
 			// generate the quoted-string syntax.''
 			write(b, " realm=")
 			writeQuoted(b, auth.Realm)
 			wrote = true
 		}
-		for name, value := range auth.Params {
+		for _, name := range sortedKeys(auth.Params) {
+			value := auth.Params[name]
 			if strings.ToLower(name) == "realm" {
 				continue
 			}
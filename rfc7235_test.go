@@ -243,6 +243,10 @@ func TestWWWAuthenticate(t *testing.T) {
 			http.Header{"Www-Authenticate": {`Foo , =bar`}},
 			[]Auth{{Scheme: "foo"}},
 		},
+		{
+			http.Header{"Www-Authenticate": {"=, Basic realm=x, @, Bearer"}},
+			[]Auth{{Scheme: "basic", Realm: "x"}, {Scheme: "bearer"}},
+		},
 	}
 	for _, test := range tests {
 		t.Run("", func(t *testing.T) {
@@ -279,7 +283,7 @@ func TestSetWWWAuthenticate(t *testing.T) {
 			http.Header{"Www-Authenticate": {`OAuth, HOBA`}},
 		},
 		{
-			// RFC 7235 page 6: ``For historical reasons, a sender MUST only
+			// RFC 9110 Section 11.5: ``For historical reasons, a sender MUST only
 			// generate the quoted-string syntax [for the realm parameter].''
 			// RFC 7616 page 9: ``For historical reasons, a sender MUST only
 			// generate the quoted string syntax values for the following
@@ -480,6 +484,14 @@ func TestAuthorization(t *testing.T) {
 			http.Header{"Authorization": {"Basic=XpLOI2ydaLvA1z"}},
 			Auth{Scheme: "basic"},
 		},
+		{
+			http.Header{"Authorization": {"Ϭasic realm=x"}},
+			Auth{},
+		},
+		{
+			http.Header{"Authorization": {"Foo (a)=b, c=d"}},
+			Auth{Scheme: "foo", Params: map[string]string{"c": "d"}},
+		},
 	}
 	for _, test := range tests {
 		t.Run("", func(t *testing.T) {
@@ -1,6 +1,9 @@
 package httpheader
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"net"
 	"net/http"
 	"strconv"
@@ -14,12 +17,21 @@ import (
 // header's syntax makes it possible for a malicious client to submit a malformed
 // value that will "shadow" further elements appended to the same value.
 func Forwarded(h http.Header) []ForwardedElem {
-	values := h["Forwarded"]
+	return DefaultLimits().Forwarded(h)
+}
+
+// Forwarded is like the Forwarded function, but applies l instead of
+// DefaultLimits.
+func (l Limits) Forwarded(h http.Header) []ForwardedElem {
+	values := l.values(h["Forwarded"])
 	if values == nil {
 		return nil
 	}
 	elems := make([]ForwardedElem, 0, estimateElems(values))
 	for v, vs := iterElems("", values); v != ""; v, vs = iterElems(v, vs) {
+		if l.elemsDone(len(elems)) {
+			break
+		}
 		var elem ForwardedElem
 	ParamsLoop:
 		for {
@@ -37,6 +49,9 @@ func Forwarded(h http.Header) []ForwardedElem {
 			case "proto":
 				elem.Proto = strings.ToLower(value)
 			default:
+				if l.paramsDone(len(elem.Ext)) {
+					continue
+				}
 				if elem.Ext == nil {
 					elem.Ext = make(map[string]string)
 				}
@@ -48,7 +63,26 @@ func Forwarded(h http.Header) []ForwardedElem {
 	return elems
 }
 
+// StrictForwarded is like Forwarded, but calls Check first.
+func StrictForwarded(h http.Header) ([]ForwardedElem, error) {
+	return DefaultLimits().StrictForwarded(h)
+}
+
+// StrictForwarded is like the StrictForwarded function, but applies l instead
+// of DefaultLimits.
+func (l Limits) StrictForwarded(h http.Header) ([]ForwardedElem, error) {
+	if err := l.Check(h, "Forwarded"); err != nil {
+		return nil, err
+	}
+	return l.Forwarded(h), nil
+}
+
 // SetForwarded replaces the Forwarded header in h (RFC 7239).
+//
+// Any ObfuscatedNode or ObfuscatedPort that doesn't match the obfnode
+// or obfport grammar of RFC 7239 Section 6.3 (an underscore followed by
+// letters, digits, dots, underscores, or dashes) is replaced with "unknown".
+// See also Obfuscator.
 func SetForwarded(h http.Header, elems []ForwardedElem) {
 	if len(elems) == 0 {
 		h.Del("Forwarded")
@@ -80,8 +114,8 @@ func buildForwarded(elems []ForwardedElem) string {
 		if elem.Proto != "" {
 			wrote = writeParam(b, wrote, "proto", elem.Proto)
 		}
-		for name, value := range elem.Ext {
-			wrote = writeParam(b, wrote, name, value)
+		for _, name := range sortedKeys(elem.Ext) {
+			wrote = writeParam(b, wrote, name, elem.Ext[name])
 		}
 		if !wrote {
 			write(b, "for=unknown")
@@ -111,6 +145,11 @@ type Node struct {
 	ObfuscatedPort string
 }
 
+func (node Node) isZero() bool {
+	return node.IP == nil && node.Port == 0 &&
+		node.ObfuscatedNode == "" && node.ObfuscatedPort == ""
+}
+
 func parseNode(s string) Node {
 	var node Node
 	rawIP, rawPort := s, ""
@@ -135,52 +174,295 @@ func parseNode(s string) Node {
 	return node
 }
 
+// isObfuscated reports whether s is a valid obfnode or obfport
+// (RFC 7239 Section 6.3).
+func isObfuscated(s string) bool {
+	if len(s) < 2 || s[0] != '_' {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '.', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 func writeNode(b *strings.Builder, wrote bool, name string, node Node) bool {
+	raw := formatNode(node)
+	if raw == "" {
+		return wrote
+	}
+	if wrote {
+		write(b, ";")
+	}
+	write(b, name, "=")
+	// IPv6 addresses and ports require quoting.
+	if strings.IndexByte(raw, ':') != -1 {
+		write(b, `"`, raw, `"`)
+	} else {
+		write(b, raw)
+	}
+	return true
+}
+
+// formatNode returns the node identifier for node, such as
+// "[2001:db8::1]:8080", or an empty string if node is empty.
+func formatNode(node Node) string {
 	var rawIP, rawPort string
 
 	switch {
 	case node.Port != 0:
 		rawPort = strconv.Itoa(node.Port)
-	case node.ObfuscatedPort != "":
+	case isObfuscated(node.ObfuscatedPort):
 		rawPort = node.ObfuscatedPort
 	}
 
 	switch {
 	case node.IP != nil:
 		rawIP = node.IP.String()
-	case node.ObfuscatedNode != "":
+	case isObfuscated(node.ObfuscatedNode):
 		rawIP = node.ObfuscatedNode
 	case rawPort != "":
 		rawIP = "unknown"
 	}
 
-	if rawIP == "" && rawPort == "" {
-		return wrote
+	if strings.IndexByte(rawIP, ':') != -1 {
+		rawIP = "[" + rawIP + "]"
 	}
+	if rawPort != "" {
+		return rawIP + ":" + rawPort
+	}
+	return rawIP
+}
 
-	ipv6 := strings.IndexByte(rawIP, ':') != -1
-	quoted := ipv6 || rawPort != ""
+// An Obfuscator generates obfuscated node identifiers (RFC 7239 Section 6.3)
+// from real IP addresses and ports. The identifiers are derived from an HMAC
+// of the address with Key, so a given client always gets the same identifier,
+// but recipients who don't know Key cannot recover the address.
+// Key should be random and kept secret; an empty Key provides no secrecy.
+type Obfuscator struct {
+	Key []byte
+}
 
-	if wrote {
-		write(b, ";")
+// Node returns a copy of node with IP and Port replaced by ObfuscatedNode
+// and ObfuscatedPort. The obfuscated port depends on both the address
+// (IP, or else ObfuscatedNode) and Port. An ObfuscatedNode or ObfuscatedPort
+// that does not match the grammar of RFC 7239 Section 6.3, such as one parsed
+// from a non-conforming Forwarded header, is obfuscated again. Parts that are
+// already obfuscated or missing are left as is. The method value
+// (e.g. obf.Node) is suitable for Hop's Obfuscate field.
+func (obf Obfuscator) Node(node Node) Node {
+	addr := node.ObfuscatedNode
+	if node.IP != nil {
+		addr = node.IP.String()
 	}
-	write(b, name, "=")
-	if quoted {
-		write(b, `"`)
+	switch {
+	case node.Port != 0:
+		node.ObfuscatedPort = obf.identifier(addr, ":", strconv.Itoa(node.Port))
+		node.Port = 0
+	case node.ObfuscatedPort != "" && !isObfuscated(node.ObfuscatedPort):
+		node.ObfuscatedPort = obf.identifier(addr, ":", node.ObfuscatedPort)
 	}
-	if ipv6 {
-		write(b, "[")
+	if node.IP != nil || node.ObfuscatedNode != "" && !isObfuscated(node.ObfuscatedNode) {
+		node.ObfuscatedNode = obf.identifier(addr)
+		node.IP = nil
 	}
-	write(b, rawIP)
-	if ipv6 {
-		write(b, "]")
+	return node
+}
+
+func (obf Obfuscator) identifier(parts ...string) string {
+	mac := hmac.New(sha256.New, obf.Key)
+	for _, part := range parts {
+		mac.Write([]byte(part))
 	}
-	if rawPort != "" {
-		write(b, ":", rawPort)
+	// 10 bytes (80 bits) are plenty to avoid collisions between clients.
+	return "_" + hex.EncodeToString(mac.Sum(nil)[:10])
+}
+
+// XForwarded parses the de facto X-Forwarded-For, X-Forwarded-Proto,
+// X-Forwarded-Host and X-Forwarded-Port headers from h, converting them
+// into elements like those returned by Forwarded (RFC 7239 Section 7.4).
+//
+// Each element of X-Forwarded-For becomes one ForwardedElem with the For field
+// set. X-Forwarded-Proto, X-Forwarded-Host and X-Forwarded-Port are mapped to
+// Proto, Host and By.Port. As RFC 7239 Section 7.4 points out, it is impossible
+// to tell for sure how these headers relate to each other. When one of them
+// has exactly as many elements as X-Forwarded-For, its elements are assigned
+// to the returned elements one by one. Otherwise, only its first element
+// is used, and assigned to the first returned element (the one describing
+// the original client's request).
+//
+// X-Forwarded-Port is the port on which a proxy received the request, so it
+// becomes the Port of the By node, leaving its address unknown. SetForwarded
+// writes such a node as by="unknown:443". To omit it, clear By.
+//
+// If h contains none of these headers (or only empty ones), XForwarded returns
+// nil. If h contains some of them but not X-Forwarded-For, a single element
+// is returned.
+func XForwarded(h http.Header) []ForwardedElem {
+	return DefaultLimits().XForwarded(h)
+}
+
+// XForwarded is like the XForwarded function, but applies l instead of
+// DefaultLimits.
+func (l Limits) XForwarded(h http.Header) []ForwardedElem {
+	fors := l.xForwardedItems(h["X-Forwarded-For"])
+	protos := l.xForwardedItems(h["X-Forwarded-Proto"])
+	hosts := l.xForwardedItems(h["X-Forwarded-Host"])
+	ports := l.xForwardedItems(h["X-Forwarded-Port"])
+	n := len(fors)
+	if n == 0 {
+		if len(protos) == 0 && len(hosts) == 0 && len(ports) == 0 {
+			return nil
+		}
+		n = 1
 	}
-	if quoted {
-		write(b, `"`)
+	elems := make([]ForwardedElem, n)
+	for i, item := range fors {
+		// Unlike Forwarded, X-Forwarded-For doesn't bracket IPv6 addresses.
+		if ip := net.ParseIP(item); ip != nil {
+			elems[i].For.IP = ip
+		} else {
+			elems[i].For = parseNode(item)
+		}
 	}
+	for i, item := range spreadXForwarded(protos, n) {
+		elems[i].Proto = strings.ToLower(item)
+	}
+	for i, item := range spreadXForwarded(hosts, n) {
+		elems[i].Host = item
+	}
+	for i, item := range spreadXForwarded(ports, n) {
+		elems[i].By.Port, _ = strconv.Atoi(item)
+	}
+	return elems
+}
 
-	return true
+// StrictXForwarded is like XForwarded, but calls Check first.
+func StrictXForwarded(h http.Header) ([]ForwardedElem, error) {
+	return DefaultLimits().StrictXForwarded(h)
+}
+
+// StrictXForwarded is like the StrictXForwarded function, but applies l
+// instead of DefaultLimits.
+func (l Limits) StrictXForwarded(h http.Header) ([]ForwardedElem, error) {
+	err := l.Check(h, "X-Forwarded-For", "X-Forwarded-Proto", "X-Forwarded-Host",
+		"X-Forwarded-Port")
+	if err != nil {
+		return nil, err
+	}
+	return l.XForwarded(h), nil
+}
+
+func (l Limits) xForwardedItems(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	values = l.values(values)
+	items := make([]string, 0, estimateElems(values))
+	for v, vs := iterElems("", values); v != ""; v, vs = iterElems(v, vs) {
+		if l.elemsDone(len(items)) {
+			break
+		}
+		var item string
+		item, v = consumeItem(v)
+		if item == "" {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func spreadXForwarded(items []string, n int) []string {
+	if len(items) == n || len(items) == 0 {
+		return items
+	}
+	return items[:1]
+}
+
+// SetXForwarded replaces the X-Forwarded-For, X-Forwarded-Proto,
+// X-Forwarded-Host and X-Forwarded-Port headers in h, converting them
+// from elems (RFC 7239 Section 7.4). See XForwarded for the mapping.
+//
+// X-Forwarded-Proto is generated with one value per element if every element
+// has a Proto; otherwise, with the first Proto that is present, if any.
+// Similarly for X-Forwarded-Host and X-Forwarded-Port. Missing For nodes
+// are sent as "unknown", as are obfuscated identifiers that are not valid
+// (RFC 7239 Section 6.3). A Proto or Host that contains whitespace or
+// delimiters such as commas is treated as missing.
+func SetXForwarded(h http.Header, elems []ForwardedElem) {
+	var fors, protos, hosts, ports []string
+	for _, elem := range elems {
+		fors = append(fors, xForwardedNode(elem.For))
+		protos = append(protos, xForwardedItem(elem.Proto))
+		hosts = append(hosts, xForwardedItem(elem.Host))
+		var port string
+		if elem.By.Port != 0 {
+			port = strconv.Itoa(elem.By.Port)
+		}
+		ports = append(ports, port)
+	}
+	setXForwarded(h, "X-Forwarded-For", fors)
+	setXForwarded(h, "X-Forwarded-Proto", protos)
+	setXForwarded(h, "X-Forwarded-Host", hosts)
+	setXForwarded(h, "X-Forwarded-Port", ports)
+}
+
+func setXForwarded(h http.Header, name string, items []string) {
+	first := ""
+	for _, item := range items {
+		if item == "" {
+			items = nil
+		} else if first == "" {
+			first = item
+		}
+	}
+	switch {
+	case items != nil:
+		h.Set(name, strings.Join(items, ", "))
+	case first != "":
+		h.Set(name, first)
+	default:
+		h.Del(name)
+	}
+}
+
+// xForwardedItem returns s if it can be sent as one item of an X-Forwarded-*
+// header, that is, if XForwarded would parse it back as a whole.
+// Otherwise, it returns an empty string.
+func xForwardedItem(s string) string {
+	item, rest := consumeItem(s)
+	if rest != "" {
+		return ""
+	}
+	return item
+}
+
+func xForwardedNode(node Node) string {
+	var rawIP, rawPort string
+	switch {
+	case node.IP != nil:
+		rawIP = node.IP.String()
+	case isObfuscated(node.ObfuscatedNode):
+		rawIP = node.ObfuscatedNode
+	default:
+		rawIP = "unknown"
+	}
+	switch {
+	case node.Port != 0:
+		rawPort = strconv.Itoa(node.Port)
+	case isObfuscated(node.ObfuscatedPort):
+		rawPort = node.ObfuscatedPort
+	default:
+		return rawIP
+	}
+	if strings.IndexByte(rawIP, ':') != -1 {
+		return "[" + rawIP + "]:" + rawPort
+	}
+	return rawIP + ":" + rawPort
 }
@@ -5,6 +5,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -355,6 +356,17 @@ func TestSetForwarded(t *testing.T) {
 			},
 			http.Header{"Forwarded": {`for="_vsHsYz:_aEC"`}},
 		},
+		{
+			[]ForwardedElem{
+				{For: Node{ObfuscatedNode: "vsHsYz"}},
+				{For: Node{ObfuscatedNode: "_vs;Hs"}, By: Node{ObfuscatedNode: "_"}},
+				{For: Node{ObfuscatedNode: "_vsHsYz", ObfuscatedPort: "8080"}},
+				{For: Node{ObfuscatedNode: "_a:b", ObfuscatedPort: "_aEC"}},
+			},
+			http.Header{"Forwarded": {
+				`for=unknown, for=unknown, for=_vsHsYz, for="unknown:_aEC"`,
+			}},
+		},
 	}
 	for _, test := range tests {
 		t.Run("", func(t *testing.T) {
@@ -408,6 +420,253 @@ func BenchmarkForwardedComplex(b *testing.B) {
 	}
 }
 
+func ExampleObfuscator() {
+	obf := Obfuscator{Key: []byte("secret key shared by our proxies")}
+	header := http.Header{}
+	SetForwarded(header, []ForwardedElem{{
+		For: obf.Node(Node{IP: net.IPv4(192, 0, 2, 43), Port: 50123}),
+	}})
+	header.Write(os.Stdout)
+	// Output: Forwarded: for="_6560420ab10b2311e806:_7366453bcabdfa6cafdf"
+}
+
+func TestObfuscator(t *testing.T) {
+	obf1 := Obfuscator{Key: []byte("foo")}
+	obf2 := Obfuscator{Key: []byte("bar")}
+	node := Node{IP: net.IPv4(192, 0, 2, 43), Port: 50123}
+	other := Node{IP: net.IPv4(192, 0, 2, 43), Port: 50124}
+
+	result := obf1.Node(node)
+	if result.IP != nil || result.Port != 0 ||
+		!isObfuscated(result.ObfuscatedNode) ||
+		!isObfuscated(result.ObfuscatedPort) {
+		t.Errorf("bad obfuscation of %#v: %#v", node, result)
+	}
+	if again := obf1.Node(node); !reflect.DeepEqual(again, result) {
+		t.Errorf("unstable obfuscation: %#v then %#v", result, again)
+	}
+	if result2 := obf2.Node(node); result2.ObfuscatedNode == result.ObfuscatedNode {
+		t.Errorf("same obfuscation with different keys: %#v", result2)
+	}
+	otherResult := obf1.Node(other)
+	if otherResult.ObfuscatedNode != result.ObfuscatedNode ||
+		otherResult.ObfuscatedPort == result.ObfuscatedPort {
+		t.Errorf("bad obfuscation of %#v: %#v", other, otherResult)
+	}
+
+	portA := obf1.Node(Node{ObfuscatedNode: "_a", Port: 80})
+	portB := obf1.Node(Node{ObfuscatedNode: "_b", Port: 80})
+	if portA.ObfuscatedNode != "_a" || portA.Port != 0 ||
+		!isObfuscated(portA.ObfuscatedPort) ||
+		portA.ObfuscatedPort == portB.ObfuscatedPort {
+		t.Errorf("bad obfuscation of ports of obfuscated nodes: %#v, %#v", portA, portB)
+	}
+
+	parsed := Forwarded(http.Header{"Forwarded": {`for="a:b"`}})[0].For
+	if result := obf1.Node(parsed); !isObfuscated(result.ObfuscatedNode) ||
+		!isObfuscated(result.ObfuscatedPort) {
+		t.Errorf("bad obfuscation of %#v: %#v", parsed, result)
+	}
+
+	already := Node{ObfuscatedNode: "_foo", ObfuscatedPort: "_bar"}
+	if result := obf1.Node(already); !reflect.DeepEqual(result, already) {
+		t.Errorf("bad obfuscation of %#v: %#v", already, result)
+	}
+}
+
+func ExampleXForwarded() {
+	header := http.Header{
+		"X-Forwarded-For":   {"203.0.113.195, 2001:db8:85a3::8a2e:370:7334"},
+		"X-Forwarded-Proto": {"https"},
+	}
+	SetForwarded(header, XForwarded(header))
+	fmt.Println(header.Get("Forwarded"))
+	// Output: for=203.0.113.195;proto=https, for="[2001:db8:85a3::8a2e:370:7334]"
+}
+
+func TestXForwarded(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		result []ForwardedElem
+	}{
+		// Valid headers.
+		{
+			http.Header{"X-Forwarded-For": {"192.0.2.43, 2001:db8:cafe::17"}},
+			[]ForwardedElem{
+				{For: Node{IP: net.IPv4(192, 0, 2, 43)}},
+				{For: Node{IP: mustParseIP("2001:db8:cafe::17")}},
+			},
+		},
+		{
+			http.Header{
+				"X-Forwarded-For":   {"192.0.2.43", "198.51.100.17"},
+				"X-Forwarded-Proto": {"HTTPS"},
+				"X-Forwarded-Host":  {"example.com"},
+				"X-Forwarded-Port":  {"443"},
+			},
+			[]ForwardedElem{
+				{
+					For:   Node{IP: net.IPv4(192, 0, 2, 43)},
+					By:    Node{Port: 443},
+					Host:  "example.com",
+					Proto: "https",
+				},
+				{For: Node{IP: net.IPv4(198, 51, 100, 17)}},
+			},
+		},
+		{
+			http.Header{
+				"X-Forwarded-For":   {"192.0.2.43, 198.51.100.17"},
+				"X-Forwarded-Proto": {"https, http"},
+			},
+			[]ForwardedElem{
+				{For: Node{IP: net.IPv4(192, 0, 2, 43)}, Proto: "https"},
+				{For: Node{IP: net.IPv4(198, 51, 100, 17)}, Proto: "http"},
+			},
+		},
+		{
+			http.Header{"X-Forwarded-For": {
+				"[2001:db8:cafe::17]:4711, 192.0.2.43:80, unknown, _hidden",
+			}},
+			[]ForwardedElem{
+				{For: Node{IP: mustParseIP("2001:db8:cafe::17"), Port: 4711}},
+				{For: Node{IP: net.IPv4(192, 0, 2, 43), Port: 80}},
+				{},
+				{For: Node{ObfuscatedNode: "_hidden"}},
+			},
+		},
+		{
+			http.Header{"X-Forwarded-Proto": {"https"}},
+			[]ForwardedElem{{Proto: "https"}},
+		},
+		{
+			http.Header{"Forwarded": {"for=192.0.2.43"}},
+			nil,
+		},
+
+		// Invalid headers.
+		// Precise outputs on them are not a guaranteed part of the API.
+		// They may change as convenient for the parsing code.
+		{
+			http.Header{"X-Forwarded-For": {""}},
+			nil,
+		},
+		{
+			http.Header{
+				"X-Forwarded-For":  {"192.0.2.43,,;, 198.51.100.17"},
+				"X-Forwarded-Port": {"https, http, ftp"},
+			},
+			[]ForwardedElem{
+				{For: Node{IP: net.IPv4(192, 0, 2, 43)}},
+				{For: Node{IP: net.IPv4(198, 51, 100, 17)}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			checkParse(t, test.header, test.result, XForwarded(test.header))
+		})
+	}
+}
+
+func TestSetXForwarded(t *testing.T) {
+	tests := []struct {
+		input  []ForwardedElem
+		result http.Header
+	}{
+		{
+			[]ForwardedElem{},
+			http.Header{},
+		},
+		{
+			[]ForwardedElem{
+				{
+					For:   Node{IP: mustParseIP("2001:db8:cafe::17"), Port: 4711},
+					By:    Node{IP: net.IPv4(192, 0, 2, 1), Port: 443},
+					Host:  "example.com",
+					Proto: "https",
+				},
+				{For: Node{ObfuscatedNode: "_hidden"}, Proto: "http"},
+				{Proto: "http"},
+			},
+			http.Header{
+				"X-Forwarded-For":   {"[2001:db8:cafe::17]:4711, _hidden, unknown"},
+				"X-Forwarded-Proto": {"https, http, http"},
+				"X-Forwarded-Host":  {"example.com"},
+				"X-Forwarded-Port":  {"443"},
+			},
+		},
+		{
+			[]ForwardedElem{
+				{For: Node{IP: net.IPv4(192, 0, 2, 43)}},
+				{For: Node{IP: net.IPv4(198, 51, 100, 17)}, Host: "example.com"},
+			},
+			http.Header{
+				"X-Forwarded-For":  {"192.0.2.43, 198.51.100.17"},
+				"X-Forwarded-Host": {"example.com"},
+			},
+		},
+		{
+			// Values that would be split into several items are not sent.
+			[]ForwardedElem{
+				{
+					For:   Node{ObfuscatedNode: "_a, 1.2.3.4", ObfuscatedPort: "_b, 5"},
+					Host:  "example.com, evil.example",
+					Proto: "https",
+				},
+				{For: Node{ObfuscatedNode: "_c", ObfuscatedPort: "_d"}, Proto: "http"},
+			},
+			http.Header{
+				"X-Forwarded-For":   {"unknown, _c:_d"},
+				"X-Forwarded-Proto": {"https, http"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			header := http.Header{
+				"X-Forwarded-Host": {"stale.example"},
+			}
+			SetXForwarded(header, test.input)
+			checkGenerate(t, test.input, test.result, header)
+		})
+	}
+}
+
+func TestXForwardedToForwarded(t *testing.T) {
+	header := http.Header{
+		"X-Forwarded-For":   {"192.0.2.43, 198.51.100.17"},
+		"X-Forwarded-Proto": {"https"},
+		"X-Forwarded-Host":  {"example.com"},
+		"X-Forwarded-Port":  {"443"},
+	}
+	SetForwarded(header, XForwarded(header))
+	expected := `for=192.0.2.43;by="unknown:443";host=example.com;proto=https, for=198.51.100.17`
+	if actual := header.Get("Forwarded"); actual != expected {
+		t.Errorf("expected: %s\nactual:   %s", expected, actual)
+	}
+}
+
+func TestXForwardedFuzz(t *testing.T) {
+	checkFuzz(t, "X-Forwarded-For", XForwarded, SetXForwarded)
+}
+
+func TestXForwardedRoundTrip(t *testing.T) {
+	checkRoundTrip(t, SetXForwarded, XForwarded,
+		[]ForwardedElem{
+			{
+				For: Node{
+					IP:             net.IP{},
+					ObfuscatedPort: "_obfID | empty",
+				},
+				By:    Node{Port: 9999},
+				Host:  "token",
+				Proto: "lower token",
+			},
+		},
+	)
+}
+
 func mustParseIP(s string) net.IP {
 	ip := net.ParseIP(s)
 	if ip == nil {
@@ -2,7 +2,10 @@ package httpheader
 
 import (
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // A Pref contains a preference's value and any associated parameters (RFC 7240).
@@ -14,16 +17,27 @@ type Pref struct {
 // Prefer parses the Prefer header from h (RFC 7240 with errata),
 // returning a map where keys are preference names.
 func Prefer(h http.Header) map[string]Pref {
-	values := h["Prefer"]
+	return DefaultLimits().Prefer(h)
+}
+
+// Prefer is like the Prefer function, but applies l instead of DefaultLimits.
+func (l Limits) Prefer(h http.Header) map[string]Pref {
+	values := l.values(h["Prefer"])
 	if values == nil {
 		return nil
 	}
 	r := make(map[string]Pref)
 	for v, vs := iterElems("", values); v != ""; v, vs = iterElems(v, vs) {
+		if l.elemsDone(len(r)) {
+			break
+		}
 		var name string
 		var pref Pref
 		name, pref.Value, v = consumeParam(v)
-		pref.Params, v = consumeParams(v)
+		if name == "" {
+			continue
+		}
+		pref.Params, v = l.consumeParams(v)
 		// RFC 7240 page 5: ``If any preference is specified more than once,
 		// only the first instance is to be considered.''
 		if _, seen := r[name]; seen {
@@ -35,6 +49,20 @@ func Prefer(h http.Header) map[string]Pref {
 	return r
 }
 
+// StrictPrefer is like Prefer, but calls Check first.
+func StrictPrefer(h http.Header) (map[string]Pref, error) {
+	return DefaultLimits().StrictPrefer(h)
+}
+
+// StrictPrefer is like the StrictPrefer function, but applies l instead of
+// DefaultLimits.
+func (l Limits) StrictPrefer(h http.Header) (map[string]Pref, error) {
+	if err := l.Check(h, "Prefer"); err != nil {
+		return nil, err
+	}
+	return l.Prefer(h), nil
+}
+
 // SetPrefer replaces the Prefer header in h (RFC 7240 with errata).
 func SetPrefer(h http.Header, prefs map[string]Pref) {
 	if len(prefs) == 0 {
@@ -43,7 +71,13 @@ func SetPrefer(h http.Header, prefs map[string]Pref) {
 	}
 	b := &strings.Builder{}
 	var wrote bool
-	for name, pref := range prefs {
+	names := make([]string, 0, len(prefs))
+	for name := range prefs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pref := prefs[name]
 		wrote = writeDirective(b, wrote, name, pref.Value)
 		writeNullableParams(b, pref.Params)
 	}
@@ -53,14 +87,26 @@ func SetPrefer(h http.Header, prefs map[string]Pref) {
 // PreferenceApplied parses the Preference-Applied header from h (RFC 7240
 // with errata), returning a map where keys are preference names.
 func PreferenceApplied(h http.Header) map[string]string {
-	values := h["Preference-Applied"]
+	return DefaultLimits().PreferenceApplied(h)
+}
+
+// PreferenceApplied is like the PreferenceApplied function, but applies l
+// instead of DefaultLimits.
+func (l Limits) PreferenceApplied(h http.Header) map[string]string {
+	values := l.values(h["Preference-Applied"])
 	if values == nil {
 		return nil
 	}
 	r := make(map[string]string)
 	for v, vs := iterElems("", values); v != ""; v, vs = iterElems(v, vs) {
+		if l.elemsDone(len(r)) {
+			break
+		}
 		var name, value string
 		name, value, v = consumeParam(v)
+		if name == "" {
+			continue
+		}
 		if _, seen := r[name]; seen {
 			continue
 		}
@@ -70,6 +116,20 @@ func PreferenceApplied(h http.Header) map[string]string {
 	return r
 }
 
+// StrictPreferenceApplied is like PreferenceApplied, but calls Check first.
+func StrictPreferenceApplied(h http.Header) (map[string]string, error) {
+	return DefaultLimits().StrictPreferenceApplied(h)
+}
+
+// StrictPreferenceApplied is like the StrictPreferenceApplied function, but
+// applies l instead of DefaultLimits.
+func (l Limits) StrictPreferenceApplied(h http.Header) (map[string]string, error) {
+	if err := l.Check(h, "Preference-Applied"); err != nil {
+		return nil, err
+	}
+	return l.PreferenceApplied(h), nil
+}
+
 // SetPreferenceApplied replaces the Preference-Applied header in h.
 func SetPreferenceApplied(h http.Header, prefs map[string]string) {
 	if len(prefs) == 0 {
@@ -78,12 +138,81 @@ func SetPreferenceApplied(h http.Header, prefs map[string]string) {
 	}
 	b := &strings.Builder{}
 	var wrote bool
-	for name, value := range prefs {
-		wrote = writeDirective(b, wrote, name, value)
+	for _, name := range sortedKeys(prefs) {
+		wrote = writeDirective(b, wrote, name, prefs[name])
 	}
 	h.Set("Preference-Applied", b.String())
 }
 
+// PreferReturn returns the value of the return preference in prefs
+// (RFC 7240 Section 4.2), which is "minimal" or "representation",
+// or an empty string if it is missing or invalid.
+func PreferReturn(prefs map[string]Pref) string {
+	switch v := prefs["return"].Value; v {
+	case "minimal", "representation":
+		return v
+	default:
+		return ""
+	}
+}
+
+// PreferRespondAsync returns true if prefs contain the respond-async
+// preference (RFC 7240 Section 4.1).
+func PreferRespondAsync(prefs map[string]Pref) bool {
+	_, ok := prefs["respond-async"]
+	return ok
+}
+
+// PreferWait returns the duration of the wait preference in prefs
+// (RFC 7240 Section 4.3). If it is missing or invalid, ok is false.
+// See also WaitContext.
+func PreferWait(prefs map[string]Pref) (wait time.Duration, ok bool) {
+	pref, ok := prefs["wait"]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseUint(pref.Value, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// PreferHandling returns the value of the handling preference in prefs
+// (RFC 7240 Section 4.4), which is "strict" or "lenient", or an empty string
+// if it is missing or invalid.
+func PreferHandling(prefs map[string]Pref) string {
+	switch v := prefs["handling"].Value; v {
+	case "strict", "lenient":
+		return v
+	default:
+		return ""
+	}
+}
+
+// PreferODataMaxPageSize returns the value of the odata.maxpagesize preference
+// in prefs (OData Version 4.01 Part 1 Section 8.2.8.3), which is a positive
+// integer. If it is missing or invalid, ok is false.
+func PreferODataMaxPageSize(prefs map[string]Pref) (size int, ok bool) {
+	pref, ok := prefs["odata.maxpagesize"]
+	if !ok {
+		return 0, false
+	}
+	size, err := strconv.Atoi(pref.Value)
+	if err != nil || size <= 0 {
+		return 0, false
+	}
+	return size, true
+}
+
+// PreferODataTrackChanges returns true if prefs contain the
+// odata.track-changes preference (OData Version 4.01 Part 1
+// Section 8.2.8.8).
+func PreferODataTrackChanges(prefs map[string]Pref) bool {
+	_, ok := prefs["odata.track-changes"]
+	return ok
+}
+
 func canonicalPref(name, value string) string {
 	switch name {
 	case "handling", "return":
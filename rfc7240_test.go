@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func ExamplePrefer() {
@@ -126,7 +127,7 @@ func TestPrefer(t *testing.T) {
 		{
 			// Whitespace around '=' is not allowed by RFC 7240 errata 4439.
 			// But we still parse it in consumeParam because it is
-			// allowed elsewhere (e.g. in RFC 7230 transfer-extension).
+			// allowed elsewhere (e.g. in RFC 9110 transfer-coding).
 			http.Header{"Prefer": {"foo = bar"}},
 			map[string]Pref{"foo": {"bar", nil}},
 		},
@@ -147,7 +148,7 @@ func TestPrefer(t *testing.T) {
 		},
 		{
 			http.Header{"Prefer": {";;;, foo=yes"}},
-			map[string]Pref{"": {"", nil}, "foo": {"yes", nil}},
+			map[string]Pref{"foo": {"yes", nil}},
 		},
 		{
 			http.Header{"Prefer": {"foo=bar=baz"}},
@@ -172,7 +173,8 @@ func ExampleSetPrefer() {
 		"respond-async":  {},
 		"check-spelling": {Params: map[string]string{"lang": "en"}},
 	})
-	// Output:
+	fmt.Println(header.Get("Prefer"))
+	// Output: check-spelling;lang=en, respond-async, wait=10
 }
 
 func TestSetPrefer(t *testing.T) {
@@ -230,6 +232,14 @@ func TestSetPrefer(t *testing.T) {
 			},
 			http.Header{"Prefer": {`foo;qux="\""`}},
 		},
+		{
+			map[string]Pref{
+				"wait":   {"10", nil},
+				"return": {"minimal", map[string]string{"z": "", "a": "1"}},
+				"foo":    {},
+			},
+			http.Header{"Prefer": {"foo, return=minimal;a=1;z, wait=10"}},
+		},
 	}
 	for _, test := range tests {
 		t.Run("", func(t *testing.T) {
@@ -255,6 +265,87 @@ func TestPreferRoundTrip(t *testing.T) {
 	)
 }
 
+func TestPreferAccessors(t *testing.T) {
+	type result struct {
+		Return       string
+		RespondAsync bool
+		Wait         time.Duration
+		WaitOK       bool
+		Handling     string
+		MaxPageSize  int
+		MaxPageOK    bool
+		TrackChanges bool
+	}
+	tests := []struct {
+		header http.Header
+		result result
+	}{
+		{
+			http.Header{},
+			result{},
+		},
+		{
+			http.Header{"Prefer": {"return=Minimal, respond-async, wait=30"}},
+			result{
+				Return:       "minimal",
+				RespondAsync: true,
+				Wait:         30 * time.Second,
+				WaitOK:       true,
+			},
+		},
+		{
+			http.Header{"Prefer": {
+				`return="representation", handling=STRICT, wait=0`,
+				"odata.maxpagesize=50, odata.track-changes",
+			}},
+			result{
+				Return:       "representation",
+				Wait:         0,
+				WaitOK:       true,
+				Handling:     "strict",
+				MaxPageSize:  50,
+				MaxPageOK:    true,
+				TrackChanges: true,
+			},
+		},
+		{
+			http.Header{"Prefer": {"handling=lenient; foo=bar, Respond-Async=true"}},
+			result{RespondAsync: true, Handling: "lenient"},
+		},
+
+		// Invalid values.
+		{
+			http.Header{"Prefer": {
+				"return=full, wait=-5, handling=loose, odata.maxpagesize=0",
+			}},
+			result{},
+		},
+		{
+			http.Header{"Prefer": {
+				"return, wait=1.5, handling, odata.maxpagesize=many",
+			}},
+			result{},
+		},
+		{
+			http.Header{"Prefer": {"wait=99999999999999999999"}},
+			result{},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			prefs := Prefer(test.header)
+			var actual result
+			actual.Return = PreferReturn(prefs)
+			actual.RespondAsync = PreferRespondAsync(prefs)
+			actual.Wait, actual.WaitOK = PreferWait(prefs)
+			actual.Handling = PreferHandling(prefs)
+			actual.MaxPageSize, actual.MaxPageOK = PreferODataMaxPageSize(prefs)
+			actual.TrackChanges = PreferODataTrackChanges(prefs)
+			checkParse(t, test.header, test.result, actual)
+		})
+	}
+}
+
 func BenchmarkPreferSimple(b *testing.B) {
 	header := http.Header{"Prefer": {"wait=10, respond-async"}}
 	for i := 0; i < b.N; i++ {
@@ -3,6 +3,7 @@ package httpheader
 import (
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
@@ -10,19 +11,21 @@ import (
 // Standard target attributes are stored in the corresponding fields;
 // any extension attributes are stored in Ext.
 type LinkElem struct {
-	Anchor   *url.URL // usually nil
-	Rel      string
-	Target   *url.URL // always non-nil
-	Title    string
-	Type     string
-	HrefLang []string
-	Media    string
-	Ext      map[string]string
+	Anchor    *url.URL // usually nil
+	Rel       string
+	Target    *url.URL // always non-nil
+	Title     string
+	TitleLang string // language of Title, if from 'title*'
+	Type      string
+	HrefLang  []string
+	Media     string
+	Ext       map[string]string
 }
 
 // Link parses the Link header from h (RFC 8288), resolving any relative Target
 // and Anchor URLs against base, which is the URL that h was obtained from
-// (http.Response's Request.URL).
+// (http.Response's Request.URL). If base is nil, relative URLs are left
+// unresolved.
 //
 // In general, callers should check the Anchor of each returned LinkElem:
 // a non-nil Anchor indicates a link pointing "from" another context
@@ -34,15 +37,48 @@ type LinkElem struct {
 //
 // When the header contains multiple relation types in one value,
 // like rel="next prefetch", multiple LinkElems with different Rel are returned.
+// Relation types are lowercased, except extension relation types
+// that are absolute URIs (RFC 8288 Section 2.1.2), which are kept intact.
 // Any 'rev' parameter is discarded.
 func Link(h http.Header, base *url.URL) []LinkElem {
-	values := h["Link"]
+	return DefaultLimits().Link(h, base)
+}
+
+// Link is like the Link function, but applies l instead of DefaultLimits.
+func (l Limits) Link(h http.Header, base *url.URL) []LinkElem {
+	links, _ := parseLinks(l.values(h["Link"]), base, "", l)
+	return links
+}
+
+// StrictLink is like Link, but calls Check first.
+func StrictLink(h http.Header, base *url.URL) ([]LinkElem, error) {
+	return DefaultLimits().StrictLink(h, base)
+}
+
+// StrictLink is like the StrictLink function, but applies l instead of
+// DefaultLimits.
+func (l Limits) StrictLink(h http.Header, base *url.URL) ([]LinkElem, error) {
+	if err := l.Check(h, "Link"); err != nil {
+		return nil, err
+	}
+	return l.Link(h, base), nil
+}
+
+// parseLinks parses Link header values. Links without rel are discarded
+// unless defaultRel is non-empty, in which case it is used instead.
+// At most limits.MaxElems links are returned; if any more are discarded,
+// truncated is true.
+func parseLinks(values []string, base *url.URL, defaultRel string, limits Limits) (links []LinkElem, truncated bool) {
 	if values == nil {
-		return nil
+		return nil, false
 	}
-	links := make([]LinkElem, 0, estimateElems(values))
+	links = make([]LinkElem, 0, estimateElems(values))
 LinksLoop:
 	for v, vs := iterElems("", values); v != ""; v, vs = iterElems(v, vs) {
+		if limits.elemsDone(len(links)) {
+			truncated = true
+			break
+		}
 		var link LinkElem
 		var rawTarget string
 		var err error
@@ -54,10 +90,16 @@ LinksLoop:
 		if err != nil {
 			continue
 		}
-		link.Target = base.ResolveReference(link.Target)
+		if base != nil {
+			link.Target = base.ResolveReference(link.Target)
+		}
 
 		// RFC 8288 requires us to ignore duplicates of certain parameters.
 		var seenRel, seenMedia, seenTitle, seenTitleStar, seenType bool
+		// Only hreflang and extension attributes count toward MaxParams,
+		// so that the limit cannot hide an anchor, which changes
+		// the meaning of a link.
+		n := 0
 	ParamsLoop:
 		for {
 			var name, value string
@@ -73,13 +115,15 @@ LinksLoop:
 					// better not ignore it.
 					continue LinksLoop
 				}
-				link.Anchor = base.ResolveReference(link.Anchor)
+				if base != nil {
+					link.Anchor = base.ResolveReference(link.Anchor)
+				}
 
 			case "rel":
 				if seenRel {
 					continue
 				}
-				link.Rel = strings.ToLower(value)
+				link.Rel = value
 				seenRel = true
 
 			case "rev":
@@ -101,8 +145,11 @@ LinksLoop:
 				if seenTitleStar {
 					continue
 				}
-				if decoded, _, err := DecodeExtValue(value); err == nil {
+				if decoded, lang, err := DecodeExtValue(value); err == nil {
 					link.Title = decoded
+					if isLangTag(lang) {
+						link.TitleLang = strings.ToLower(lang)
+					}
 				}
 				seenTitleStar = true
 
@@ -114,7 +161,11 @@ LinksLoop:
 				seenType = true
 
 			case "hreflang":
+				if limits.paramsDone(n) {
+					continue
+				}
 				link.HrefLang = append(link.HrefLang, strings.ToLower(value))
+				n++
 
 			case "media":
 				if seenMedia {
@@ -124,19 +175,30 @@ LinksLoop:
 				seenMedia = true
 
 			default: // extension attributes
+				if limits.paramsDone(n) {
+					continue
+				}
 				link.Ext = insertVariform(link.Ext, name, value)
+				n++
 			}
 		}
 
 		// "Explode" into one LinkElem for each relation type. This has the side
 		// effect of discarding any value with empty or missing rel, which is
 		// probably a good idea anyway. "The rel parameter MUST be present".
+		if !seenRel {
+			link.Rel = defaultRel
+		}
 		for _, relType := range strings.Fields(link.Rel) {
+			if limits.elemsDone(len(links)) {
+				truncated = true
+				break
+			}
 			links = append(links, link)
-			links[len(links)-1].Rel = relType
+			links[len(links)-1].Rel = normalizeRel(relType)
 		}
 	}
-	return links
+	return links, truncated
 }
 
 // SetLink replaces the Link header in h. See also AddLink.
@@ -144,10 +206,13 @@ LinksLoop:
 // The Title of each LinkElem, if non-empty, is serialized into a 'title'
 // parameter in quoted-string form, or a 'title*' parameter in RFC 8187 encoding,
 // or both, depending on what characters it contains. Title should be valid UTF-8.
+// If TitleLang is a language tag (RFC 5646), only 'title*' is sent, tagged
+// with that language; otherwise TitleLang is ignored.
 //
 // Similarly, if Ext contains a 'qux' or 'qux*' key, it will be serialized into
 // a 'qux' and/or 'qux*' parameter depending on its contents; the asterisk
-// in the key is ignored.
+// in the key is ignored. An empty value of a key that is known to be a flag,
+// such as 'nopush', is serialized as a parameter without a value.
 //
 // Any members of Ext named like corresponding fields of LinkElem,
 // such as 'title*' or 'hreflang', are skipped.
@@ -180,11 +245,16 @@ func buildLink(links []LinkElem) string {
 		// "The rel parameter MUST be present".
 		write(b, "; rel=")
 		writeTokenOrQuoted(b, link.Rel)
-		if link.Title != "" {
+		switch {
+		case isLangTag(link.TitleLang):
+			write(b, "; title*=")
+			writeExtValue(b, link.Title, link.TitleLang)
+		case link.Title != "":
 			writeVariform(b, "title", link.Title)
 		}
 		if link.Type != "" {
-			write(b, `; type="`, link.Type, `"`)
+			write(b, "; type=")
+			writeQuoted(b, link.Type)
 		}
 		for _, lang := range link.HrefLang {
 			write(b, "; hreflang=", lang)
@@ -193,15 +263,136 @@ func buildLink(links []LinkElem) string {
 			write(b, "; media=")
 			writeTokenOrQuoted(b, link.Media)
 		}
-		for name, value := range link.Ext {
+		for _, name := range sortedKeys(link.Ext) {
+			value := link.Ext[name]
 			switch strings.ToLower(name) {
 			case "anchor", "rel", "title", "title*", "type", "hreflang", "media":
 				continue
 			default:
 				name = strings.TrimSuffix(name, "*")
+				if value == "" && flagLinkAttrs[strings.ToLower(name)] {
+					write(b, "; ", name)
+					continue
+				}
 				writeVariform(b, name, value)
 			}
 		}
 	}
 	return b.String()
 }
+
+// flagLinkAttrs are target attributes that are meaningful without a value,
+// and that some recipients may not recognize with an empty one.
+var flagLinkAttrs = map[string]bool{
+	"nopush": true,
+}
+
+//go:generate go run gen_linkrels.go
+
+// RegisteredRel returns true if rel is a relation type registered
+// in the IANA Link Relation Types registry (RFC 8288 Section 2.1.1),
+// such as "next" or "preload", compared case-insensitively.
+func RegisteredRel(rel string) bool {
+	return registeredRels[strings.ToLower(rel)]
+}
+
+// ValidRel returns true if rel is a valid relation type: either registered
+// (see RegisteredRel), or an extension relation type, which must be
+// an absolute URI (RFC 8288 Section 2.1.2). This is useful for catching
+// typos like "nxt".
+func ValidRel(rel string) bool {
+	return RegisteredRel(rel) || isAbsoluteURI(rel)
+}
+
+func isAbsoluteURI(s string) bool {
+	if !strings.Contains(s, ":") {
+		return false
+	}
+	u, err := url.Parse(s)
+	return err == nil && u.IsAbs()
+}
+
+// normalizeRel lowercases rel unless it is an extension relation type,
+// whose case may be significant.
+func normalizeRel(rel string) string {
+	if isAbsoluteURI(rel) {
+		return rel
+	}
+	return strings.ToLower(rel)
+}
+
+// LinkByRel returns the first of links that has the given relation type
+// (compared case-insensitively) and whose context is base, the URL that
+// links were obtained from (as passed to Link). Links with some other Anchor
+// are skipped because they describe a relation of another resource, not of
+// the one that supplied the Link header (RFC 8288 Section 3.2); an Anchor that
+// resolves to base itself, such as anchor="", is the same as none. If no such
+// link exists, ok is false.
+func LinkByRel(links []LinkElem, base *url.URL, rel string) (link LinkElem, ok bool) {
+	var context string
+	if base != nil {
+		context = base.String()
+	}
+	for _, link := range links {
+		if link.Anchor != nil && link.Anchor.String() != context {
+			continue
+		}
+		if strings.EqualFold(link.Rel, rel) {
+			return link, true
+		}
+	}
+	return LinkElem{}, false
+}
+
+// PageLinks returns links with relation types first, prev, next and last
+// for navigating the given page of a paginated collection, where pages
+// are numbered from 1 to last. The Target of each link is a copy of base
+// with the query parameter named param set to the page number. If base is nil,
+// the Target is a relative reference such as "?page=2". The first
+// and prev links are omitted on the first page, and next and last are omitted
+// on the last page. If last is 0, meaning the number of pages is unknown,
+// the last link is always omitted and the next link is always included.
+// If page is less than 1, last is negative, or page is greater than a non-zero
+// last, PageLinks returns nil.
+//
+// The returned links can be passed to SetLink or AddLink.
+func PageLinks(base *url.URL, param string, page, last int) []LinkElem {
+	if page < 1 || last < 0 || last != 0 && page > last {
+		return nil
+	}
+	pageLink := func(rel string, n int) LinkElem {
+		var target url.URL
+		if base != nil {
+			target = *base
+		}
+		query := target.Query()
+		query.Set(param, strconv.Itoa(n))
+		target.RawQuery = query.Encode()
+		return LinkElem{Rel: rel, Target: &target}
+	}
+	var links []LinkElem
+	if page > 1 {
+		links = append(links, pageLink("first", 1), pageLink("prev", page-1))
+	}
+	if last == 0 || page < last {
+		links = append(links, pageLink("next", page+1))
+	}
+	if last != 0 && page < last {
+		links = append(links, pageLink("last", last))
+	}
+	return links
+}
+
+// isLangTag reports whether s looks like a language tag (RFC 5646),
+// which is all that is needed to write it safely into an ext-value.
+func isLangTag(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isAlpha(s[i]) && !isDigit(s[i]) && s[i] != '-' {
+			return false
+		}
+	}
+	return true
+}
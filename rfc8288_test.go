@@ -75,6 +75,13 @@ func TestLink(t *testing.T) {
 			http.Header{"Link": {`<urn:whatever:123>;rel="urn:whatever:456"`}},
 			[]LinkElem{{Rel: "urn:whatever:456", Target: U("urn:whatever:123")}},
 		},
+		{
+			http.Header{"Link": {`<b>; rel="Next https://example.com/Rels/Thing"`}},
+			[]LinkElem{
+				{Rel: "next", Target: U("http://x.test/b")},
+				{Rel: "https://example.com/Rels/Thing", Target: U("http://x.test/b")},
+			},
+		},
 		{
 			http.Header{"Link": {`<b>; rel="next prefetch"; hreflang=en; extra`}},
 			[]LinkElem{
@@ -120,9 +127,10 @@ func TestLink(t *testing.T) {
 			http.Header{"Link": {`</> ; rel = "https://vocab.example/memberOf"`}},
 			[]LinkElem{
 				{
-					// "When extension relation types are compared, they MUST be
-					// compared as strings [...] in a case-insensitive fashion"
-					Rel:    "https://vocab.example/memberof",
+					// An extension relation type is a URI, whose path may be
+					// case-sensitive, so it is kept intact. RFC 8288 Section 2.1.2
+					// compares them case-insensitively, and so does LinkByRel.
+					Rel:    "https://vocab.example/memberOf",
 					Target: U("http://x.test/"),
 				},
 			},
@@ -244,14 +252,16 @@ func TestLink(t *testing.T) {
 			}},
 			[]LinkElem{
 				{
-					Rel:    "previous",
-					Target: U("http://x.test/TheBook/chapter2"),
-					Title:  "letztes Kapitel",
+					Rel:       "previous",
+					Target:    U("http://x.test/TheBook/chapter2"),
+					Title:     "letztes Kapitel",
+					TitleLang: "de",
 				},
 				{
-					Rel:    "next",
-					Target: U("http://x.test/TheBook/chapter4"),
-					Title:  "nächstes Kapitel",
+					Rel:       "next",
+					Target:    U("http://x.test/TheBook/chapter4"),
+					Title:     "nächstes Kapitel",
+					TitleLang: "de",
 				},
 			},
 		},
@@ -488,6 +498,14 @@ func TestSetLink(t *testing.T) {
 			[]LinkElem{{Rel: "next", Target: U("baz")}},
 			http.Header{"Link": {"<baz>; rel=next"}},
 		},
+		{
+			[]LinkElem{{Rel: "next", Target: U("baz"), Title: "Ján", TitleLang: "sk"}},
+			http.Header{"Link": {"<baz>; rel=next; title*=UTF-8'sk'J%C3%A1n"}},
+		},
+		{
+			[]LinkElem{{Rel: "preload", Target: U("baz"), Type: `0"0`}},
+			http.Header{"Link": {`<baz>; rel=preload; type="0\"0"`}},
+		},
 		{
 			[]LinkElem{
 				{
@@ -614,13 +632,14 @@ func TestLinkFuzz(t *testing.T) {
 func TestLinkRoundTrip(t *testing.T) {
 	checkRoundTrip(t, SetLink, baseLink,
 		[]LinkElem{{
-			Anchor:   &url.URL{},
-			Rel:      "lower token | lower URL",
-			Target:   &url.URL{},
-			Title:    "token | quotable | UTF-8 | empty",
-			Type:     "lower token/token | empty",
-			HrefLang: []string{"lower token"},
-			Media:    "token | empty",
+			Anchor:    &url.URL{},
+			Rel:       "lower token | lower URL",
+			Target:    &url.URL{},
+			Title:     "token | quotable | UTF-8 | empty",
+			TitleLang: "lower language | empty",
+			Type:      "lower token/token | empty",
+			HrefLang:  []string{"lower token"},
+			Media:     "token | empty",
 			Ext: map[string]string{
 				"lower token without *": "token | quotable | UTF-8 | empty",
 			},
@@ -628,6 +647,150 @@ func TestLinkRoundTrip(t *testing.T) {
 	)
 }
 
+func ExampleLinkByRel() {
+	base, _ := url.Parse("https://api.example/items?page=2")
+	header := http.Header{"Link": {
+		`</items?page=3>; rel=next, </about>; rel=next; anchor="/items/1"`,
+	}}
+	if next, ok := LinkByRel(Link(header, base), base, "next"); ok {
+		fmt.Println(next.Target)
+	}
+	// Output: https://api.example/items?page=3
+}
+
+func TestLinkByRel(t *testing.T) {
+	base := U("http://x.test/items?page=2")
+	links := []LinkElem{
+		{Rel: "next", Target: U("http://x.test/about"), Anchor: U("http://x.test/items/1")},
+		{Rel: "next", Target: U("http://x.test/items?page=3")},
+		{Rel: "prev", Target: U("http://x.test/items?page=1"), Anchor: U("http://x.test/items?page=2")},
+		{Rel: "next", Target: U("http://x.test/items?cursor=xyz")},
+	}
+	tests := []struct {
+		base *url.URL
+		rel  string
+		link LinkElem
+		ok   bool
+	}{
+		{base, "next", links[1], true},
+		{base, "Prev", links[2], true},
+		{base, "last", LinkElem{}, false},
+		{U("http://x.test/items/1"), "next", links[0], true},
+		{nil, "prev", LinkElem{}, false},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			link, ok := LinkByRel(links, test.base, test.rel)
+			checkParse(t, nil, test.link, link, test.ok, ok)
+		})
+	}
+}
+
+func ExampleValidRel() {
+	header := http.Header{"Link": {`</items?page=2>; rel="nxt"`}}
+	for _, link := range Link(header, &url.URL{Path: "/items"}) {
+		if !ValidRel(link.Rel) {
+			fmt.Printf("unknown relation type %q\n", link.Rel)
+		}
+	}
+	// Output: unknown relation type "nxt"
+}
+
+func TestValidRel(t *testing.T) {
+	tests := []struct {
+		rel        string
+		registered bool
+		valid      bool
+	}{
+		{"next", true, true},
+		{"Next", true, true},
+		{"dns-prefetch", true, true},
+		{"openid2.local_id", true, true},
+		{"intervalAfter", true, true},
+		{"nxt", false, false},
+		{"", false, false},
+		{"https://example.com/rels/thing", false, true},
+		{"urn:example:thing", false, true},
+		{"tag:example.com,2024:Thing", false, true},
+		{"/rels/thing", false, false},
+		{"example.com/rels/thing", false, false},
+		{"http://example.com/%zz", false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.rel, func(t *testing.T) {
+			registered, valid := RegisteredRel(test.rel), ValidRel(test.rel)
+			if registered != test.registered || valid != test.valid {
+				t.Errorf("got registered=%v valid=%v, expected %v %v",
+					registered, valid, test.registered, test.valid)
+			}
+		})
+	}
+}
+
+func ExamplePageLinks() {
+	header := http.Header{}
+	base, _ := url.Parse("https://api.example/items?sort=name")
+	SetLink(header, PageLinks(base, "page", 2, 5))
+	header.Write(os.Stdout)
+	// Output: Link: <https://api.example/items?page=1&sort=name>; rel=first, <https://api.example/items?page=1&sort=name>; rel=prev, <https://api.example/items?page=3&sort=name>; rel=next, <https://api.example/items?page=5&sort=name>; rel=last
+}
+
+func TestPageLinks(t *testing.T) {
+	base := U("http://x.test/items?page=7&q=foo#top")
+	tests := []struct {
+		page, last int
+		result     []LinkElem
+	}{
+		{
+			1, 1,
+			nil,
+		},
+		{
+			1, 3,
+			[]LinkElem{
+				{Rel: "next", Target: U("http://x.test/items?page=2&q=foo#top")},
+				{Rel: "last", Target: U("http://x.test/items?page=3&q=foo#top")},
+			},
+		},
+		{
+			3, 3,
+			[]LinkElem{
+				{Rel: "first", Target: U("http://x.test/items?page=1&q=foo#top")},
+				{Rel: "prev", Target: U("http://x.test/items?page=2&q=foo#top")},
+			},
+		},
+		{
+			4, 0,
+			[]LinkElem{
+				{Rel: "first", Target: U("http://x.test/items?page=1&q=foo#top")},
+				{Rel: "prev", Target: U("http://x.test/items?page=3&q=foo#top")},
+				{Rel: "next", Target: U("http://x.test/items?page=5&q=foo#top")},
+			},
+		},
+		{0, 3, nil},
+		{-1, 0, nil},
+		{4, 3, nil},
+		{1, -1, nil},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			links := PageLinks(base, "page", test.page, test.last)
+			checkParse(t, nil, test.result, links)
+		})
+	}
+	if base.String() != "http://x.test/items?page=7&q=foo#top" {
+		t.Errorf("base modified: %v", base)
+	}
+}
+
+func TestPageLinksNilBase(t *testing.T) {
+	links := PageLinks(nil, "page", 1, 2)
+	checkParse(t, nil, []LinkElem{
+		{Rel: "next", Target: U("?page=2")},
+		{Rel: "last", Target: U("?page=2")},
+	}, links)
+}
+
 const (
 	linkSimple  = `</chapter/4>; rel=next`
 	linkComplex = `</chapter/4>; rel="next prefetch", </chapter/2>; rel=prev, </chapter/preface>; rel=start; title="Preface to the Second Edition of the \"Grand Book of Protocols\"", <../>; rel=up, <https://example.com/help>; rel=help; title*=UTF-8'en'Reader%20help, </dark.css>; rel="alternate stylesheet"; type="text/css"; media=screen`
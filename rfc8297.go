@@ -0,0 +1,81 @@
+package httpheader
+
+import (
+	"net/url"
+	"strings"
+)
+
+// A Preload is a typed representation of a Link (RFC 8288) for resource hints
+// and preloading, as defined by the HTML and Resource Hints specifications.
+// Use its Link method to obtain a LinkElem for SetLink, AddLink or
+// WriteEarlyHints, and ParsePreload to go the other way.
+type Preload struct {
+	// Rel is "preload", "modulepreload", "preconnect", or "dns-prefetch".
+	Rel    string
+	Target *url.URL // must be non-nil, as in LinkElem
+
+	As            string // destination, such as "script" or "font"
+	Type          string // MIME type
+	CrossOrigin   string // "", "anonymous", or "use-credentials"
+	Integrity     string // subresource integrity metadata
+	FetchPriority string // "", "high", "low", or "auto"
+
+	// NoPush asks a server or CDN that supports HTTP/2 push
+	// not to push the resource based on this link.
+	NoPush bool
+}
+
+// Link returns a LinkElem representing p, with the extension attributes
+// stored in Ext. If p's Target is nil, so is the LinkElem's, which SetLink
+// and AddLink do not accept.
+func (p Preload) Link() LinkElem {
+	link := LinkElem{Rel: p.Rel, Target: p.Target, Type: p.Type}
+	ext := map[string]string{}
+	if p.As != "" {
+		ext["as"] = p.As
+	}
+	if p.CrossOrigin != "" {
+		ext["crossorigin"] = p.CrossOrigin
+	}
+	if p.Integrity != "" {
+		ext["integrity"] = p.Integrity
+	}
+	if p.FetchPriority != "" {
+		ext["fetchpriority"] = p.FetchPriority
+	}
+	if p.NoPush {
+		ext["nopush"] = ""
+	}
+	if len(ext) > 0 {
+		link.Ext = ext
+	}
+	return link
+}
+
+// ParsePreload converts link, as returned by Link, into a Preload.
+// If link's Rel is not one of the relation types supported by Preload,
+// ok is false. Known keywords, such as the values of as and crossorigin,
+// are lowercased; a crossorigin attribute without a value means "anonymous".
+func ParsePreload(link LinkElem) (p Preload, ok bool) {
+	switch link.Rel {
+	case "preload", "modulepreload", "preconnect", "dns-prefetch":
+	default:
+		return Preload{}, false
+	}
+	p = Preload{
+		Rel:           link.Rel,
+		Target:        link.Target,
+		Type:          link.Type,
+		As:            strings.ToLower(link.Ext["as"]),
+		Integrity:     link.Ext["integrity"],
+		FetchPriority: strings.ToLower(link.Ext["fetchpriority"]),
+	}
+	if crossOrigin, ok := link.Ext["crossorigin"]; ok {
+		p.CrossOrigin = strings.ToLower(crossOrigin)
+		if p.CrossOrigin == "" {
+			p.CrossOrigin = "anonymous"
+		}
+	}
+	_, p.NoPush = link.Ext["nopush"]
+	return p, true
+}
@@ -0,0 +1,27 @@
+//go:build go1.19
+// +build go1.19
+
+package httpheader
+
+import "net/http"
+
+// WriteEarlyHints sends a 103 (Early Hints) interim response (RFC 8297)
+// with the given preloads in the Link header. The Link header is added
+// to w.Header(), so it is also sent with the final response, as is typical;
+// any other headers already in w.Header() are sent with the interim response
+// as well. Preloads with a nil Target are skipped; if none are left,
+// nothing is sent.
+func WriteEarlyHints(w http.ResponseWriter, preloads ...Preload) {
+	links := make([]LinkElem, 0, len(preloads))
+	for _, p := range preloads {
+		if p.Target == nil {
+			continue
+		}
+		links = append(links, p.Link())
+	}
+	if len(links) == 0 {
+		return
+	}
+	AddLink(w.Header(), links...)
+	w.WriteHeader(http.StatusEarlyHints)
+}
@@ -0,0 +1,44 @@
+//go:build go1.19
+// +build go1.19
+
+package httpheader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteEarlyHints(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteEarlyHints(w,
+		Preload{Rel: "preload", Target: U("/app.js"), As: "script"},
+		Preload{Rel: "preconnect", Target: U("https://cdn.example")},
+	)
+	if w.Code != 103 {
+		t.Errorf("expected 103, got %d", w.Code)
+	}
+	expected := http.Header{"Link": {
+		`</app.js>; rel=preload; as=script, <https://cdn.example>; rel=preconnect`,
+	}}
+	checkGenerate(t, nil, expected, w.Header())
+}
+
+func TestWriteEarlyHintsNilTarget(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteEarlyHints(w,
+		Preload{Rel: "preload", As: "script"},
+		Preload{Rel: "preconnect", Target: U("https://cdn.example")},
+	)
+	if w.Code != 103 {
+		t.Errorf("expected 103, got %d", w.Code)
+	}
+	expected := http.Header{"Link": {`<https://cdn.example>; rel=preconnect`}}
+	checkGenerate(t, nil, expected, w.Header())
+
+	w = httptest.NewRecorder()
+	WriteEarlyHints(w, Preload{Rel: "preload", As: "script"})
+	if w.Code != 200 || len(w.Header()) != 0 {
+		t.Errorf("expected nothing sent, got %d %v", w.Code, w.Header())
+	}
+}
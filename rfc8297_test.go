@@ -0,0 +1,123 @@
+package httpheader
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func ExamplePreload() {
+	header := http.Header{}
+	SetLink(header, []LinkElem{
+		Preload{
+			Rel:    "preload",
+			Target: &url.URL{Path: "/fonts/inter.woff2"},
+			Type:   "font/woff2",
+			As:     "font",
+		}.Link(),
+	})
+	header.Write(os.Stdout)
+	// Output: Link: </fonts/inter.woff2>; rel=preload; type="font/woff2"; as=font
+}
+
+func TestPreloadLink(t *testing.T) {
+	tests := []struct {
+		input  Preload
+		result LinkElem
+	}{
+		{
+			Preload{Rel: "preconnect", Target: U("https://cdn.example")},
+			LinkElem{Rel: "preconnect", Target: U("https://cdn.example")},
+		},
+		{
+			Preload{
+				Rel:           "modulepreload",
+				Target:        U("/app.mjs"),
+				Type:          "text/javascript",
+				As:            "script",
+				CrossOrigin:   "use-credentials",
+				Integrity:     "sha384-oqVuAfXRKap7fdgcCY5uykM6+R9GqQ8K/uxy9rx7HNQlGYl1kPzQho1wx4JwY8wC",
+				FetchPriority: "high",
+				NoPush:        true,
+			},
+			LinkElem{
+				Rel:    "modulepreload",
+				Target: U("/app.mjs"),
+				Type:   "text/javascript",
+				Ext: map[string]string{
+					"as":            "script",
+					"crossorigin":   "use-credentials",
+					"integrity":     "sha384-oqVuAfXRKap7fdgcCY5uykM6+R9GqQ8K/uxy9rx7HNQlGYl1kPzQho1wx4JwY8wC",
+					"fetchpriority": "high",
+					"nopush":        "",
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			checkParse(t, nil, test.result, test.input.Link())
+		})
+	}
+}
+
+func TestParsePreload(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		result Preload
+		ok     bool
+	}{
+		{
+			http.Header{"Link": {`</style.css>; rel=preload; as=Style; nopush`}},
+			Preload{
+				Rel:    "preload",
+				Target: U("http://x.test/style.css"),
+				As:     "style",
+				NoPush: true,
+			},
+			true,
+		},
+		{
+			http.Header{"Link": {`<https://fonts.example>; rel=preconnect; crossorigin`}},
+			Preload{
+				Rel:         "preconnect",
+				Target:      U("https://fonts.example"),
+				CrossOrigin: "anonymous",
+			},
+			true,
+		},
+		{
+			http.Header{"Link": {`</next>; rel=next; as=document`}},
+			Preload{},
+			false,
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			p, ok := ParsePreload(baseLink(test.header)[0])
+			checkParse(t, test.header, test.result, p, test.ok, ok)
+		})
+	}
+}
+
+func TestSetLinkNoPush(t *testing.T) {
+	header := http.Header{}
+	SetLink(header, []LinkElem{
+		Preload{Rel: "preload", Target: U("/style.css"), As: "style", NoPush: true}.Link(),
+	})
+	expected := http.Header{"Link": {`</style.css>; rel=preload; as=style; nopush`}}
+	checkGenerate(t, nil, expected, header)
+	p, _ := ParsePreload(baseLink(header)[0])
+	checkParse(t, header, true, p.NoPush)
+}
+
+func TestPreloadNilTarget(t *testing.T) {
+	p := Preload{Rel: "preload", As: "script"}
+	if link := p.Link(); link.Target != nil {
+		t.Errorf("unexpected Target %v", link.Target)
+	}
+	if text, err := p.MarshalText(); err == nil {
+		t.Errorf("marshaling %#v: expected error, got %q", p, text)
+	}
+}
@@ -0,0 +1,115 @@
+package httpheader
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AcceptRanges parses the Accept-Ranges header from h (RFC 9110 Section 14.3),
+// returning the lowercased range units. The unit "none" means that
+// range requests are not supported.
+func AcceptRanges(h http.Header) []string {
+	return DefaultLimits().AcceptRanges(h)
+}
+
+// AcceptRanges is like the AcceptRanges function, but applies l instead of
+// DefaultLimits.
+func (l Limits) AcceptRanges(h http.Header) []string {
+	values := l.values(h["Accept-Ranges"])
+	if values == nil {
+		return nil
+	}
+	units := make([]string, 0, estimateElems(values))
+	for v, vs := iterElems("", values); v != ""; v, vs = iterElems(v, vs) {
+		if l.elemsDone(len(units)) {
+			break
+		}
+		var unit string
+		unit, v = consumeItem(v)
+		if unit == "" {
+			continue
+		}
+		units = append(units, strings.ToLower(unit))
+	}
+	return units
+}
+
+// StrictAcceptRanges is like AcceptRanges, but calls Check first.
+func StrictAcceptRanges(h http.Header) ([]string, error) {
+	return DefaultLimits().StrictAcceptRanges(h)
+}
+
+// StrictAcceptRanges is like the StrictAcceptRanges function, but applies l
+// instead of DefaultLimits.
+func (l Limits) StrictAcceptRanges(h http.Header) ([]string, error) {
+	if err := l.Check(h, "Accept-Ranges"); err != nil {
+		return nil, err
+	}
+	return l.AcceptRanges(h), nil
+}
+
+// SetAcceptRanges replaces the Accept-Ranges header in h.
+// To advertise that range requests are not supported, pass []string{"none"}.
+func SetAcceptRanges(h http.Header, units []string) {
+	if len(units) == 0 {
+		h.Del("Accept-Ranges")
+		return
+	}
+	h.Set("Accept-Ranges", strings.Join(units, ", "))
+}
+
+// ContentLocation parses the Content-Location header from h
+// (RFC 9110 Section 8.7). A relative reference is resolved against base,
+// which is the target URI of the request; if base is nil, it is returned
+// as is. If there is no such header in h, or it cannot be parsed,
+// ContentLocation returns nil.
+//
+// When it equals the target URI in a response to GET or HEAD, or in a 2xx
+// response to a state-changing method, the content is a representation
+// of that resource; otherwise, it only identifies where the content
+// can be found.
+func ContentLocation(h http.Header, base *url.URL) *url.URL {
+	return DefaultLimits().ContentLocation(h, base)
+}
+
+// ContentLocation is like the ContentLocation function, but applies l instead
+// of DefaultLimits.
+func (l Limits) ContentLocation(h http.Header, base *url.URL) *url.URL {
+	v := strings.TrimSpace(l.value(h.Get("Content-Location")))
+	if v == "" || strings.IndexByte(v, ' ') >= 0 { // not allowed in a URI
+		return nil
+	}
+	u, err := url.Parse(v)
+	if err != nil || u.String() == "" { // such as "#", which is as good as empty
+		return nil
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	return u
+}
+
+// StrictContentLocation is like ContentLocation, but calls Check first.
+func StrictContentLocation(h http.Header, base *url.URL) (*url.URL, error) {
+	return DefaultLimits().StrictContentLocation(h, base)
+}
+
+// StrictContentLocation is like the StrictContentLocation function, but
+// applies l instead of DefaultLimits.
+func (l Limits) StrictContentLocation(h http.Header, base *url.URL) (*url.URL, error) {
+	if err := l.Check(h, "Content-Location"); err != nil {
+		return nil, err
+	}
+	return l.ContentLocation(h, base), nil
+}
+
+// SetContentLocation replaces the Content-Location header in h.
+// If u is nil, the header is deleted.
+func SetContentLocation(h http.Header, u *url.URL) {
+	if u == nil {
+		h.Del("Content-Location")
+		return
+	}
+	h.Set("Content-Location", u.String())
+}
@@ -0,0 +1,151 @@
+package httpheader
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func ExampleAcceptRFC9110() {
+	header := http.Header{"Accept": {"text/html;q=0.5;level=1"}}
+	fmt.Printf("%+v\n", Accept(header))
+	fmt.Printf("%+v\n", AcceptRFC9110(header))
+	// Output:
+	// [{Type:text/html Params:map[] Q:0.5 Ext:map[level:1]}]
+	// [{Type:text/html Params:map[level:1] Q:0.5 Ext:map[]}]
+}
+
+func TestAcceptRFC9110(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		result []AcceptElem
+	}{
+		{
+			http.Header{"Accept": {"text/html;level=1;q=0.9"}},
+			[]AcceptElem{{Type: "text/html", Params: map[string]string{"level": "1"}, Q: 0.9}},
+		},
+		{
+			http.Header{"Accept": {"Text/HTML; Q=0.5; Foo=Bar; baz, */*;q=0"}},
+			[]AcceptElem{
+				{Type: "text/html", Params: map[string]string{"foo": "Bar", "baz": ""}, Q: 0.5},
+				{Type: "*/*", Q: 0},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			checkParse(t, test.header, test.result, AcceptRFC9110(test.header))
+		})
+	}
+}
+
+func ExampleAcceptRanges() {
+	header := http.Header{"Accept-Ranges": {"Bytes"}}
+	fmt.Println(AcceptRanges(header))
+	// Output: [bytes]
+}
+
+func TestAcceptRanges(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		result []string
+	}{
+		{
+			http.Header{},
+			nil,
+		},
+		{
+			http.Header{"Accept-Ranges": {"none"}},
+			[]string{"none"},
+		},
+		{
+			http.Header{"Accept-Ranges": {"bytes, Pages", ",items", "=none"}},
+			[]string{"bytes", "pages", "items"},
+		},
+		{
+			http.Header{"Accept-Ranges": {""}},
+			[]string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			checkParse(t, test.header, test.result, AcceptRanges(test.header))
+		})
+	}
+}
+
+func TestSetAcceptRanges(t *testing.T) {
+	tests := []struct {
+		input  []string
+		result http.Header
+	}{
+		{nil, http.Header{}},
+		{[]string{"none"}, http.Header{"Accept-Ranges": {"none"}}},
+		{[]string{"bytes", "pages"}, http.Header{"Accept-Ranges": {"bytes, pages"}}},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			header := http.Header{"Accept-Ranges": {"bytes"}}
+			SetAcceptRanges(header, test.input)
+			checkGenerate(t, test.input, test.result, header)
+		})
+	}
+}
+
+func TestAcceptRangesFuzz(t *testing.T) {
+	checkFuzz(t, "Accept-Ranges", AcceptRanges, SetAcceptRanges)
+}
+
+func ExampleContentLocation() {
+	request, _ := http.NewRequest("POST", "https://example.com/articles/", nil)
+	header := http.Header{"Content-Location": {"42"}}
+	fmt.Println(ContentLocation(header, request.URL))
+	// Output: https://example.com/articles/42
+}
+
+func TestContentLocation(t *testing.T) {
+	base := U("https://example.com/a/b")
+	tests := []struct {
+		header http.Header
+		base   *url.URL
+		result *url.URL
+	}{
+		{http.Header{}, base, nil},
+		{http.Header{"Content-Location": {""}}, base, nil},
+		{http.Header{"Content-Location": {"%"}}, base, nil},
+		{http.Header{"Content-Location": {"#"}}, base, nil},
+		{http.Header{"Content-Location": {"/a b"}}, base, nil},
+		{http.Header{"Content-Location": {" /c?d "}}, base, U("https://example.com/c?d")},
+		{http.Header{"Content-Location": {"c"}}, base, U("https://example.com/a/c")},
+		{http.Header{"Content-Location": {"c"}}, nil, U("c")},
+		{
+			http.Header{"Content-Location": {"http://other.example/"}},
+			base,
+			U("http://other.example/"),
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			checkParse(t, test.header, test.result, ContentLocation(test.header, test.base))
+		})
+	}
+}
+
+func TestSetContentLocation(t *testing.T) {
+	tests := []struct {
+		input  *url.URL
+		result http.Header
+	}{
+		{nil, http.Header{}},
+		{U("/b?c"), http.Header{"Content-Location": {"/b?c"}}},
+		{U("https://example.com/"), http.Header{"Content-Location": {"https://example.com/"}}},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			header := http.Header{"Content-Location": {"/a"}}
+			SetContentLocation(header, test.input)
+			checkGenerate(t, test.input, test.result, header)
+		})
+	}
+}
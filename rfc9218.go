@@ -0,0 +1,107 @@
+package httpheader
+
+import (
+	"net/http"
+)
+
+// PriorityParams represents the priority parameters of the Priority header
+// (RFC 9218 Section 4). The zero value is not the default priority;
+// see DefaultPriority.
+type PriorityParams struct {
+	Urgency     int // 0 (highest) to 7 (lowest)
+	Incremental bool
+}
+
+// DefaultPriority is the priority of a request or response without
+// the Priority header (RFC 9218 Sections 4.1 and 4.2).
+var DefaultPriority = PriorityParams{Urgency: 3}
+
+// Priority parses the Priority header from h (RFC 9218 Section 5).
+// Missing or invalid parameters are set to their defaults, and unknown ones
+// are ignored. If the header is not a valid structured field Dictionary
+// (RFC 9651), it is ignored entirely.
+func Priority(h http.Header) PriorityParams {
+	return DefaultLimits().Priority(h)
+}
+
+// Priority is like the Priority function, but applies l instead of
+// DefaultLimits.
+func (l Limits) Priority(h http.Header) PriorityParams {
+	return l.priorityFrom(DefaultPriority, h["Priority"])
+}
+
+// StrictPriority is like Priority, but calls Check first.
+func StrictPriority(h http.Header) (PriorityParams, error) {
+	return DefaultLimits().StrictPriority(h)
+}
+
+// StrictPriority is like the StrictPriority function, but applies l instead of
+// DefaultLimits.
+func (l Limits) StrictPriority(h http.Header) (PriorityParams, error) {
+	if err := l.Check(h, "Priority"); err != nil {
+		return DefaultPriority, err
+	}
+	return l.Priority(h), nil
+}
+
+// MergePriority determines the priority of a response, given the headers
+// of the request and the response (RFC 9218 Section 8). Parameters present
+// in the response's Priority header (a server's override) take precedence
+// over those in the request's Priority header, which take precedence over
+// the defaults.
+func MergePriority(request, response http.Header) PriorityParams {
+	return DefaultLimits().MergePriority(request, response)
+}
+
+// MergePriority is like the MergePriority function, but applies l instead of
+// DefaultLimits.
+func (l Limits) MergePriority(request, response http.Header) PriorityParams {
+	p := l.priorityFrom(DefaultPriority, request["Priority"])
+	return l.priorityFrom(p, response["Priority"])
+}
+
+func (l Limits) priorityFrom(p PriorityParams, values []string) PriorityParams {
+	if values == nil {
+		return p
+	}
+	dict, err := l.ParseDictionary(values)
+	if err != nil {
+		return p
+	}
+	if member, ok := dict.Get("u"); ok {
+		if u, ok := member.Value.(int64); ok && 0 <= u && u <= 7 {
+			p.Urgency = int(u)
+		}
+	}
+	if member, ok := dict.Get("i"); ok {
+		if i, ok := member.Value.(bool); ok {
+			p.Incremental = i
+		}
+	}
+	return p
+}
+
+// SetPriority replaces the Priority header in h. Parameters with their default
+// values are omitted, and if p is DefaultPriority, the header is deleted.
+// An Urgency outside the range of 0 to 7 is clamped to it.
+func SetPriority(h http.Header, p PriorityParams) {
+	switch {
+	case p.Urgency < 0:
+		p.Urgency = 0
+	case p.Urgency > 7:
+		p.Urgency = 7
+	}
+	var dict Dictionary
+	if p.Urgency != DefaultPriority.Urgency {
+		dict = append(dict, DictMember{Name: "u", Item: Item{Value: p.Urgency}})
+	}
+	if p.Incremental {
+		dict = append(dict, DictMember{Name: "i", Item: Item{Value: true}})
+	}
+	v, err := SerializeDictionary(dict)
+	if err != nil || v == "" {
+		h.Del("Priority")
+		return
+	}
+	h.Set("Priority", v)
+}
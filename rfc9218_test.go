@@ -0,0 +1,146 @@
+package httpheader
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func ExamplePriority() {
+	header := http.Header{"Priority": {"u=5, i"}}
+	fmt.Printf("%+v\n", Priority(header))
+	// Output: {Urgency:5 Incremental:true}
+}
+
+func TestPriority(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		result PriorityParams
+	}{
+		// Valid headers.
+		{
+			http.Header{},
+			PriorityParams{3, false},
+		},
+		{
+			http.Header{"Priority": {"u=0"}},
+			PriorityParams{0, false},
+		},
+		{
+			http.Header{"Priority": {"i"}},
+			PriorityParams{3, true},
+		},
+		{
+			http.Header{"Priority": {"i=?0, u=7"}},
+			PriorityParams{7, false},
+		},
+		{
+			http.Header{"Priority": {"u=1", "i;foo=bar"}},
+			PriorityParams{1, true},
+		},
+		{
+			http.Header{"Priority": {"u=1, x-ext=(a b), i, u=2"}},
+			PriorityParams{2, true},
+		},
+
+		// Invalid headers.
+		{
+			http.Header{"Priority": {"u=8, i"}},
+			PriorityParams{3, true},
+		},
+		{
+			http.Header{"Priority": {"u=1.0, i=1"}},
+			PriorityParams{3, false},
+		},
+		{
+			http.Header{"Priority": {"u=1, i,"}},
+			PriorityParams{3, false},
+		},
+		{
+			http.Header{"Priority": {"U=1"}},
+			PriorityParams{3, false},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			checkParse(t, test.header, test.result, Priority(test.header))
+		})
+	}
+}
+
+func TestSetPriority(t *testing.T) {
+	tests := []struct {
+		input  PriorityParams
+		result http.Header
+	}{
+		{PriorityParams{3, false}, http.Header{}},
+		{PriorityParams{0, false}, http.Header{"Priority": {"u=0"}}},
+		{PriorityParams{3, true}, http.Header{"Priority": {"i"}}},
+		{PriorityParams{6, true}, http.Header{"Priority": {"u=6, i"}}},
+		{PriorityParams{9, false}, http.Header{"Priority": {"u=7"}}},
+		{PriorityParams{-1, false}, http.Header{"Priority": {"u=0"}}},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			header := http.Header{"Priority": {"u=1"}}
+			SetPriority(header, test.input)
+			checkGenerate(t, test.input, test.result, header)
+		})
+	}
+}
+
+func TestPriorityFuzz(t *testing.T) {
+	checkFuzz(t, "Priority", Priority, SetPriority)
+}
+
+func TestPriorityRoundTrip(t *testing.T) {
+	checkRoundTrip(t, SetPriority, Priority, PriorityParams{
+		Urgency:     7,
+		Incremental: true,
+	})
+}
+
+func TestMergePriority(t *testing.T) {
+	tests := []struct {
+		request  http.Header
+		response http.Header
+		result   PriorityParams
+	}{
+		{
+			http.Header{},
+			http.Header{},
+			PriorityParams{3, false},
+		},
+		{
+			http.Header{"Priority": {"u=1, i"}},
+			http.Header{},
+			PriorityParams{1, true},
+		},
+		{
+			http.Header{"Priority": {"u=1, i"}},
+			http.Header{"Priority": {"u=4"}},
+			PriorityParams{4, true},
+		},
+		{
+			http.Header{"Priority": {"u=1"}},
+			http.Header{"Priority": {"i"}},
+			PriorityParams{1, true},
+		},
+		{
+			http.Header{"Priority": {"u=1, i"}},
+			http.Header{"Priority": {"i=?0, u=9"}},
+			PriorityParams{1, false},
+		},
+		{
+			http.Header{"Priority": {"u=1, i"}},
+			http.Header{"Priority": {"u=2,"}},
+			PriorityParams{1, true},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			checkParse(t, test.response, test.result,
+				MergePriority(test.request, test.response))
+		})
+	}
+}
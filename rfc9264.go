@@ -0,0 +1,370 @@
+package httpheader
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// ParseLinkset parses a document in the application/linkset format
+// (RFC 9264 Section 4.1), which is like the value of the Link header
+// but may span multiple lines. Target and Anchor URLs are resolved against
+// base, which is the URL of the linkset document. Links are parsed exactly
+// like in the Link function, but under DefaultDocumentLimits. If doc exceeds
+// them, ParseLinkset returns the links that fit, and ErrTruncated.
+func ParseLinkset(doc []byte, base *url.URL) ([]LinkElem, error) {
+	return DefaultDocumentLimits().ParseLinkset(doc, base)
+}
+
+// ParseLinkset is like the ParseLinkset function, but applies l instead of
+// DefaultDocumentLimits.
+func (l Limits) ParseLinkset(doc []byte, base *url.URL) ([]LinkElem, error) {
+	return l.parseLinkDoc(doc, base, "")
+}
+
+// parseLinkDoc parses a document of Link header values separated by commas
+// and line breaks, such as application/linkset, reporting any links discarded
+// because of l with ErrTruncated.
+func (l Limits) parseLinkDoc(doc []byte, base *url.URL, defaultRel string) ([]LinkElem, error) {
+	v := strings.NewReplacer("\r\n", " ", "\n", " ").Replace(string(doc))
+	values := []string{v}
+	links, truncated := parseLinks(l.values(values), base, defaultRel, l)
+	if truncated || l.tooLong(values) {
+		return links, ErrTruncated
+	}
+	return links, nil
+}
+
+// MarshalLinkset serializes links into the application/linkset format
+// (RFC 9264 Section 4.1), with one link per line. Links are serialized
+// exactly like in SetLink.
+func MarshalLinkset(links []LinkElem) []byte {
+	b := &bytes.Buffer{}
+	for i, link := range links {
+		if i > 0 {
+			b.WriteString(",\n")
+		}
+		b.WriteString(buildLink([]LinkElem{link}))
+	}
+	if len(links) > 0 {
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
+
+// ParseLinksetJSON parses a document in the application/linkset+json format
+// (RFC 9264 Section 4.2), resolving Target and Anchor URLs against base,
+// which is the URL of the linkset document. If base is nil, relative URLs
+// are left unresolved. An error is returned if doc is not valid JSON
+// of the expected general shape; invalid links are skipped. The order of links
+// is preserved. Relation types are normalized like in the Link function.
+//
+// ParseLinksetJSON applies DefaultDocumentLimits. If doc is longer than
+// MaxBytes, it is not parsed, and ErrTruncated is returned. If it has more
+// than MaxElems links, the first MaxElems are returned with ErrTruncated.
+// Like in Link, only hreflang values and extension attributes count toward
+// MaxParams.
+//
+// The 'title*' attribute overrides 'title', and its language is stored
+// in TitleLang. For any extension attribute, only its first value is stored
+// in Ext; values of attributes whose name ends in an asterisk override those
+// without it, and are stored under the name without the asterisk. Language
+// information of such values is discarded. Attributes named like those above
+// in a different case or with an asterisk, such as 'Type*', are skipped.
+func ParseLinksetJSON(doc []byte, base *url.URL) ([]LinkElem, error) {
+	return DefaultDocumentLimits().ParseLinksetJSON(doc, base)
+}
+
+// ParseLinksetJSON is like the ParseLinksetJSON function, but applies l
+// instead of DefaultDocumentLimits.
+func (l Limits) ParseLinksetJSON(doc []byte, base *url.URL) ([]LinkElem, error) {
+	if l.MaxBytes > 0 && len(doc) > l.MaxBytes {
+		return nil, ErrTruncated
+	}
+	var linkset struct {
+		Linkset []json.RawMessage `json:"linkset"`
+	}
+	if err := json.Unmarshal(doc, &linkset); err != nil {
+		return nil, err
+	}
+	var links []LinkElem
+	for _, rawContext := range linkset.Linkset {
+		var context map[string]json.RawMessage
+		if err := json.Unmarshal(rawContext, &context); err != nil {
+			return nil, err
+		}
+		rels, err := objectKeys(rawContext)
+		if err != nil {
+			return nil, err
+		}
+		var anchor *url.URL
+		if rawAnchor, ok := context["anchor"]; ok {
+			var s string
+			if err := json.Unmarshal(rawAnchor, &s); err != nil {
+				return nil, err
+			}
+			anchor, err = url.Parse(s)
+			if err != nil {
+				// An anchor completely changes the meaning of a link,
+				// better not ignore it.
+				continue
+			}
+			if base != nil {
+				anchor = base.ResolveReference(anchor)
+			}
+		}
+		for _, rel := range rels {
+			if rel == "anchor" || rel == "" {
+				continue
+			}
+			var targets []json.RawMessage
+			if err := json.Unmarshal(context[rel], &targets); err != nil {
+				return nil, err
+			}
+			for _, rawTarget := range targets {
+				link, err := parseLinksetTarget(rawTarget, base, l)
+				if err != nil {
+					return nil, err
+				}
+				if link.Target == nil {
+					continue
+				}
+				if l.elemsDone(len(links)) {
+					return links, ErrTruncated
+				}
+				link.Anchor = anchor
+				link.Rel = normalizeRel(rel)
+				links = append(links, link)
+			}
+		}
+	}
+	return links, nil
+}
+
+// A linksetIntlValue is an internationalized target attribute value
+// (RFC 9264 Section 4.2.4.2).
+type linksetIntlValue struct {
+	Value    string `json:"value"`
+	Language string `json:"language,omitempty"`
+}
+
+func parseLinksetTarget(rawTarget json.RawMessage, base *url.URL, l Limits) (LinkElem, error) {
+	var link LinkElem
+	var target map[string]json.RawMessage
+	if err := json.Unmarshal(rawTarget, &target); err != nil {
+		return link, err
+	}
+	names, err := objectKeys(rawTarget)
+	if err != nil {
+		return link, err
+	}
+	var titleStar bool
+	starred := make(map[string]bool)
+	n := 0 // hreflang values and extension attributes, toward l.MaxParams
+	for _, name := range names {
+		raw := target[name]
+		switch name {
+		case "href":
+			var href string
+			if json.Unmarshal(raw, &href) != nil {
+				continue
+			}
+			link.Target, err = url.Parse(href)
+			if err == nil && base != nil {
+				link.Target = base.ResolveReference(link.Target)
+			}
+
+		case "type", "media", "title":
+			var s string
+			if json.Unmarshal(raw, &s) != nil {
+				continue
+			}
+			switch name {
+			case "type":
+				link.Type = strings.ToLower(s)
+			case "media":
+				link.Media = s
+			case "title":
+				if !titleStar {
+					link.Title = s
+				}
+			}
+
+		case "hreflang":
+			var langs []string
+			if json.Unmarshal(raw, &langs) != nil {
+				continue
+			}
+			for _, lang := range langs {
+				if l.paramsDone(n) {
+					break
+				}
+				link.HrefLang = append(link.HrefLang, strings.ToLower(lang))
+				n++
+			}
+
+		case "title*":
+			var values []linksetIntlValue
+			if json.Unmarshal(raw, &values) != nil || len(values) == 0 {
+				continue
+			}
+			link.Title = values[0].Value
+			if isLangTag(values[0].Language) {
+				link.TitleLang = strings.ToLower(values[0].Language)
+			}
+			titleStar = true
+
+		default: // extension attributes
+			if l.paramsDone(n) {
+				continue
+			}
+			n++
+			name = strings.ToLower(name)
+			plainName := strings.TrimSuffix(name, "*")
+			if plainName == "" || strings.HasSuffix(plainName, "*") ||
+				isLinksetTargetAttr(plainName) {
+				continue // not a valid extension attribute name
+			}
+			if plainName != name {
+				var values []linksetIntlValue
+				if json.Unmarshal(raw, &values) != nil || len(values) == 0 {
+					continue
+				}
+				if link.Ext == nil {
+					link.Ext = make(map[string]string)
+				}
+				link.Ext[plainName] = values[0].Value
+				starred[name] = true
+				continue
+			}
+			var values []string
+			if json.Unmarshal(raw, &values) != nil || len(values) == 0 {
+				continue
+			}
+			if link.Ext == nil {
+				link.Ext = make(map[string]string)
+			}
+			if !starred[name+"*"] {
+				link.Ext[name] = values[0]
+			}
+		}
+	}
+	return link, nil
+}
+
+// objectKeys returns the names of members of the JSON object raw,
+// in their original order.
+func objectKeys(raw json.RawMessage) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil { // opening brace
+		return nil, err
+	}
+	var keys []string
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key.(string))
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// MarshalLinksetJSON serializes links into the application/linkset+json format
+// (RFC 9264 Section 4.2). Links are grouped into link context objects by their
+// Anchor, in order of first appearance; links without an Anchor are grouped
+// into a context object without an anchor member. A Title with TitleLang
+// is serialized as 'title*' under the same rules as in SetLink. Extension
+// attributes from Ext are serialized as arrays of one string each, except that
+// a 'qux*' key is serialized as a 'qux*' attribute with an internationalized
+// value, and then any 'qux' key is skipped. Any members of Ext named like
+// corresponding fields of LinkElem are also skipped.
+func MarshalLinksetJSON(links []LinkElem) ([]byte, error) {
+	type context struct {
+		anchor  string
+		members map[string]interface{}
+	}
+	// An Anchor that serializes to an empty string is still distinct
+	// from no Anchor.
+	type anchorKey struct {
+		ok     bool
+		anchor string
+	}
+	var contexts []*context
+	byAnchor := make(map[anchorKey]*context)
+	for _, link := range links {
+		var key anchorKey
+		if link.Anchor != nil {
+			key = anchorKey{true, link.Anchor.String()}
+		}
+		c := byAnchor[key]
+		if c == nil {
+			c = &context{key.anchor, make(map[string]interface{})}
+			if key.ok {
+				c.members["anchor"] = key.anchor
+			}
+			contexts = append(contexts, c)
+			byAnchor[key] = c
+		}
+		targets, _ := c.members[link.Rel].([]map[string]interface{})
+		c.members[link.Rel] = append(targets, linksetTarget(link))
+	}
+	linkset := make([]map[string]interface{}, 0, len(contexts))
+	for _, c := range contexts {
+		linkset = append(linkset, c.members)
+	}
+	b := &bytes.Buffer{}
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false) // no need to escape ampersands in URLs
+	if err := enc.Encode(map[string]interface{}{"linkset": linkset}); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func linksetTarget(link LinkElem) map[string]interface{} {
+	target := map[string]interface{}{"href": link.Target.String()}
+	if link.Type != "" {
+		target["type"] = link.Type
+	}
+	switch {
+	case isLangTag(link.TitleLang):
+		target["title*"] = []linksetIntlValue{{link.Title, link.TitleLang}}
+	case link.Title != "":
+		target["title"] = link.Title
+	}
+	if link.HrefLang != nil {
+		target["hreflang"] = link.HrefLang
+	}
+	if link.Media != "" {
+		target["media"] = link.Media
+	}
+	for _, name := range sortedKeys(link.Ext) {
+		value := link.Ext[name]
+		if isLinksetTargetAttr(strings.TrimSuffix(strings.ToLower(name), "*")) {
+			continue
+		}
+		if strings.HasSuffix(name, "*") {
+			target[name] = []linksetIntlValue{{Value: value}}
+		} else if _, ok := link.Ext[name+"*"]; !ok {
+			target[name] = []string{value}
+		}
+	}
+	return target
+}
+
+// isLinksetTargetAttr reports whether name, lowercased and without
+// a trailing asterisk, is that of a target attribute stored in a field
+// of LinkElem, or of a member that is not a target attribute at all.
+func isLinksetTargetAttr(name string) bool {
+	switch name {
+	case "anchor", "href", "rel", "title", "type", "hreflang", "media":
+		return true
+	}
+	return false
+}
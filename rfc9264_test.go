@@ -0,0 +1,314 @@
+package httpheader
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func ExampleParseLinkset() {
+	base, _ := url.Parse("https://example.org/linkset")
+	doc := []byte(`<https://example.org/articles/1>; rel=author; anchor="/book",
+<https://example.org/articles/2>; rel=next; anchor="/book"
+`)
+	links, _ := ParseLinkset(doc, base)
+	for _, link := range links {
+		fmt.Println(link.Anchor, link.Rel, link.Target)
+	}
+	// Output: https://example.org/book author https://example.org/articles/1
+	// https://example.org/book next https://example.org/articles/2
+}
+
+func ExampleMarshalLinksetJSON() {
+	doc, _ := MarshalLinksetJSON([]LinkElem{
+		{
+			Anchor:   U("https://example.org/book"),
+			Rel:      "author",
+			Target:   U("https://example.org/people/1"),
+			HrefLang: []string{"en"},
+			Title:    "Jan Kowalski",
+		},
+	})
+	os.Stdout.Write(doc)
+	// Output: {"linkset":[{"anchor":"https://example.org/book","author":[{"href":"https://example.org/people/1","hreflang":["en"],"title":"Jan Kowalski"}]}]}
+}
+
+func TestParseLinkset(t *testing.T) {
+	tests := []struct {
+		doc    string
+		result []LinkElem
+	}{
+		{
+			"",
+			[]LinkElem{},
+		},
+		{
+			"<a>; rel=next,\r\n<b>; rel=prev; anchor=\"/c\"\n,\n\n<d>;\n rel=up\n",
+			[]LinkElem{
+				{Rel: "next", Target: U("http://x.test/a")},
+				{Rel: "prev", Target: U("http://x.test/b"), Anchor: U("http://x.test/c")},
+				{Rel: "up", Target: U("http://x.test/d")},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			links, err := ParseLinkset([]byte(test.doc), U(testBase))
+			checkParse(t, nil, test.result, links, nil, err)
+		})
+	}
+}
+
+// largeLinks returns n links that serialize to well over DefaultLimits.MaxBytes.
+func largeLinks(n int) []LinkElem {
+	links := make([]LinkElem, n)
+	for i := range links {
+		links[i] = LinkElem{
+			Rel:    "item",
+			Target: U(fmt.Sprintf("http://x.test/items/%d", i)),
+		}
+	}
+	return links
+}
+
+func TestParseLinksetLarge(t *testing.T) {
+	links := largeLinks(2000)
+	doc := MarshalLinkset(links)
+	if len(doc) <= DefaultLimits().MaxBytes {
+		t.Fatalf("document of %d bytes is too small for this test", len(doc))
+	}
+	parsed, err := ParseLinkset(doc, nil)
+	checkParse(t, nil, links, parsed, nil, err)
+
+	parsed, err = Limits{MaxElems: 100}.ParseLinkset(doc, nil)
+	checkParse(t, nil, links[:100], parsed, ErrTruncated, err)
+	parsed, err = Limits{MaxBytes: 100}.ParseLinkset(doc, nil)
+	checkParse(t, nil, links[:2], parsed, ErrTruncated, err)
+}
+
+func TestParseLinksetJSONLarge(t *testing.T) {
+	links := largeLinks(2000)
+	doc, err := MarshalLinksetJSON(links)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc) <= DefaultLimits().MaxBytes {
+		t.Fatalf("document of %d bytes is too small for this test", len(doc))
+	}
+	parsed, err := ParseLinksetJSON(doc, nil)
+	checkParse(t, nil, links, parsed, nil, err)
+
+	parsed, err = Limits{MaxElems: 100}.ParseLinksetJSON(doc, nil)
+	checkParse(t, nil, links[:100], parsed, ErrTruncated, err)
+	parsed, err = Limits{MaxBytes: 100}.ParseLinksetJSON(doc, nil)
+	checkParse(t, nil, []LinkElem(nil), parsed, ErrTruncated, err)
+}
+
+func TestMarshalLinkset(t *testing.T) {
+	links := []LinkElem{
+		{Rel: "next", Target: U("http://x.test/a")},
+		{Rel: "prev", Target: U("http://x.test/b"), Anchor: U("http://x.test/c")},
+	}
+	expected := "<http://x.test/a>; rel=next,\n" +
+		`<http://x.test/b>; anchor="http://x.test/c"; rel=prev` + "\n"
+	if actual := string(MarshalLinkset(links)); actual != expected {
+		t.Errorf("expected: %q\nactual:   %q", expected, actual)
+	}
+	parsed, err := ParseLinkset([]byte(expected), U(testBase))
+	checkParse(t, nil, links, parsed, nil, err)
+}
+
+func TestParseLinksetJSON(t *testing.T) {
+	tests := []struct {
+		doc    string
+		result []LinkElem
+	}{
+		{
+			`{"linkset": []}`,
+			nil,
+		},
+		{
+			`{"linkset": [
+				{
+					"anchor": "/book",
+					"next": [
+						{"href": "/b2", "type": "Text/HTML", "hreflang": ["EN", "de"]},
+						{"href": "/b2.pdf", "media": "print"}
+					],
+					"Author": [
+						{
+							"href": "https://example.com/jan",
+							"title": "Jan",
+							"title*": [{"value": "Ján", "language": "sk"}],
+							"foo": ["bar", "baz"],
+							"qux*": [{"value": "Ünïcode"}],
+							"qux": ["ASCII"]
+						}
+					]
+				},
+				{
+					"up": [{"href": "/"}, {"title": "missing href"}]
+				}
+			]}`,
+			[]LinkElem{
+				{
+					Anchor:   U("http://x.test/book"),
+					Rel:      "next",
+					Target:   U("http://x.test/b2"),
+					Type:     "text/html",
+					HrefLang: []string{"en", "de"},
+				},
+				{
+					Anchor: U("http://x.test/book"),
+					Rel:    "next",
+					Target: U("http://x.test/b2.pdf"),
+					Media:  "print",
+				},
+				{
+					Anchor:    U("http://x.test/book"),
+					Rel:       "author",
+					Target:    U("https://example.com/jan"),
+					Title:     "Ján",
+					TitleLang: "sk",
+					Ext:       map[string]string{"foo": "bar", "qux": "Ünïcode"},
+				},
+				{
+					Rel:    "up",
+					Target: U("http://x.test/"),
+				},
+			},
+		},
+		{
+			// Not valid relation types and attribute names.
+			`{"linkset": [{
+				"": [{"href": "/b1"}],
+				"next": [{
+					"href": "/b2",
+					"*": [{"value": "a"}],
+					"x**": [{"value": "b"}],
+					"TYPE*": [{"value": "d"}],
+					"HREF": ["e"],
+					"x": ["c"]
+				}]
+			}]}`,
+			[]LinkElem{
+				{
+					Rel:    "next",
+					Target: U("http://x.test/b2"),
+					Ext:    map[string]string{"x": "c"},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			links, err := ParseLinksetJSON([]byte(test.doc), U(testBase))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkParse(t, nil, test.result, links)
+		})
+	}
+}
+
+func TestParseLinksetJSONNilBase(t *testing.T) {
+	doc := `{"linkset": [{"anchor": "/book", "next": [{"href": "b2"}]}]}`
+	links, err := ParseLinksetJSON([]byte(doc), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkParse(t, nil, []LinkElem{
+		{Anchor: U("/book"), Rel: "next", Target: U("b2")},
+	}, links)
+}
+
+func TestParseLinksetJSONErrors(t *testing.T) {
+	docs := []string{
+		``,
+		`{"linkset": {}}`,
+		`{"linkset": [[]]}`,
+		`{"linkset": [{"anchor": 1}]}`,
+		`{"linkset": [{"next": {"href": "/"}}]}`,
+		`{"linkset": [{"next": ["/"]}]}`,
+	}
+	for _, doc := range docs {
+		if _, err := ParseLinksetJSON([]byte(doc), U(testBase)); err == nil {
+			t.Errorf("no error on %q", doc)
+		}
+	}
+}
+
+func TestLinksetJSONRoundTrip(t *testing.T) {
+	links := []LinkElem{
+		{
+			Anchor:   U("http://x.test/book"),
+			Rel:      "author",
+			Target:   U("https://example.com/jan?a=1&b=2"),
+			Title:    "Ján <Kowalski>",
+			HrefLang: []string{"sk"},
+			Ext:      map[string]string{"foo": "bar"},
+		},
+		{
+			Anchor:    U("http://x.test/book"),
+			Rel:       "author",
+			Target:    U("https://example.com/jan.sk"),
+			Title:     "Ján Kowalski",
+			TitleLang: "sk",
+		},
+		{
+			Anchor: U("http://x.test/book"),
+			Rel:    "next",
+			Target: U("http://x.test/b2"),
+			Type:   "text/html",
+			Media:  "screen",
+		},
+		{
+			Rel:    "up",
+			Target: U("http://x.test/"),
+		},
+	}
+	doc, err := MarshalLinksetJSON(links)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("marshaled: %s", doc)
+	parsed, err := ParseLinksetJSON(doc, U(testBase))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkParse(t, nil, links, parsed)
+}
+
+func TestMarshalLinksetJSONEmptyAnchor(t *testing.T) {
+	links := []LinkElem{
+		{Rel: "next", Target: U("/a")},
+		{Anchor: U("#"), Rel: "next", Target: U("/b")},
+	}
+	doc, err := MarshalLinksetJSON(links)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"linkset":[{"next":[{"href":"/a"}]},{"anchor":"","next":[{"href":"/b"}]}]}` + "\n"
+	if string(doc) != expected {
+		t.Errorf("expected: %s\nactual:   %s", expected, doc)
+	}
+}
+
+func TestMarshalLinksetJSONStarred(t *testing.T) {
+	// Whatever the map order, 'qux*' wins over 'qux'.
+	for i := 0; i < 20; i++ {
+		doc, err := MarshalLinksetJSON([]LinkElem{{
+			Rel:    "next",
+			Target: U("http://x.test/"),
+			Ext:    map[string]string{"qux": "ASCII", "qux*": "Ünïcode"},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `{"linkset":[{"next":[{"href":"http://x.test/","qux*":[{"value":"Ünïcode"}]}]}]}` + "\n"
+		if string(doc) != expected {
+			t.Fatalf("expected: %s\nactual:   %s", expected, doc)
+		}
+	}
+}
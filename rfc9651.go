@@ -0,0 +1,781 @@
+package httpheader
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// This file implements Structured Field Values for HTTP (RFC 9651, formerly
+// RFC 8941). Unlike the rest of this package, these parsers are strict:
+// RFC 9651 requires that a field which fails to parse be ignored entirely,
+// so ParseItem, ParseList and ParseDictionary return an error in that case,
+// and the caller should then act as if the field were absent.
+
+// A Token is a structured field Token (RFC 9651 Section 3.3.4).
+// It is distinct from a String.
+type Token string
+
+// A DisplayString is a structured field Display String (RFC 9651
+// Section 3.3.8), which, unlike a String, may contain any Unicode text.
+type DisplayString string
+
+// An Item is a structured field Item (RFC 9651 Section 3.3), or a member
+// of a List or Dictionary. Value is a bare item of one of the following types,
+// or, for members of Lists and Dictionaries only, an InnerList:
+//
+//	Integer         int64 (int is also accepted for serialization)
+//	Decimal         float64
+//	String          string
+//	Token           Token
+//	Byte Sequence   []byte
+//	Boolean         bool
+//	Date            time.Time
+//	Display String  DisplayString
+type Item struct {
+	Value  interface{}
+	Params Params
+}
+
+// An InnerList is a structured field Inner List (RFC 9651 Section 3.1.1).
+// Its parameters are stored in the enclosing Item.
+type InnerList []Item
+
+// A List is a structured field List (RFC 9651 Section 3.1).
+type List []Item
+
+// A Param is one parameter of an Item or an InnerList (RFC 9651
+// Section 3.1.2). Value is a bare item.
+type Param struct {
+	Name  string
+	Value interface{}
+}
+
+// Params are parameters in their original order.
+type Params []Param
+
+// Get returns the value of the parameter with the given name, if any.
+func (params Params) Get(name string) (value interface{}, ok bool) {
+	for _, param := range params {
+		if param.Name == name {
+			return param.Value, true
+		}
+	}
+	return nil, false
+}
+
+// A DictMember is one member of a Dictionary.
+type DictMember struct {
+	Name string
+	Item
+}
+
+// A Dictionary is a structured field Dictionary (RFC 9651 Section 3.2),
+// with members in their original order.
+type Dictionary []DictMember
+
+// Get returns the member of dict with the given name, if any.
+func (dict Dictionary) Get(name string) (item Item, ok bool) {
+	for _, member := range dict {
+		if member.Name == name {
+			return member.Item, true
+		}
+	}
+	return Item{}, false
+}
+
+// ParseItem parses values, which are all lines of a header field
+// (as found in http.Header), as a structured field Item.
+func ParseItem(values []string) (Item, error) {
+	return DefaultLimits().ParseItem(values)
+}
+
+// ParseItem is like the ParseItem function, but applies l instead of
+// DefaultLimits.
+func (l Limits) ParseItem(values []string) (Item, error) {
+	p := l.newSFParser(values)
+	if err := p.checkLength(); err != nil {
+		return Item{}, err
+	}
+	item, err := p.item()
+	if err != nil {
+		return Item{}, err
+	}
+	if err := p.end(); err != nil {
+		return Item{}, err
+	}
+	return item, nil
+}
+
+// ParseList parses values, which are all lines of a header field
+// (as found in http.Header), as a structured field List. An empty or missing
+// field is an empty (nil) List.
+func ParseList(values []string) (List, error) {
+	return DefaultLimits().ParseList(values)
+}
+
+// ParseList is like the ParseList function, but applies l instead of
+// DefaultLimits.
+func (l Limits) ParseList(values []string) (List, error) {
+	p := l.newSFParser(values)
+	if err := p.checkLength(); err != nil {
+		return nil, err
+	}
+	var list List
+	for p.i < len(p.s) {
+		if l.elemsDone(len(list)) {
+			return nil, p.fail("more than %d members", l.MaxElems)
+		}
+		member, err := p.member()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, member)
+		done, err := p.nextMember()
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			break
+		}
+	}
+	return list, nil
+}
+
+// ParseDictionary parses values, which are all lines of a header field
+// (as found in http.Header), as a structured field Dictionary. An empty
+// or missing field is an empty (nil) Dictionary. When a name occurs more than
+// once, the last value is used, but the position of the first is kept.
+func ParseDictionary(values []string) (Dictionary, error) {
+	return DefaultLimits().ParseDictionary(values)
+}
+
+// ParseDictionary is like the ParseDictionary function, but applies l instead
+// of DefaultLimits.
+func (l Limits) ParseDictionary(values []string) (Dictionary, error) {
+	p := l.newSFParser(values)
+	if err := p.checkLength(); err != nil {
+		return nil, err
+	}
+	var dict Dictionary
+	for p.i < len(p.s) {
+		if l.elemsDone(len(dict)) {
+			return nil, p.fail("more than %d members", l.MaxElems)
+		}
+		var member DictMember
+		var err error
+		member.Name, err = p.key()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() == '=' {
+			p.i++
+			member.Item, err = p.member()
+		} else {
+			member.Value = true
+			member.Params, err = p.params()
+		}
+		if err != nil {
+			return nil, err
+		}
+		dict = dict.set(member)
+		done, err := p.nextMember()
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			break
+		}
+	}
+	return dict, nil
+}
+
+func (dict Dictionary) set(member DictMember) Dictionary {
+	for i := range dict {
+		if dict[i].Name == member.Name {
+			dict[i] = member
+			return dict
+		}
+	}
+	return append(dict, member)
+}
+
+type sfParser struct {
+	s      string
+	i      int
+	limits Limits
+}
+
+func (l Limits) newSFParser(values []string) *sfParser {
+	// RFC 9651 Section 4.2: combine field lines with commas,
+	// then discard leading and trailing SP (but not HTAB).
+	s := strings.Join(values, ", ")
+	return &sfParser{s: strings.Trim(s, " "), limits: l}
+}
+
+// checkLength fails if the combined field value exceeds p.limits.
+func (p *sfParser) checkLength() error {
+	if p.limits.MaxBytes > 0 && len(p.s) > p.limits.MaxBytes {
+		p.i = p.limits.MaxBytes
+		return p.fail("longer than %d bytes", p.limits.MaxBytes)
+	}
+	return nil
+}
+
+func (p *sfParser) fail(format string, args ...interface{}) error {
+	return &sfError{p.i, fmt.Sprintf(format, args...)}
+}
+
+// An sfError is a parsing error at the given offset into
+// the combined field value.
+type sfError struct {
+	offset int
+	msg    string
+}
+
+func (e *sfError) Error() string {
+	return fmt.Sprintf("bad structured field at offset %d: %s", e.offset, e.msg)
+}
+
+func (p *sfParser) peek() byte {
+	if p.i >= len(p.s) {
+		return 0
+	}
+	return p.s[p.i]
+}
+
+func (p *sfParser) end() error {
+	if p.i < len(p.s) {
+		return p.fail("unexpected %q", p.s[p.i])
+	}
+	return nil
+}
+
+func (p *sfParser) skipSP() {
+	for p.peek() == ' ' {
+		p.i++
+	}
+}
+
+func (p *sfParser) skipOWS() {
+	for p.peek() == ' ' || p.peek() == '\t' {
+		p.i++
+	}
+}
+
+// nextMember consumes the separator after a member of a List or Dictionary.
+// It returns true if there are no more members.
+func (p *sfParser) nextMember() (done bool, err error) {
+	p.skipOWS()
+	if p.i == len(p.s) {
+		return true, nil
+	}
+	if p.s[p.i] != ',' {
+		return true, p.fail("expected ',', got %q", p.s[p.i])
+	}
+	p.i++
+	p.skipOWS()
+	if p.i == len(p.s) {
+		return true, p.fail("trailing comma")
+	}
+	return false, nil
+}
+
+func (p *sfParser) member() (Item, error) {
+	if p.peek() == '(' {
+		return p.innerList()
+	}
+	return p.item()
+}
+
+func (p *sfParser) innerList() (Item, error) {
+	p.i++ // opening parenthesis
+	var list InnerList
+	for p.i < len(p.s) {
+		p.skipSP()
+		if p.peek() == ')' {
+			p.i++
+			params, err := p.params()
+			if err != nil {
+				return Item{}, err
+			}
+			if list == nil {
+				list = InnerList{}
+			}
+			return Item{Value: list, Params: params}, nil
+		}
+		if p.limits.elemsDone(len(list)) {
+			return Item{}, p.fail("more than %d items in inner list", p.limits.MaxElems)
+		}
+		item, err := p.item()
+		if err != nil {
+			return Item{}, err
+		}
+		list = append(list, item)
+		if c := p.peek(); c != ' ' && c != ')' {
+			return Item{}, p.fail("bad inner list")
+		}
+	}
+	return Item{}, p.fail("unterminated inner list")
+}
+
+func (p *sfParser) item() (Item, error) {
+	var item Item
+	var err error
+	item.Value, err = p.bareItem()
+	if err != nil {
+		return Item{}, err
+	}
+	item.Params, err = p.params()
+	if err != nil {
+		return Item{}, err
+	}
+	return item, nil
+}
+
+func (p *sfParser) params() (Params, error) {
+	var params Params
+	for p.peek() == ';' {
+		if p.limits.paramsDone(len(params)) {
+			return nil, p.fail("more than %d parameters", p.limits.MaxParams)
+		}
+		p.i++
+		p.skipSP()
+		name, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		var value interface{} = true
+		if p.peek() == '=' {
+			p.i++
+			value, err = p.bareItem()
+			if err != nil {
+				return nil, err
+			}
+		}
+		params = params.set(Param{name, value})
+	}
+	return params, nil
+}
+
+func (params Params) set(param Param) Params {
+	for i := range params {
+		if params[i].Name == param.Name {
+			params[i] = param
+			return params
+		}
+	}
+	return append(params, param)
+}
+
+func (p *sfParser) key() (string, error) {
+	start := p.i
+	if c := p.peek(); !isLCAlpha(c) && c != '*' {
+		return "", p.fail("bad key")
+	}
+	for p.i < len(p.s) && isKeyChar(p.s[p.i]) {
+		p.i++
+	}
+	return p.s[start:p.i], nil
+}
+
+func (p *sfParser) bareItem() (interface{}, error) {
+	c := p.peek()
+	switch {
+	case c == '-' || isDigit(c):
+		return p.number(false)
+	case c == '"':
+		return p.string()
+	case c == '*' || isAlpha(c):
+		return p.token(), nil
+	case c == ':':
+		return p.byteSequence()
+	case c == '?':
+		return p.boolean()
+	case c == '@':
+		return p.date()
+	case c == '%':
+		return p.displayString()
+	case c == 0:
+		return nil, p.fail("missing item")
+	default:
+		return nil, p.fail("unexpected %q", c)
+	}
+}
+
+func (p *sfParser) number(integerOnly bool) (interface{}, error) {
+	start := p.i
+	if p.peek() == '-' {
+		p.i++
+	}
+	if !isDigit(p.peek()) {
+		return nil, p.fail("bad number")
+	}
+	digitsStart := p.i
+	dot := -1
+	for ; p.i < len(p.s); p.i++ {
+		c := p.s[p.i]
+		if isDigit(c) {
+			continue
+		}
+		if c == '.' && dot == -1 && !integerOnly {
+			if p.i-digitsStart > 12 {
+				return nil, p.fail("decimal too long")
+			}
+			dot = p.i
+			continue
+		}
+		break
+	}
+	if dot == -1 {
+		if p.i-digitsStart > 15 {
+			return nil, p.fail("integer too long")
+		}
+		n, err := strconv.ParseInt(p.s[start:p.i], 10, 64)
+		if err != nil {
+			return nil, p.fail("bad integer")
+		}
+		return n, nil
+	}
+	if frac := p.i - dot - 1; frac < 1 || frac > 3 {
+		return nil, p.fail("bad decimal fraction")
+	}
+	f, err := strconv.ParseFloat(p.s[start:p.i], 64)
+	if err != nil {
+		return nil, p.fail("bad decimal")
+	}
+	return f, nil
+}
+
+func (p *sfParser) string() (interface{}, error) {
+	p.i++ // opening quote
+	b := &strings.Builder{}
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		p.i++
+		switch {
+		case c == '\\':
+			next := p.peek()
+			if next != '"' && next != '\\' {
+				return nil, p.fail("bad escape in string")
+			}
+			b.WriteByte(next)
+			p.i++
+		case c == '"':
+			return b.String(), nil
+		case c < 0x20 || c > 0x7E:
+			return nil, p.fail("bad character in string")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return nil, p.fail("unterminated string")
+}
+
+func (p *sfParser) token() Token {
+	start := p.i
+	p.i++ // first character, already checked
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		if byteClass[c] != cTokenOK && c != ':' && c != '/' {
+			break
+		}
+		p.i++
+	}
+	return Token(p.s[start:p.i])
+}
+
+func (p *sfParser) byteSequence() (interface{}, error) {
+	p.i++ // opening colon
+	end := strings.IndexByte(p.s[p.i:], ':')
+	if end == -1 {
+		return nil, p.fail("unterminated byte sequence")
+	}
+	encoded := p.s[p.i : p.i+end]
+	for i := 0; i < len(encoded); i++ {
+		if !isAlpha(encoded[i]) && !isDigit(encoded[i]) &&
+			strings.IndexByte("+/=", encoded[i]) == -1 {
+			return nil, p.fail("bad character in byte sequence")
+		}
+	}
+	// Padding is optional for parsers.
+	decoded, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	if err != nil {
+		return nil, p.fail("bad byte sequence")
+	}
+	p.i += end + 1
+	return decoded, nil
+}
+
+func (p *sfParser) boolean() (interface{}, error) {
+	p.i++ // question mark
+	switch p.peek() {
+	case '1':
+		p.i++
+		return true, nil
+	case '0':
+		p.i++
+		return false, nil
+	default:
+		return nil, p.fail("bad boolean")
+	}
+}
+
+func (p *sfParser) date() (interface{}, error) {
+	p.i++ // at sign
+	n, err := p.number(true)
+	if err != nil {
+		return nil, err
+	}
+	return time.Unix(n.(int64), 0).UTC(), nil
+}
+
+func (p *sfParser) displayString() (interface{}, error) {
+	p.i++ // percent sign
+	if p.peek() != '"' {
+		return nil, p.fail("bad display string")
+	}
+	p.i++
+	var b []byte
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		p.i++
+		switch {
+		case c < 0x20 || c > 0x7E:
+			return nil, p.fail("bad character in display string")
+		case c == '%':
+			if p.i+2 > len(p.s) ||
+				!isLowerHex(p.s[p.i]) || !isLowerHex(p.s[p.i+1]) {
+				return nil, p.fail("bad percent-encoding in display string")
+			}
+			octet, _ := strconv.ParseUint(p.s[p.i:p.i+2], 16, 8)
+			b = append(b, byte(octet))
+			p.i += 2
+		case c == '"':
+			if !utf8.Valid(b) {
+				return nil, p.fail("bad UTF-8 in display string")
+			}
+			return DisplayString(b), nil
+		default:
+			b = append(b, c)
+		}
+	}
+	return nil, p.fail("unterminated display string")
+}
+
+func isDigit(c byte) bool    { return '0' <= c && c <= '9' }
+func isLCAlpha(c byte) bool  { return 'a' <= c && c <= 'z' }
+func isAlpha(c byte) bool    { return isLCAlpha(c) || ('A' <= c && c <= 'Z') }
+func isLowerHex(c byte) bool { return isDigit(c) || ('a' <= c && c <= 'f') }
+
+func isKeyChar(c byte) bool {
+	return isLCAlpha(c) || isDigit(c) || c == '_' || c == '-' || c == '.' || c == '*'
+}
+
+// SerializeItem serializes item as a structured field Item
+// (RFC 9651 Section 4.1.3). It returns an error if item cannot be represented,
+// for example if it contains an Integer that is out of range.
+func SerializeItem(item Item) (string, error) {
+	b := &strings.Builder{}
+	if err := writeSFItem(b, item); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// SerializeList serializes list as a structured field List
+// (RFC 9651 Section 4.1.1). An empty list is serialized to an empty string,
+// which means the field should be omitted.
+func SerializeList(list List) (string, error) {
+	b := &strings.Builder{}
+	for i, member := range list {
+		if i > 0 {
+			write(b, ", ")
+		}
+		if err := writeSFMember(b, member); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// SerializeDictionary serializes dict as a structured field Dictionary
+// (RFC 9651 Section 4.1.2). An empty dict is serialized to an empty string,
+// which means the field should be omitted.
+func SerializeDictionary(dict Dictionary) (string, error) {
+	b := &strings.Builder{}
+	for i, member := range dict {
+		if i > 0 {
+			write(b, ", ")
+		}
+		if err := writeSFKey(b, member.Name); err != nil {
+			return "", err
+		}
+		if member.Value == true {
+			if err := writeSFParams(b, member.Params); err != nil {
+				return "", err
+			}
+			continue
+		}
+		write(b, "=")
+		if err := writeSFMember(b, member.Item); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+func writeSFMember(b *strings.Builder, member Item) error {
+	list, ok := member.Value.(InnerList)
+	if !ok {
+		return writeSFItem(b, member)
+	}
+	write(b, "(")
+	for i, item := range list {
+		if i > 0 {
+			write(b, " ")
+		}
+		if err := writeSFItem(b, item); err != nil {
+			return err
+		}
+	}
+	write(b, ")")
+	return writeSFParams(b, member.Params)
+}
+
+func writeSFItem(b *strings.Builder, item Item) error {
+	if err := writeSFBareItem(b, item.Value); err != nil {
+		return err
+	}
+	return writeSFParams(b, item.Params)
+}
+
+func writeSFParams(b *strings.Builder, params Params) error {
+	for _, param := range params {
+		write(b, ";")
+		if err := writeSFKey(b, param.Name); err != nil {
+			return err
+		}
+		if param.Value == true {
+			continue
+		}
+		write(b, "=")
+		if err := writeSFBareItem(b, param.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeSFKey(b *strings.Builder, key string) error {
+	if !isSFKey(key) {
+		return fmt.Errorf("bad structured field key %q", key)
+	}
+	write(b, key)
+	return nil
+}
+
+func isSFKey(key string) bool {
+	if key == "" || (!isLCAlpha(key[0]) && key[0] != '*') {
+		return false
+	}
+	for i := 1; i < len(key); i++ {
+		if !isKeyChar(key[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func writeSFBareItem(b *strings.Builder, value interface{}) error {
+	switch value := value.(type) {
+	case int:
+		return writeSFInteger(b, int64(value))
+	case int64:
+		return writeSFInteger(b, value)
+	case float64:
+		return writeSFDecimal(b, value)
+	case string:
+		return writeSFString(b, value)
+	case Token:
+		return writeSFToken(b, value)
+	case []byte:
+		write(b, ":", base64.StdEncoding.EncodeToString(value), ":")
+	case bool:
+		if value {
+			write(b, "?1")
+		} else {
+			write(b, "?0")
+		}
+	case time.Time:
+		write(b, "@")
+		return writeSFInteger(b, value.Unix())
+	case DisplayString:
+		writeSFDisplayString(b, value)
+	default:
+		return fmt.Errorf("cannot serialize %T as a structured field bare item", value)
+	}
+	return nil
+}
+
+func writeSFInteger(b *strings.Builder, n int64) error {
+	if n < -999999999999999 || n > 999999999999999 {
+		return fmt.Errorf("structured field integer out of range: %d", n)
+	}
+	write(b, strconv.FormatInt(n, 10))
+	return nil
+}
+
+func writeSFDecimal(b *strings.Builder, f float64) error {
+	f = math.RoundToEven(f*1000) / 1000
+	if math.IsNaN(f) || math.Abs(f) >= 1e12 {
+		return fmt.Errorf("structured field decimal out of range: %v", f)
+	}
+	s := strconv.FormatFloat(f, 'f', 3, 64)
+	s = strings.TrimRight(s, "0")
+	if strings.HasSuffix(s, ".") {
+		s += "0"
+	}
+	write(b, s)
+	return nil
+}
+
+func writeSFString(b *strings.Builder, s string) error {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7E {
+			return fmt.Errorf("cannot serialize %q as a structured field string", s)
+		}
+	}
+	writeQuoted(b, s)
+	return nil
+}
+
+func writeSFToken(b *strings.Builder, t Token) error {
+	ok := t != "" && (t[0] == '*' || isAlpha(t[0]))
+	for i := 1; ok && i < len(t); i++ {
+		ok = byteClass[t[i]] == cTokenOK || t[i] == ':' || t[i] == '/'
+	}
+	if !ok {
+		return fmt.Errorf("bad structured field token %q", t)
+	}
+	write(b, string(t))
+	return nil
+}
+
+func writeSFDisplayString(b *strings.Builder, s DisplayString) {
+	write(b, `%"`)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '%' || c == '"' || c < 0x20 || c > 0x7E {
+			write(b, "%", strconv.FormatUint(uint64(c)>>4, 16),
+				strconv.FormatUint(uint64(c)&0xF, 16))
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	write(b, `"`)
+}
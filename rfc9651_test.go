@@ -0,0 +1,324 @@
+package httpheader
+
+import (
+	"encoding/base32"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ExampleParseDictionary() {
+	header := http.Header{"Example-Dict": {`a=?0, b, c;foo=bar`, `d=(1 2.5 "x")`}}
+	dict, err := ParseDictionary(header["Example-Dict"])
+	if err != nil {
+		// Treat the field as absent.
+		return
+	}
+	c, _ := dict.Get("c")
+	foo, _ := c.Params.Get("foo")
+	fmt.Printf("%#v\n", foo)
+	d, _ := dict.Get("d")
+	fmt.Printf("%#v\n", d.Value)
+	// Output: "bar"
+	// httpheader.InnerList{httpheader.Item{Value:1, Params:httpheader.Params(nil)}, httpheader.Item{Value:2.5, Params:httpheader.Params(nil)}, httpheader.Item{Value:"x", Params:httpheader.Params(nil)}}
+}
+
+func ExampleSerializeList() {
+	v, _ := SerializeList(List{
+		{Value: Token("gzip"), Params: Params{{"q", 0.5}}},
+		{Value: InnerList{{Value: "foo"}, {Value: []byte("bar")}}},
+	})
+	fmt.Println(v)
+	// Output: gzip;q=0.5, ("foo" :YmFy:)
+}
+
+// sfTest is a test case in the format of the structured-field-tests suite
+// (https://github.com/httpwg/structured-field-tests).
+type sfTest struct {
+	Name       string
+	Raw        []string
+	HeaderType string `json:"header_type"`
+	Expected   interface{}
+	MustFail   bool `json:"must_fail"`
+	CanFail    bool `json:"can_fail"`
+	Canonical  []string
+}
+
+// sfSuiteDir is the HTTP Working Group's structured-field-tests suite,
+// vendored with its license by "make structured-field-tests".
+const sfSuiteDir = "testdata/structured-field-tests"
+
+// sfExtraDir holds extra vectors of our own, transcribed from RFC 9651.
+const sfExtraDir = "testdata/rfc9651-vectors"
+
+func TestStructuredFieldVectors(t *testing.T) {
+	if license, _ := filepath.Glob(filepath.Join(sfSuiteDir, "LICENSE*")); license == nil {
+		t.Fatalf("%s is missing or has no license; "+
+			"vendor it with make structured-field-tests", sfSuiteDir)
+	}
+	var files []string
+	for _, dir := range []string{sfSuiteDir, sfExtraDir} {
+		dirFiles, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+		serialisationFiles, _ :=
+			filepath.Glob(filepath.Join(dir, "serialisation-tests", "*.json"))
+		if len(dirFiles) == 0 {
+			t.Fatalf("no test vectors found in %s", dir)
+		}
+		files = append(files, dirFiles...)
+		files = append(files, serialisationFiles...)
+	}
+	for _, file := range files {
+		for _, test := range loadSFTests(t, file) {
+			name := filepath.Base(filepath.Dir(file)) + "/" +
+				filepath.Base(file) + "/" + test.Name
+			t.Run(name, func(t *testing.T) {
+				if test.Raw == nil {
+					checkSFSerialize(t, test)
+				} else {
+					checkSFParse(t, test)
+				}
+			})
+		}
+	}
+}
+
+func loadSFTests(t *testing.T, file string) []sfTest {
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	dec.UseNumber()
+	var tests []sfTest
+	if err := dec.Decode(&tests); err != nil {
+		t.Fatalf("%s: %v", file, err)
+	}
+	return tests
+}
+
+func checkSFParse(t *testing.T, test sfTest) {
+	var actual interface{}
+	var err error
+	switch test.HeaderType {
+	case "item":
+		actual, err = ParseItem(test.Raw)
+	case "list":
+		actual, err = ParseList(test.Raw)
+	case "dictionary":
+		actual, err = ParseDictionary(test.Raw)
+	default:
+		t.Fatalf("unknown header type %q", test.HeaderType)
+	}
+	switch {
+	case err != nil && (test.MustFail || test.CanFail):
+		return
+	case err != nil:
+		t.Fatalf("parsing %q: %v", test.Raw, err)
+	case test.MustFail:
+		t.Fatalf("parsing %q: expected failure, got %#v", test.Raw, actual)
+	}
+	expected := sfFromJSON(test.HeaderType, test.Expected)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("parsing %q:\nexpected: %#v\nactual:   %#v",
+			test.Raw, expected, actual)
+	}
+	canonical := test.Canonical
+	if canonical == nil {
+		canonical = test.Raw
+	}
+	checkSFSerialized(t, actual, strings.Join(canonical, ", "), false)
+}
+
+func checkSFSerialize(t *testing.T, test sfTest) {
+	input := sfFromJSON(test.HeaderType, test.Expected)
+	checkSFSerialized(t, input, strings.Join(test.Canonical, ", "), test.MustFail)
+}
+
+func checkSFSerialized(t *testing.T, input interface{}, expected string, mustFail bool) {
+	var actual string
+	var err error
+	switch input := input.(type) {
+	case Item:
+		actual, err = SerializeItem(input)
+	case List:
+		actual, err = SerializeList(input)
+	case Dictionary:
+		actual, err = SerializeDictionary(input)
+	}
+	switch {
+	case err != nil && mustFail:
+		return
+	case err != nil:
+		t.Fatalf("serializing %#v: %v", input, err)
+	case mustFail:
+		t.Fatalf("serializing %#v: expected failure, got %q", input, actual)
+	case actual != expected:
+		t.Fatalf("serializing %#v:\nexpected: %q\nactual:   %q",
+			input, expected, actual)
+	}
+}
+
+// sfFromJSON converts the JSON representation of a structured field
+// used by structured-field-tests into the types of this package.
+func sfFromJSON(headerType string, v interface{}) interface{} {
+	switch headerType {
+	case "item":
+		return sfItemFromJSON(v)
+	case "list":
+		var list List
+		for _, member := range v.([]interface{}) {
+			list = append(list, sfItemFromJSON(member))
+		}
+		return list
+	case "dictionary":
+		var dict Dictionary
+		for _, member := range v.([]interface{}) {
+			pair := member.([]interface{})
+			dict = append(dict, DictMember{
+				Name: pair[0].(string),
+				Item: sfItemFromJSON(pair[1]),
+			})
+		}
+		return dict
+	default:
+		panic(fmt.Sprintf("unknown header type %q", headerType))
+	}
+}
+
+func sfItemFromJSON(v interface{}) Item {
+	pair := v.([]interface{})
+	var item Item
+	if inner, ok := pair[0].([]interface{}); ok {
+		list := InnerList{}
+		for _, innerItem := range inner {
+			list = append(list, sfItemFromJSON(innerItem))
+		}
+		item.Value = list
+	} else {
+		item.Value = sfBareItemFromJSON(pair[0])
+	}
+	for _, param := range pair[1].([]interface{}) {
+		nameValue := param.([]interface{})
+		item.Params = append(item.Params, Param{
+			Name:  nameValue[0].(string),
+			Value: sfBareItemFromJSON(nameValue[1]),
+		})
+	}
+	return item
+}
+
+func sfBareItemFromJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			f, _ := v.Float64()
+			return f
+		}
+		n, _ := v.Int64()
+		return n
+	case string, bool:
+		return v
+	case map[string]interface{}:
+		value := v["value"]
+		switch v["__type"] {
+		case "token":
+			return Token(value.(string))
+		case "displaystring":
+			return DisplayString(value.(string))
+		case "binary":
+			b, err := base32.StdEncoding.DecodeString(value.(string))
+			if err != nil {
+				panic(err)
+			}
+			return b
+		case "date":
+			n, _ := value.(json.Number).Int64()
+			return time.Unix(n, 0).UTC()
+		}
+	}
+	panic(fmt.Sprintf("cannot convert %#v from JSON", v))
+}
+
+func TestParseStructuredFieldFailure(t *testing.T) {
+	// On failure, nothing is returned, not even the members parsed so far
+	// (RFC 9651 Section 4.2).
+	tests := []struct {
+		values []string
+		parse  func([]string) (interface{}, error)
+		zero   interface{}
+	}{
+		{
+			[]string{"a, b, ab\xff"},
+			func(v []string) (interface{}, error) { return ParseList(v) },
+			List(nil),
+		},
+		{
+			[]string{"a, b", "ab\xff"},
+			func(v []string) (interface{}, error) { return ParseList(v) },
+			List(nil),
+		},
+		{
+			[]string{"a, b, c d"},
+			func(v []string) (interface{}, error) { return ParseList(v) },
+			List(nil),
+		},
+		{
+			[]string{"a=1, b=ab\xff"},
+			func(v []string) (interface{}, error) { return ParseDictionary(v) },
+			Dictionary(nil),
+		},
+		{
+			[]string{"a=1, b=2,"},
+			func(v []string) (interface{}, error) { return ParseDictionary(v) },
+			Dictionary(nil),
+		},
+		{
+			[]string{"ab\xff"},
+			func(v []string) (interface{}, error) { return ParseItem(v) },
+			Item{},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			result, err := test.parse(test.values)
+			if err == nil || !reflect.DeepEqual(result, test.zero) {
+				t.Errorf("parsing %q: expected failure and no result, got %#v, %v",
+					test.values, result, err)
+			}
+		})
+	}
+}
+
+func TestSerializeTokenHighByte(t *testing.T) {
+	for _, token := range []Token{"ab\xff", "\xff", "a\x80b"} {
+		if v, err := SerializeItem(Item{Value: token}); err == nil {
+			t.Errorf("serializing %q: expected failure, got %q", token, v)
+		}
+	}
+}
+
+func TestStructuredFieldFuzz(t *testing.T) {
+	checkFuzz(t, "Example",
+		func(h http.Header) (Item, error) { return ParseItem(h["Example"]) },
+		nil)
+	checkFuzz(t, "Example",
+		func(h http.Header) (List, error) { return ParseList(h["Example"]) },
+		nil)
+	checkFuzz(t, "Example",
+		func(h http.Header) (Dictionary, error) { return ParseDictionary(h["Example"]) },
+		nil)
+}
+
+func BenchmarkParseDictionary(b *testing.B) {
+	values := []string{`a=(1 2), b=3, c=4;aa=bb, d=(5 6);valid`, `u=3, i`}
+	for i := 0; i < b.N; i++ {
+		ParseDictionary(values)
+	}
+}
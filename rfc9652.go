@@ -0,0 +1,252 @@
+package httpheader
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// A LinkTemplateElem represents a templated link from the Link-Template header
+// (RFC 9652). Unlike in LinkElem, the target and anchor are URI Templates
+// (RFC 6570), which are turned into a LinkElem by Expand. Standard target
+// attributes are stored in the corresponding fields; any extension attributes,
+// including hreflang, are stored in Ext.
+type LinkTemplateElem struct {
+	Template string // always non-empty
+	Anchor   string // usually empty
+	Rel      string
+	// VarBase is the URI reference that variable names are resolved against
+	// to obtain URIs identifying their semantics (RFC 9652 Section 2.1).
+	// It is informational only, and not used by Expand. Usually empty.
+	VarBase string
+	Title   string
+	Type    string
+	Media   string
+	Ext     map[string]string
+}
+
+// LinkTemplate parses the Link-Template header from h (RFC 9652).
+// If the header is not a valid structured field List (RFC 9651), it is ignored
+// entirely. Members that are not Strings, or that have no rel, are skipped.
+//
+// As in Link, multiple relation types in one member produce multiple
+// LinkTemplateElems, relation types are normalized, and Type is lowercased.
+// Extension attributes are stored in Ext as text: Boolean true becomes
+// an empty string, false is skipped, and other non-string values are stored
+// in their structured field serialization.
+func LinkTemplate(h http.Header) []LinkTemplateElem {
+	return DefaultLimits().LinkTemplate(h)
+}
+
+// LinkTemplate is like the LinkTemplate function, but applies l instead of
+// DefaultLimits.
+func (l Limits) LinkTemplate(h http.Header) []LinkTemplateElem {
+	values := h["Link-Template"]
+	if values == nil {
+		return nil
+	}
+	list, err := l.ParseList(values)
+	if err != nil {
+		return nil
+	}
+	elems := make([]LinkTemplateElem, 0, len(list))
+	for _, item := range list {
+		template, ok := item.Value.(string)
+		if !ok || template == "" {
+			continue
+		}
+		elem := LinkTemplateElem{Template: template}
+		for _, param := range item.Params {
+			value, ok := sfText(param.Value)
+			if !ok {
+				continue
+			}
+			switch param.Name {
+			case "rel":
+				elem.Rel = value
+			case "anchor":
+				elem.Anchor = value
+			case "var-base":
+				elem.VarBase = value
+			case "title":
+				elem.Title = value
+			case "type":
+				elem.Type = strings.ToLower(value)
+			case "media":
+				elem.Media = value
+			default:
+				if elem.Ext == nil {
+					elem.Ext = make(map[string]string)
+				}
+				elem.Ext[param.Name] = value
+			}
+		}
+		for _, relType := range strings.Fields(elem.Rel) {
+			elems = append(elems, elem)
+			elems[len(elems)-1].Rel = normalizeRel(relType)
+		}
+	}
+	return elems
+}
+
+// StrictLinkTemplate is like LinkTemplate, but calls Check first.
+func StrictLinkTemplate(h http.Header) ([]LinkTemplateElem, error) {
+	return DefaultLimits().StrictLinkTemplate(h)
+}
+
+// StrictLinkTemplate is like the StrictLinkTemplate function, but applies l
+// instead of DefaultLimits.
+func (l Limits) StrictLinkTemplate(h http.Header) ([]LinkTemplateElem, error) {
+	if err := l.Check(h, "Link-Template"); err != nil {
+		return nil, err
+	}
+	return l.LinkTemplate(h), nil
+}
+
+// sfText converts the bare item v of a parameter into text.
+func sfText(v interface{}) (text string, ok bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case Token:
+		return string(v), true
+	case DisplayString:
+		return string(v), true
+	case bool:
+		return "", v
+	default:
+		b := &strings.Builder{}
+		if writeSFBareItem(b, v) != nil {
+			return "", false
+		}
+		return b.String(), true
+	}
+}
+
+// SetLinkTemplate replaces the Link-Template header in h (RFC 9652).
+// Text that cannot be represented as a structured field String is written
+// as a Display String. Extension attributes from Ext are written in order of
+// their lowercased names, with empty values written as Boolean true; of names
+// that differ only in case, the lowercase one is written, or else the first
+// in sorted order. Those with names that are not valid structured field keys
+// are skipped, as are elems with a Template that is not valid ASCII.
+func SetLinkTemplate(h http.Header, elems []LinkTemplateElem) {
+	if len(elems) == 0 {
+		h.Del("Link-Template")
+		return
+	}
+	members := make([]string, 0, len(elems))
+	for _, elem := range elems {
+		item := Item{Value: elem.Template}
+		addParam := func(name, value string) {
+			if value != "" {
+				item.Params = append(item.Params, Param{name, sfTextValue(value)})
+			}
+		}
+		addParam("rel", elem.Rel)
+		addParam("anchor", elem.Anchor)
+		addParam("var-base", elem.VarBase)
+		addParam("title", elem.Title)
+		addParam("type", elem.Type)
+		addParam("media", elem.Media)
+		// Keys are lowercase, so names differing only in case would be
+		// duplicates; keep the one that is already lowercase, if any.
+		ext := make(map[string]string, len(elem.Ext))
+		for _, name := range sortedKeys(elem.Ext) {
+			lower := strings.ToLower(name)
+			if _, seen := ext[lower]; seen && name != lower {
+				continue
+			}
+			ext[lower] = elem.Ext[name]
+		}
+		for _, name := range sortedKeys(ext) {
+			value := ext[name]
+			switch name {
+			case "rel", "anchor", "var-base", "title", "type", "media":
+				continue
+			}
+			if !isSFKey(name) {
+				continue
+			}
+			if value == "" {
+				item.Params = append(item.Params, Param{name, true})
+			} else {
+				item.Params = append(item.Params, Param{name, sfTextValue(value)})
+			}
+		}
+		member, err := SerializeItem(item)
+		if err != nil {
+			continue
+		}
+		members = append(members, member)
+	}
+	if len(members) == 0 {
+		h.Del("Link-Template")
+		return
+	}
+	h.Set("Link-Template", strings.Join(members, ", "))
+}
+
+// sfTextValue returns s as a String if possible, otherwise as a DisplayString.
+func sfTextValue(s string) interface{} {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7E {
+			return DisplayString(s)
+		}
+	}
+	return s
+}
+
+// Expand returns a LinkElem with the Target and Anchor obtained by expanding
+// the corresponding templates with vars (see ExpandURITemplate), and resolving
+// them against base, which is the URL that the Link-Template header was
+// obtained from. If base is nil, relative URLs are left unresolved.
+// An hreflang extension attribute is moved to HrefLang.
+// VarBase is not applied: vars are keyed by variable names as they appear
+// in the templates, and it is up to the caller to resolve them against VarBase
+// when looking up what they mean.
+// An error is returned if a template cannot be expanded or does not expand
+// into a valid URL.
+func (elem LinkTemplateElem) Expand(base *url.URL, vars map[string]interface{}) (LinkElem, error) {
+	link := LinkElem{
+		Rel:   elem.Rel,
+		Title: elem.Title,
+		Type:  elem.Type,
+		Media: elem.Media,
+	}
+	var err error
+	if link.Target, err = expandURL(elem.Template, base, vars); err != nil {
+		return LinkElem{}, err
+	}
+	if elem.Anchor != "" {
+		if link.Anchor, err = expandURL(elem.Anchor, base, vars); err != nil {
+			return LinkElem{}, err
+		}
+	}
+	for name, value := range elem.Ext {
+		if name == "hreflang" {
+			link.HrefLang = []string{strings.ToLower(value)}
+			continue
+		}
+		if link.Ext == nil {
+			link.Ext = make(map[string]string)
+		}
+		link.Ext[name] = value
+	}
+	return link, nil
+}
+
+func expandURL(template string, base *url.URL, vars map[string]interface{}) (*url.URL, error) {
+	s, err := ExpandURITemplate(template, vars)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if base == nil {
+		return u, nil
+	}
+	return base.ResolveReference(u), nil
+}
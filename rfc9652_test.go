@@ -0,0 +1,260 @@
+package httpheader
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func ExampleLinkTemplate() {
+	header := http.Header{"Link-Template": {
+		`"/books/{book_id}/author"; rel="author"; anchor="#{book_id}"`,
+	}}
+	base, _ := url.Parse("https://example.org/books/")
+	for _, elem := range LinkTemplate(header) {
+		link, err := elem.Expand(base, map[string]interface{}{"book_id": "1234"})
+		if err != nil {
+			continue
+		}
+		fmt.Println(link.Rel, link.Target, link.Anchor)
+	}
+	// Output: author https://example.org/books/1234/author https://example.org/books/#1234
+}
+
+func TestLinkTemplate(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		result []LinkTemplateElem
+	}{
+		// Valid headers.
+		{
+			http.Header{},
+			nil,
+		},
+		{
+			http.Header{"Link-Template": {`"/{username}"; rel="item"`}},
+			[]LinkTemplateElem{{Template: "/{username}", Rel: "item"}},
+		},
+		{
+			http.Header{"Link-Template": {
+				`"/widgets/{widget_id}"; rel="https://example.org/rel/Widget"; var-base="https://example.org/vars/"`,
+			}},
+			[]LinkTemplateElem{
+				{
+					Template: "/widgets/{widget_id}",
+					Rel:      "https://example.org/rel/Widget",
+					VarBase:  "https://example.org/vars/",
+				},
+			},
+		},
+		{
+			http.Header{"Link-Template": {
+				`"/s{?q}"; rel="Search Alternate"; title=%"caf%c3%a9"; type="Text/HTML"`,
+				`"/feed"; rel=alternate; media=screen; hreflang=en; sz=123; x; y=?0`,
+			}},
+			[]LinkTemplateElem{
+				{Template: "/s{?q}", Rel: "search", Title: "café", Type: "text/html"},
+				{Template: "/s{?q}", Rel: "alternate", Title: "café", Type: "text/html"},
+				{
+					Template: "/feed",
+					Rel:      "alternate",
+					Media:    "screen",
+					Ext:      map[string]string{"hreflang": "en", "sz": "123", "x": ""},
+				},
+			},
+		},
+
+		// Invalid headers.
+		{
+			http.Header{"Link-Template": {`"/{username}"; rel="item", `}},
+			nil,
+		},
+		{
+			http.Header{"Link-Template": {`</{username}>; rel="item"`}},
+			nil,
+		},
+		{
+			http.Header{"Link-Template": {
+				`"/a"; rel="item", "/b", ("/c"); rel="item", ""; rel="item", "/d"; rel=?1`,
+			}},
+			[]LinkTemplateElem{{Template: "/a", Rel: "item"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			result := LinkTemplate(test.header)
+			if len(result) == 0 && len(test.result) == 0 {
+				return
+			}
+			checkParse(t, test.header, test.result, result)
+		})
+	}
+}
+
+func TestSetLinkTemplate(t *testing.T) {
+	tests := []struct {
+		input  []LinkTemplateElem
+		result http.Header
+	}{
+		{
+			[]LinkTemplateElem{},
+			http.Header{},
+		},
+		{
+			[]LinkTemplateElem{
+				{Template: "/{username}", Rel: "item"},
+				{
+					Template: "/widgets/{widget_id}",
+					Rel:      "https://example.org/rel/widget",
+					VarBase:  "https://example.org/vars/",
+				},
+			},
+			http.Header{"Link-Template": {
+				`"/{username}";rel="item", "/widgets/{widget_id}";rel="https://example.org/rel/widget";var-base="https://example.org/vars/"`,
+			}},
+		},
+		{
+			[]LinkTemplateElem{
+				{
+					Template: "/books/{id}",
+					Anchor:   "#{id}",
+					Rel:      "author",
+					Title:    "Café \"Books\"",
+					Type:     "text/html",
+					Media:    "screen",
+					Ext: map[string]string{
+						"hreflang": "en",
+						"X-Flag":   "",
+						"Rel":      "ignored",
+						"bad key!": "skipped",
+					},
+				},
+			},
+			http.Header{"Link-Template": {
+				`"/books/{id}";rel="author";anchor="#{id}";title=%"Caf%c3%a9 %22Books%22";type="text/html";media="screen";hreflang="en";x-flag`,
+			}},
+		},
+		{
+			[]LinkTemplateElem{
+				{
+					Template: "/{id}",
+					Rel:      "item",
+					Ext:      map[string]string{"Foo": "1", "foo": "2", "BAR": "3", "Bar": "4"},
+				},
+			},
+			http.Header{"Link-Template": {`"/{id}";rel="item";bar="3";foo="2"`}},
+		},
+		{
+			[]LinkTemplateElem{
+				{Template: "/café", Rel: "item"},
+			},
+			http.Header{},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			header := http.Header{"Link-Template": {`"/old"; rel="item"`}}
+			SetLinkTemplate(header, test.input)
+			checkGenerate(t, test.input, test.result, header)
+		})
+	}
+}
+
+func TestLinkTemplateExpand(t *testing.T) {
+	base := U("https://example.org/api/")
+	tests := []struct {
+		elem   LinkTemplateElem
+		vars   map[string]interface{}
+		result LinkElem
+	}{
+		{
+			LinkTemplateElem{Template: "search{?q,page}", Rel: "search"},
+			map[string]interface{}{"q": "a&b"},
+			LinkElem{Rel: "search", Target: U("https://example.org/api/search?q=a%26b")},
+		},
+		{
+			LinkTemplateElem{
+				Template: "{+host}/users{/id}",
+				Anchor:   "/users/{id}#{id}",
+				Rel:      "item",
+				Title:    "User",
+				Type:     "application/json",
+				Ext:      map[string]string{"hreflang": "EN", "x": ""},
+			},
+			map[string]interface{}{"host": "https://other.example", "id": "42"},
+			LinkElem{
+				Anchor:   U("https://example.org/users/42#42"),
+				Rel:      "item",
+				Target:   U("https://other.example/users/42"),
+				Title:    "User",
+				Type:     "application/json",
+				HrefLang: []string{"en"},
+				Ext:      map[string]string{"x": ""},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			link, err := test.elem.Expand(base, test.vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkParse(t, nil, test.result, link)
+		})
+	}
+}
+
+func TestLinkTemplateExpandNilBase(t *testing.T) {
+	elem := LinkTemplateElem{Template: "/books/{id}", Anchor: "#{id}", Rel: "item"}
+	link, err := elem.Expand(nil, map[string]interface{}{"id": "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkParse(t, nil,
+		LinkElem{Anchor: U("#42"), Rel: "item", Target: U("/books/42")}, link)
+}
+
+func TestLinkTemplateExpandErrors(t *testing.T) {
+	base := U("https://example.org/")
+	for _, elem := range []LinkTemplateElem{
+		{Template: "/{id", Rel: "item"},
+		{Template: "/{id}", Anchor: "{=id}", Rel: "item"},
+		{Template: "{+id}", Rel: "item"},
+	} {
+		t.Run(elem.Template, func(t *testing.T) {
+			_, err := elem.Expand(base, map[string]interface{}{"id": "http://[::1"})
+			if err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestLinkTemplateFuzz(t *testing.T) {
+	checkFuzz(t, "Link-Template", LinkTemplate, SetLinkTemplate)
+}
+
+func TestLinkTemplateRoundTrip(t *testing.T) {
+	checkRoundTrip(t, SetLinkTemplate, LinkTemplate,
+		[]LinkTemplateElem{{
+			Template: "URL",
+			Anchor:   "URL | empty",
+			Rel:      "lower token | lower URL",
+			VarBase:  "URL | empty",
+			Title:    "token | UTF-8 | empty",
+			Type:     "lower token/token | empty",
+			Media:    "token | empty",
+		}},
+	)
+}
+
+func BenchmarkLinkTemplate(b *testing.B) {
+	header := http.Header{"Link-Template": {
+		`"/books/{book_id}/author"; rel="author"; anchor="#{book_id}"`,
+		`"/widgets/{widget_id}"; rel="https://example.org/rel/widget"; var-base="https://example.org/vars/"`,
+	}}
+	for i := 0; i < b.N; i++ {
+		LinkTemplate(header)
+	}
+}
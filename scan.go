@@ -0,0 +1,89 @@
+package httpheader
+
+import (
+	"net/http"
+)
+
+// A Scanner walks the elements of a comma-separated list header
+// (RFC 9110 Section 5.6.1) and their parameters directly over the header's
+// field values, without building slices or maps. It is a low-level
+// alternative to functions like Accept or CacheControl for hot paths
+// that only look for a particular element or directive.
+//
+// Each element is seen as a name with an optional value, such as
+// "max-age=60" or "gzip", followed by semicolon-separated parameters,
+// such as ";q=0.5". Strings returned by a Scanner are substrings of
+// the header. Names are not lowercased, so compare them with strings.EqualFold.
+// Values are unquoted, which allocates only if a quoted string
+// contains quoted pairs (backslash escapes).
+//
+// Like the other parsers in this package, a Scanner tolerates malformed input.
+type Scanner struct {
+	rest        string // unconsumed text of the current field value
+	vs          []string
+	name, value string
+	pname, pval string
+	eq, peq     bool
+}
+
+// ScanList returns a Scanner over the header with the given name in h.
+// The name must be in canonical form, such as "Accept-Encoding".
+func ScanList(h http.Header, name string) Scanner {
+	return Scanner{vs: h[name]}
+}
+
+// Next advances s to the next element, reporting whether there is one.
+func (s *Scanner) Next() bool {
+	for s.NextParam() { // skip the rest of the current element
+	}
+	var v string
+	v, s.vs = iterElems(s.rest, s.vs)
+	if v == "" {
+		s.name, s.value, s.eq, s.rest = "", "", false, ""
+		return false
+	}
+	s.name, s.value, s.eq, s.rest = consumeRawParamEq(v)
+	s.pname, s.pval, s.peq = "", "", false
+	return true
+}
+
+// Name returns the name of the current element, such as "max-age" or
+// "text/html".
+func (s *Scanner) Name() string {
+	return s.name
+}
+
+// Value returns the value of the current element, such as "60" for
+// "max-age=60", or an empty string if there is none.
+func (s *Scanner) Value() string {
+	return s.value
+}
+
+// HasValue reports whether the current element has a value, even an empty
+// one: it is true for "foo=" and `foo=""`, but false for "foo".
+func (s *Scanner) HasValue() bool {
+	return s.eq
+}
+
+// NextParam advances s to the next parameter of the current element,
+// reporting whether there is one.
+func (s *Scanner) NextParam() bool {
+	s.pname, s.pval, s.peq, s.rest = consumeRawParamEq(s.rest)
+	return s.pname != ""
+}
+
+// ParamName returns the name of the current parameter, such as "q".
+func (s *Scanner) ParamName() string {
+	return s.pname
+}
+
+// ParamValue returns the value of the current parameter, such as "0.5",
+// or an empty string if there is none.
+func (s *Scanner) ParamValue() string {
+	return s.pval
+}
+
+// HasParamValue is like HasValue but for the current parameter.
+func (s *Scanner) HasParamValue() bool {
+	return s.peq
+}
@@ -0,0 +1,189 @@
+package httpheader
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func ExampleScanList() {
+	header := http.Header{"Accept-Encoding": {"br;q=1.0, GZip;q=0.8, *;q=0.1"}}
+	s := ScanList(header, "Accept-Encoding")
+	for s.Next() {
+		if strings.EqualFold(s.Name(), "gzip") {
+			for s.NextParam() {
+				fmt.Println(s.ParamName(), s.ParamValue())
+			}
+		}
+	}
+	// Output: q 0.8
+}
+
+func TestScanList(t *testing.T) {
+	type param struct{ Name, Value string }
+	type elem struct {
+		Name, Value string
+		Params      []param
+	}
+	tests := []struct {
+		header http.Header
+		result []elem
+	}{
+		{
+			http.Header{},
+			nil,
+		},
+		{
+			http.Header{"Foo": {""}},
+			nil,
+		},
+		{
+			http.Header{"Foo": {" , ,", "gzip", ",,Br ,"}},
+			[]elem{{Name: "gzip"}, {Name: "Br"}},
+		},
+		{
+			http.Header{"Foo": {`max-age=60, no-cache="Set-Cookie, Set-Cookie2",private`}},
+			[]elem{
+				{Name: "max-age", Value: "60"},
+				{Name: "no-cache", Value: "Set-Cookie, Set-Cookie2"},
+				{Name: "private"},
+			},
+		},
+		{
+			http.Header{"Foo": {`text/html;Level=1 ; q = 0.5, */*;q=0.1;foo="b\"ar", x`}},
+			[]elem{
+				{Name: "text/html", Params: []param{{"Level", "1"}, {"q", "0.5"}}},
+				{Name: "*/*", Params: []param{{"q", "0.1"}, {"foo", `b"ar`}}},
+				{Name: "x"},
+			},
+		},
+		{
+			http.Header{"Foo": {`for="[2001:db8::1]";proto=https, for=unknown`}},
+			[]elem{
+				{Name: "for", Value: "[2001:db8::1]", Params: []param{{"proto", "https"}}},
+				{Name: "for", Value: "unknown"},
+			},
+		},
+
+		// Invalid headers.
+		{
+			http.Header{"Foo": {`a;;b=, =c, "d, e" f`}},
+			[]elem{
+				{Name: "a", Params: []param{{"b", ""}}},
+				{Name: ""},
+				{Name: `"d`},
+				{Name: "e\"", Params: []param{{"f", ""}}},
+			},
+		},
+		{
+			http.Header{"Foo": {`a="unterminated, b`, "c"}},
+			[]elem{{Name: "a", Value: "unterminated, b"}, {Name: "c"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			var result []elem
+			s := ScanList(test.header, "Foo")
+			for s.Next() {
+				e := elem{Name: s.Name(), Value: s.Value()}
+				for s.NextParam() {
+					e.Params = append(e.Params, param{s.ParamName(), s.ParamValue()})
+				}
+				result = append(result, e)
+			}
+			checkParse(t, test.header, test.result, result)
+		})
+	}
+}
+
+func TestScanListHasValue(t *testing.T) {
+	header := http.Header{"Foo": {`a="";b;c=1, d, e=`}}
+	var elems, params []bool
+	s := ScanList(header, "Foo")
+	for s.Next() {
+		elems = append(elems, s.HasValue())
+		for s.NextParam() {
+			params = append(params, s.HasParamValue())
+		}
+	}
+	checkParse(t, header,
+		[]bool{true, false, true}, elems,
+		[]bool{false, true}, params,
+	)
+}
+
+func TestScanListSkipsParams(t *testing.T) {
+	header := http.Header{"Accept": {acceptComplex}}
+	var names []string
+	s := ScanList(header, "Accept")
+	for s.Next() {
+		names = append(names, s.Name())
+	}
+	checkParse(t, header, []string{
+		"application/x.my-custom+json",
+		"application/vnd.api+json",
+		"application/vnd.api+json",
+		"application/json",
+		"text/*",
+	}, names)
+}
+
+func TestScanListAllocs(t *testing.T) {
+	header := http.Header{
+		"Accept":        {acceptComplex},
+		"Cache-Control": {`private="Set-Cookie", max-age=900`, "no-transform"},
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		for _, name := range []string{"Accept", "Cache-Control"} {
+			s := ScanList(header, name)
+			for s.Next() {
+				for s.NextParam() {
+				}
+			}
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
+
+func TestScanListFuzz(t *testing.T) {
+	checkFuzz(t, "Foo", func(h http.Header) int {
+		n := 0
+		s := ScanList(h, "Foo")
+		for s.Next() {
+			for s.NextParam() {
+				n++
+			}
+			n++
+		}
+		return n
+	}, nil)
+}
+
+func BenchmarkScanListAccept(b *testing.B) {
+	header := http.Header{"Accept": {acceptComplex}}
+	for i := 0; i < b.N; i++ {
+		s := ScanList(header, "Accept")
+		for s.Next() {
+			for s.NextParam() {
+			}
+		}
+	}
+}
+
+func BenchmarkScanListCacheControl(b *testing.B) {
+	header := http.Header{"Cache-Control": {
+		`private="Set-Cookie", max-age=900, s-maxage=600, stale-if-error=30`,
+		`no-transform, must-revalidate`,
+	}}
+	for i := 0; i < b.N; i++ {
+		s := ScanList(header, "Cache-Control")
+		for s.Next() {
+			if strings.EqualFold(s.Name(), "no-store") {
+				break
+			}
+		}
+	}
+}
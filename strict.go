@@ -0,0 +1,790 @@
+package httpheader
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// A SyntaxError describes a violation of a header's grammar found by Check.
+type SyntaxError struct {
+	Header string // canonical name of the header
+	Line   int    // number of the offending field line, counting from 1
+	Offset int    // byte offset into that line
+	Msg    string // description of the violation
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("bad %s header at line %d, offset %d: %s",
+		e.Header, e.Line, e.Offset, e.Msg)
+}
+
+// Check validates the given headers in h against their grammar, returning
+// a *SyntaxError for the first violation found, or nil if there are none.
+// If no names are given, all headers in h are checked, in order of name.
+//
+// Check is meant to be called before the lenient parsing functions of this
+// package by servers that must reject malformed requests, such as with
+// 400 (Bad Request). For every header that this package parses, a function
+// such as StrictAccept does both in one call: if the header is malformed,
+// it returns zero values and the *SyntaxError. All headers covered by this
+// package are checked against their full grammar, including that singleton
+// headers like Content-Type occur only once. For other headers, Check only
+// rejects control characters. See also CheckRFC9110.
+func Check(h http.Header, names ...string) error {
+	return DefaultLimits().Check(h, names...)
+}
+
+// Check is like the Check function, but applies l instead of DefaultLimits.
+func (l Limits) Check(h http.Header, names ...string) error {
+	return l.check(h, false, names)
+}
+
+// CheckRFC9110 is like Check, but applies the rules of RFC 9110 where they
+// differ from RFC 7230 through RFC 7235: it reports parameters after
+// a q weight in Accept and TE, and allows an empty Vary list.
+func CheckRFC9110(h http.Header, names ...string) error {
+	return DefaultLimits().CheckRFC9110(h, names...)
+}
+
+// CheckRFC9110 is like the CheckRFC9110 function, but applies l instead
+// of DefaultLimits.
+func (l Limits) CheckRFC9110(h http.Header, names ...string) error {
+	return l.check(h, true, names)
+}
+
+func (l Limits) check(h http.Header, rfc9110 bool, names []string) error {
+	if len(names) == 0 {
+		for name := range h {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		if values := h[name]; values != nil {
+			if err := l.checkHeader(name, values, rfc9110); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// A grammar describes how to check a header. If list is true, the header is
+// a comma-separated list (RFC 9110 Section 5.6.1) of elements checked by elem.
+// Otherwise, each field line is exactly one elem.
+type grammar struct {
+	elem      func(c *checker) error
+	list      bool
+	nonEmpty  bool // list must have at least one element
+	singleton bool // header must not occur more than once
+	extValues bool // parameters named with an asterisk are RFC 8187 ext-values
+	weights   bool // q parameters are weights (RFC 9110 Section 12.4.2)
+	sf        func(l Limits, values []string) error
+}
+
+var grammars map[string]grammar
+
+func init() {
+	grammars = map[string]grammar{
+		"Accept":              {elem: checkMediaRange, list: true, weights: true},
+		"Accept-Ranges":       {elem: checkToken, list: true, nonEmpty: true},
+		"Allow":               {elem: checkToken, list: true},
+		"Authorization":       {elem: checkCredentials, singleton: true},
+		"Cache-Control":       {elem: checkDirective, list: true, nonEmpty: true},
+		"Connection":          {elem: checkToken, list: true, nonEmpty: true},
+		"Content-Disposition": {elem: checkDisposition, singleton: true, extValues: true},
+		"Content-Location":    {elem: checkURIReference, singleton: true},
+		"Content-Type":        {elem: checkMediaType, singleton: true},
+		"Date":                {elem: checkHTTPDate, singleton: true},
+		"Etag":                {elem: checkEntityTag, singleton: true},
+		"Forwarded":           {elem: checkForwardedElem, list: true, nonEmpty: true},
+		"If-Match":            {elem: checkEntityTagOrStar, list: true, nonEmpty: true},
+		"If-None-Match":       {elem: checkEntityTagOrStar, list: true, nonEmpty: true},
+		"Link":                {elem: checkLinkValue, list: true, extValues: true},
+		"Link-Template":       {sf: checkSFList},
+		"Max-Forwards":        {elem: checkDigits, singleton: true},
+		"Prefer":              {elem: checkPreference, list: true, nonEmpty: true},
+		"Preference-Applied":  {elem: checkAppliedPref, list: true, nonEmpty: true},
+		"Priority":            {sf: checkSFDictionary},
+		"Proxy-Authenticate":  {elem: checkChallenge, list: true, nonEmpty: true},
+		"Proxy-Authorization": {elem: checkCredentials, singleton: true},
+		"Retry-After":         {elem: checkRetryAfter, singleton: true},
+		"Server":              {elem: checkProducts, singleton: true},
+		"Te":                  {elem: checkTCoding, list: true, weights: true},
+		"User-Agent":          {elem: checkProducts, singleton: true},
+		"Vary":                {elem: checkToken, list: true, nonEmpty: true},
+		"Via":                 {elem: checkViaElem, list: true, nonEmpty: true},
+		"Warning":             {elem: checkWarningElem, list: true, nonEmpty: true},
+		"Www-Authenticate":    {elem: checkChallenge, list: true, nonEmpty: true},
+		"X-Forwarded-For":     {elem: checkHostOrPseudonym, list: true, nonEmpty: true},
+		"X-Forwarded-Host":    {elem: checkHostOrPseudonym, list: true, nonEmpty: true},
+		"X-Forwarded-Port":    {elem: checkDigits, list: true, nonEmpty: true},
+		"X-Forwarded-Proto":   {elem: checkToken, list: true, nonEmpty: true},
+	}
+}
+
+func (l Limits) checkHeader(name string, values []string, rfc9110 bool) error {
+	for line, v := range values {
+		for i := 0; i < len(v); i++ {
+			if !isQuotable(v[i]) {
+				return &SyntaxError{name, line + 1, i, fmt.Sprintf("bad character %q", v[i])}
+			}
+		}
+	}
+	g, ok := grammars[name]
+	if !ok {
+		return nil
+	}
+	if l.tooLong(values) {
+		line, offset := 0, l.MaxBytes
+		for offset > len(values[line]) {
+			offset -= len(values[line])
+			line++
+		}
+		return &SyntaxError{name, line + 1, offset,
+			fmt.Sprintf("longer than %d bytes", l.MaxBytes)}
+	}
+	if rfc9110 && name == "Vary" {
+		// RFC 9110 changed Vary from "*" / 1#field-name to #( "*" / field-name ).
+		g.nonEmpty = false
+	}
+	if g.singleton && len(values) > 1 {
+		return &SyntaxError{name, 2, 0, "duplicate field line for a singleton header"}
+	}
+	if g.sf != nil {
+		if err := g.sf(l, values); err != nil {
+			e, ok := err.(*sfError)
+			if !ok {
+				return &SyntaxError{name, 1, 0, err.Error()}
+			}
+			line, offset := sfErrorPosition(values, e.offset)
+			return &SyntaxError{name, line + 1, offset, e.msg}
+		}
+		return nil
+	}
+	c := &checker{name: name, values: values, limits: l,
+		extValues: g.extValues, weights: g.weights,
+		weightLast: g.weights && rfc9110}
+	if !g.list {
+		for c.line = range values {
+			c.v = skipWS(values[c.line])
+			if err := g.elem(c); err != nil {
+				return err
+			}
+			if err := c.end(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	n, err := c.list(g.elem)
+	if err != nil {
+		return err
+	}
+	if g.nonEmpty && n == 0 {
+		return &SyntaxError{name, 1, 0, "empty list"}
+	}
+	return nil
+}
+
+// A checker checks a header with the same scanning functions that the parsers
+// use, such as iterElems and consumeItem, and reports where the header
+// departs from its grammar.
+type checker struct {
+	name   string
+	values []string
+	line   int    // index of the field line being checked
+	v      string // the rest of that line
+	limits Limits
+
+	extValues bool
+	weights   bool
+
+	// weightLast means no parameters may follow a weight, as in RFC 9110,
+	// which dropped the accept-ext of RFC 7231. sawWeight tracks this
+	// within one element.
+	weightLast bool
+	sawWeight  bool
+}
+
+func (c *checker) errorf(format string, args ...interface{}) error {
+	return c.errorAt(c.v, format, args...)
+}
+
+// errorAt is like errorf, but reports the error at rest, which must be
+// a suffix of the current line, instead of at c.v.
+func (c *checker) errorAt(rest, format string, args ...interface{}) error {
+	return &SyntaxError{c.name, c.line + 1, len(c.values[c.line]) - len(rest),
+		fmt.Sprintf(format, args...)}
+}
+
+func (c *checker) unexpected(what string) error {
+	if c.v == "" {
+		return c.errorf("expected %s, got end of line", what)
+	}
+	return c.errorf("expected %s, got %q", what, c.v[0])
+}
+
+func (c *checker) end() error {
+	c.ows()
+	if c.v != "" {
+		return c.errorf("unexpected %q", c.v[0])
+	}
+	return nil
+}
+
+func (c *checker) ows() {
+	c.v = skipWS(c.v)
+}
+
+func (c *checker) rws() error {
+	rest := skipWS(c.v)
+	if len(rest) == len(c.v) {
+		return c.unexpected("whitespace")
+	}
+	c.v = rest
+	return nil
+}
+
+func (c *checker) expect(b byte) error {
+	if peek(c.v) != b {
+		return c.unexpected(fmt.Sprintf("%q", b))
+	}
+	c.v = c.v[1:]
+	return nil
+}
+
+// list checks the elements of a comma-separated list, iterating over them
+// with iterElems like the parsers do, and returns their number. Empty
+// elements are allowed and not counted.
+func (c *checker) list(elem func(c *checker) error) (n int, err error) {
+	for v, vs := iterElems("", c.values); v != ""; v, vs = iterElems(c.v, vs) {
+		c.line, c.v = len(c.values)-len(vs)-1, v
+		if c.limits.elemsDone(n) {
+			return n, c.errorf("more than %d elements", c.limits.MaxElems)
+		}
+		if err := elem(c); err != nil {
+			return n, err
+		}
+		n++
+		c.ows()
+		if c.v != "" && c.v[0] != ',' {
+			return n, c.unexpected("','")
+		}
+	}
+	return n, nil
+}
+
+// token checks the token at the beginning of the item (see consumeItem)
+// in c.v, and returns it. Anything in the item after the token is left
+// for the caller.
+func (c *checker) token() (string, error) {
+	item, _ := consumeItem(c.v)
+	n := 0
+	for n < len(item) && byteClass[item[n]] == cTokenOK {
+		n++
+	}
+	if n == 0 {
+		return "", c.unexpected("token")
+	}
+	token := item[:n]
+	c.v = c.v[n:]
+	return token, nil
+}
+
+func (c *checker) quoted() (string, error) {
+	if peek(c.v) != '"' {
+		return "", c.unexpected(`'"'`)
+	}
+	text, rest, ok := Limits{}.consumeDelimitedOK(c.v, '"', '"')
+	if !ok {
+		return "", c.errorf("unbalanced quote")
+	}
+	c.v = rest
+	return text, nil
+}
+
+func (c *checker) comment() error {
+	if peek(c.v) != '(' {
+		return c.unexpected("'('")
+	}
+	_, rest, ok := c.limits.consumeDelimitedOK(c.v, '(', ')')
+	switch {
+	case ok:
+		c.v = rest
+		return nil
+	case rest != "":
+		return c.errorAt(rest, "comments nested deeper than %d", c.limits.MaxNesting)
+	default:
+		return c.errorf("unbalanced parenthesis")
+	}
+}
+
+// word checks a token or a quoted string, returning its text (unescaped).
+func (c *checker) word() (string, error) {
+	if peek(c.v) == '"' {
+		return c.quoted()
+	}
+	return c.token()
+}
+
+// param checks a parameter after the semicolon and any whitespace.
+func (c *checker) param(valueOptional bool) error {
+	if c.sawWeight {
+		return c.errorf("parameter after weight")
+	}
+	name, err := c.token()
+	if err != nil {
+		return err
+	}
+	c.ows()
+	if peek(c.v) != '=' {
+		if valueOptional {
+			return nil
+		}
+		return c.unexpected("'='")
+	}
+	c.v = skipWS(c.v[1:])
+	valueStart := c.v
+	value, err := c.word()
+	if err != nil {
+		return err
+	}
+	name = strings.ToLower(name)
+	switch {
+	case c.extValues && strings.HasSuffix(name, "*"):
+		if _, _, err := DecodeExtValue(value); err != nil {
+			return c.errorAt(valueStart, "%v", err)
+		}
+	case c.weights && name == "q":
+		if !isQValue(value) {
+			return c.errorAt(valueStart, "bad weight %q", value)
+		}
+		c.sawWeight = c.weightLast
+	}
+	return nil
+}
+
+// params checks any parameters, each preceded by a semicolon.
+func (c *checker) params(valueOptional bool) error {
+	c.sawWeight = false
+	for n := 0; ; n++ {
+		rest := skipWS(c.v)
+		if peek(rest) != ';' {
+			return nil
+		}
+		if c.limits.paramsDone(n) {
+			return c.errorAt(rest, "more than %d parameters", c.limits.MaxParams)
+		}
+		c.v = skipWS(rest[1:])
+		if err := c.param(valueOptional); err != nil {
+			return err
+		}
+	}
+}
+
+// isQValue reports whether v is a qvalue (RFC 9110 Section 12.4.2).
+func isQValue(v string) bool {
+	if v == "" || len(v) > 5 || (v[0] != '0' && v[0] != '1') {
+		return false
+	}
+	if len(v) == 1 {
+		return true
+	}
+	if v[1] != '.' {
+		return false
+	}
+	for i := 2; i < len(v); i++ {
+		if !isDigit(v[i]) || (v[0] == '1' && v[i] != '0') {
+			return false
+		}
+	}
+	return true
+}
+
+func checkToken(c *checker) error {
+	_, err := c.token()
+	return err
+}
+
+func checkMediaType(c *checker) error {
+	if err := checkMediaTypeName(c); err != nil {
+		return err
+	}
+	return c.params(false)
+}
+
+func checkMediaRange(c *checker) error {
+	if err := checkMediaTypeName(c); err != nil {
+		return err
+	}
+	return c.params(true)
+}
+
+func checkMediaTypeName(c *checker) error {
+	if _, err := c.token(); err != nil {
+		return err
+	}
+	if err := c.expect('/'); err != nil {
+		return err
+	}
+	_, err := c.token()
+	return err
+}
+
+func checkDisposition(c *checker) error {
+	if _, err := c.token(); err != nil {
+		return err
+	}
+	return c.params(false)
+}
+
+func checkTCoding(c *checker) error {
+	if _, err := c.token(); err != nil {
+		return err
+	}
+	return c.params(false)
+}
+
+func checkDirective(c *checker) error {
+	if _, err := c.token(); err != nil {
+		return err
+	}
+	if peek(c.v) == '=' {
+		c.v = c.v[1:]
+		_, err := c.word()
+		return err
+	}
+	return nil
+}
+
+func checkAppliedPref(c *checker) error {
+	if _, err := c.token(); err != nil {
+		return err
+	}
+	rest := skipWS(c.v)
+	if peek(rest) != '=' {
+		return nil
+	}
+	c.v = skipWS(rest[1:])
+	_, err := c.word()
+	return err
+}
+
+func checkPreference(c *checker) error {
+	if err := checkAppliedPref(c); err != nil {
+		return err
+	}
+	return c.params(true)
+}
+
+func checkDigits(c *checker) error {
+	n := 0
+	for n < len(c.v) && isDigit(c.v[n]) {
+		n++
+	}
+	if n == 0 {
+		return c.unexpected("digit")
+	}
+	c.v = c.v[n:]
+	return nil
+}
+
+func checkHTTPDate(c *checker) error {
+	v := strings.TrimRight(c.v, " \t")
+	if _, err := http.ParseTime(v); err != nil {
+		return c.errorf("bad HTTP-date %q", v)
+	}
+	c.v = c.v[len(v):]
+	return nil
+}
+
+func checkRetryAfter(c *checker) error {
+	if isDigit(peek(c.v)) {
+		return checkDigits(c)
+	}
+	return checkHTTPDate(c)
+}
+
+func checkEntityTag(c *checker) error {
+	start := c.v
+	if strings.HasPrefix(c.v, "W/") {
+		c.v = c.v[2:]
+	}
+	if err := c.expect('"'); err != nil {
+		return err
+	}
+	opaque, rest := consumeTo(c.v, '"', false)
+	if len(opaque) == len(c.v) {
+		c.v = start
+		return c.errorf("unterminated entity-tag")
+	}
+	if i := strings.IndexAny(opaque, " \t"); i >= 0 {
+		return c.errorAt(c.v[i:], "bad character %q in entity-tag", opaque[i])
+	}
+	c.v = rest
+	return nil
+}
+
+func checkEntityTagOrStar(c *checker) error {
+	if peek(c.v) == '*' {
+		c.v = c.v[1:]
+		return nil
+	}
+	return checkEntityTag(c)
+}
+
+func checkProducts(c *checker) error {
+	if err := checkProduct(c); err != nil {
+		return err
+	}
+	for n := 1; ; {
+		if c.v == "" {
+			return nil
+		}
+		if err := c.rws(); err != nil {
+			return err
+		}
+		if c.v == "" {
+			return nil
+		}
+		var err error
+		if peek(c.v) == '(' {
+			err = c.comment()
+		} else if c.limits.elemsDone(n) {
+			err = c.errorf("more than %d products", c.limits.MaxElems)
+		} else {
+			err = checkProduct(c)
+			n++
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func checkProduct(c *checker) error {
+	if _, err := c.token(); err != nil {
+		return err
+	}
+	if peek(c.v) == '/' {
+		c.v = c.v[1:]
+		_, err := c.token()
+		return err
+	}
+	return nil
+}
+
+// checkHostOrPseudonym checks a received-by (RFC 9110 Section 7.6.3),
+// a warn-agent (RFC 9111 Section 5.5), or a node in X-Forwarded-For, loosely.
+func checkHostOrPseudonym(c *checker) error {
+	by, _ := consumeReceivedBy(c.v)
+	n := 0
+	for n < len(by) && (byteClass[by[n]] == cTokenOK || strings.IndexByte(":[]", by[n]) >= 0) {
+		n++
+	}
+	if n == 0 {
+		return c.unexpected("host or pseudonym")
+	}
+	c.v = c.v[n:]
+	return nil
+}
+
+func checkViaElem(c *checker) error {
+	if err := checkProduct(c); err != nil { // received-protocol
+		return err
+	}
+	if err := c.rws(); err != nil {
+		return err
+	}
+	if err := checkHostOrPseudonym(c); err != nil {
+		return err
+	}
+	if rest := skipWS(c.v); peek(rest) == '(' && len(rest) < len(c.v) {
+		c.v = rest
+		return c.comment()
+	}
+	return nil
+}
+
+func checkWarningElem(c *checker) error {
+	start := c.v
+	if err := checkDigits(c); err != nil {
+		return err
+	}
+	if len(start)-len(c.v) != 3 {
+		c.v = start
+		return c.errorf("warn-code must be 3 digits")
+	}
+	if err := c.expect(' '); err != nil {
+		return err
+	}
+	if err := checkHostOrPseudonym(c); err != nil {
+		return err
+	}
+	if err := c.expect(' '); err != nil {
+		return err
+	}
+	if _, err := c.quoted(); err != nil {
+		return err
+	}
+	if strings.HasPrefix(c.v, ` "`) {
+		c.v = c.v[1:]
+		_, err := c.quoted()
+		return err
+	}
+	return nil
+}
+
+func checkForwardedElem(c *checker) error {
+	for {
+		if _, err := c.token(); err != nil {
+			return err
+		}
+		if err := c.expect('='); err != nil {
+			return err
+		}
+		if _, err := c.word(); err != nil {
+			return err
+		}
+		if peek(c.v) != ';' {
+			return nil
+		}
+		c.v = c.v[1:]
+	}
+}
+
+func checkLinkValue(c *checker) error {
+	if peek(c.v) != '<' {
+		return c.unexpected("'<'")
+	}
+	ref, rest := consumeTo(c.v[1:], '>', false)
+	if len(ref) == len(c.v)-1 {
+		return c.errorf("unterminated URI reference")
+	}
+	c.v = c.v[1:]
+	if err := c.uriReference(ref); err != nil {
+		return err
+	}
+	c.v = rest
+	return c.params(true)
+}
+
+func checkURIReference(c *checker) error {
+	ref := strings.TrimRight(c.v, " \t")
+	if err := c.uriReference(ref); err != nil {
+		return err
+	}
+	c.v = c.v[len(ref):]
+	return nil
+}
+
+// uriReference checks ref, which starts at c.v, as a URI reference.
+func (c *checker) uriReference(ref string) error {
+	for i := 0; i < len(ref); i++ {
+		if ref[i] <= ' ' || ref[i] >= 0x7F || strings.IndexByte(`<>"`, ref[i]) >= 0 {
+			return c.errorAt(c.v[i:], "bad character %q in URI reference", ref[i])
+		}
+	}
+	if _, err := url.Parse(ref); err != nil {
+		return c.errorf("bad URI reference: %v", err)
+	}
+	return nil
+}
+
+func checkChallenge(c *checker) error {
+	return checkAuth(c, true)
+}
+
+func checkCredentials(c *checker) error {
+	return checkAuth(c, false)
+}
+
+// checkAuth checks a challenge or credentials (RFC 9110 Section 11.3),
+// finding its parts with consumeToken68 and detectAuthParam like consumeAuth.
+// In a challenge, a comma may also separate the next challenge, which is
+// left for the caller.
+func checkAuth(c *checker, challenge bool) error {
+	if _, err := c.token(); err != nil { // auth-scheme
+		return err
+	}
+	if c.v == "" || c.v[0] == ',' {
+		return nil
+	}
+	if err := c.rws(); err != nil {
+		return err
+	}
+	if c.v == "" || c.v[0] == ',' {
+		return nil
+	}
+	if token68, rest := consumeToken68(c.v); isToken68(token68) {
+		c.v = rest
+		return nil
+	}
+	for {
+		if _, err := c.token(); err != nil {
+			return err
+		}
+		c.ows()
+		if err := c.expect('='); err != nil {
+			return err
+		}
+		c.ows()
+		if _, err := c.word(); err != nil {
+			return err
+		}
+		rest := skipWS(c.v)
+		if peek(rest) != ',' || challenge && !detectAuthParam(rest[1:]) {
+			return nil // the end of the element, or the next challenge
+		}
+		c.v = skipWSAnd(rest, ',')
+		if c.v == "" {
+			return nil
+		}
+	}
+}
+
+// isToken68 reports whether s is a token68 (RFC 9110 Section 11.2).
+func isToken68(s string) bool {
+	s = strings.TrimRight(s, "=")
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isAlpha(s[i]) && !isDigit(s[i]) && strings.IndexByte("-._~+/", s[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func checkSFList(l Limits, values []string) error {
+	_, err := l.ParseList(values)
+	return err
+}
+
+func checkSFDictionary(l Limits, values []string) error {
+	_, err := l.ParseDictionary(values)
+	return err
+}
+
+// sfErrorPosition converts an offset into the combined field value,
+// as reported by the structured field parser, into a line and offset.
+func sfErrorPosition(values []string, offset int) (line, lineOffset int) {
+	joined := strings.Join(values, ", ")
+	offset += len(joined) - len(strings.TrimLeft(joined, " "))
+	for line, v := range values {
+		if offset <= len(v) || line == len(values)-1 {
+			return line, offset
+		}
+		offset -= len(v) + len(", ")
+		if offset < 0 {
+			return line + 1, 0
+		}
+	}
+	return 0, offset
+}